@@ -2,27 +2,39 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
-	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 
 	"era_sporta_bot_ruletka/config"
 	"era_sporta_bot_ruletka/internal/api"
 	"era_sporta_bot_ruletka/internal/api/handlers"
+	"era_sporta_bot_ruletka/internal/api/middleware"
 	"era_sporta_bot_ruletka/internal/bot"
 	"era_sporta_bot_ruletka/internal/db"
+	"era_sporta_bot_ruletka/internal/fraud"
+	"era_sporta_bot_ruletka/internal/health"
+	"era_sporta_bot_ruletka/internal/iphash"
+	"era_sporta_bot_ruletka/internal/logger"
+	"era_sporta_bot_ruletka/internal/metrics"
 	"era_sporta_bot_ruletka/internal/notifier"
 	"era_sporta_bot_ruletka/internal/repository"
 	"era_sporta_bot_ruletka/internal/service"
+	"era_sporta_bot_ruletka/internal/session"
+	"era_sporta_bot_ruletka/internal/telegram"
 
 	"github.com/gin-gonic/gin"
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/joho/godotenv"
 )
 
+const shutdownTimeout = 15 * time.Second
+
 func main() {
 	if err := run(); err != nil {
 		log.Fatal(err)
@@ -36,11 +48,12 @@ func run() error {
 	if err != nil {
 		return err
 	}
+	logger.Setup(cfg.LogLevel, cfg.LogFormat)
 	if cfg.BotToken == "" {
-		log.Fatal("BOT_TOKEN is required")
+		return errors.New("BOT_TOKEN is required")
 	}
 	if cfg.DatabaseURL == "" {
-		log.Fatal("DATABASE_URL is required")
+		return errors.New("DATABASE_URL is required")
 	}
 
 	ctx := context.Background()
@@ -49,45 +62,120 @@ func run() error {
 		return err
 	}
 	defer pool.Close()
-
-	// Bot for admin notifications
-	var adminNotify notifier.AdminNotifier
-	if tgBot, err := tgbotapi.NewBotAPI(cfg.BotToken); err == nil {
-		adminNotify = bot.NewAdminNotifierAdapter(bot.NewNotifier(tgBot, cfg.AdminTelegramChatID))
-	} else {
-		log.Printf("Warning: could not init bot for admin notifications: %v", err)
+	if err := metrics.RegisterPool(pool); err != nil {
+		return err
 	}
 
+	ctx, cancel := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
 	userRepo := repository.NewUserRepository(pool)
 	prizeRepo := repository.NewPrizeRepository(pool)
 	spinRepo := repository.NewSpinRepository(pool)
 
 	userSvc := service.NewUserService(userRepo, spinRepo)
-	rouletteSvc := service.NewRouletteService(pool, prizeRepo, spinRepo, userRepo, cfg.RouletteSpinLimit)
+	funnelSvc := service.NewFunnelService(repository.NewEventRepository(pool))
+	statsLoc, err := time.LoadLocation(cfg.StatsTimezone)
+	if err != nil {
+		return fmt.Errorf("STATS_TIMEZONE: %w", err)
+	}
+	notifyRouter, err := notifier.ParseRouter(cfg.AdminTelegramChatID, cfg.NotifyDestinations, cfg.NotifyRoutes)
+	if err != nil {
+		return err
+	}
+	// Уведомления кладутся в очередь, доставляет их процесс бота
+	outboxSvc := service.NewOutboxService(repository.NewOutboxRepository(pool), notifyRouter)
+	templateSvc := service.NewTemplateService(repository.NewTemplateRepository(pool), statsLoc)
+	// Webhook тоже только кладутся в очередь, отправляет их процесс бота
+	webhookSvc, err := service.NewWebhookService(repository.NewWebhookRepository(pool), cfg.WebhookURLs, cfg.WebhookEvents)
+	if err != nil {
+		return err
+	}
+	adminNotify, spinBatcher, err := bot.NewSpinNotifier(outboxSvc, templateSvc, cfg.AdminSpinNotifyMode, time.Duration(cfg.AdminSpinBatchMin)*time.Minute, statsLoc)
+	if err != nil {
+		return err
+	}
+	fraudRules, err := fraud.ParseRules(cfg.FraudRules)
+	if err != nil {
+		return err
+	}
+	ipHasher, err := iphash.New(cfg.IPHashSecret, cfg.BotToken, time.Duration(cfg.IPHashRotationDays)*24*time.Hour)
+	if err != nil {
+		return err
+	}
+	rouletteSvc := service.NewRouletteService(pool, prizeRepo, spinRepo, userRepo, fraud.NewScorer(fraudRules, spinRepo, ipHasher), ipHasher, cfg.RouletteSpinLimit, time.Duration(cfg.VoucherTTLDays)*24*time.Hour,
+		time.Duration(cfg.RouletteLockTTLSec)*time.Second)
+
+	initDataMaxAge := time.Duration(cfg.InitDataMaxAgeSec) * time.Second
+	sessions := session.NewManager(cfg.SessionSecret, cfg.BotToken,
+		time.Duration(cfg.SessionTTLSec)*time.Second, time.Duration(cfg.SessionRefreshTTLSec)*time.Second,
+		repository.NewSessionRepository(pool))
+	var nonces *session.NonceStore
+	if cfg.SpinNonceRequired {
+		nonces = session.NewNonceStore(sessions.TTL())
+	}
+
+	validator := telegram.NewValidator(cfg.BotToken, initDataMaxAge, cfg.TelegramTestEnv)
 
-	authHandler := handlers.NewAuthHandler(userSvc, cfg.BotToken, cfg.RouletteSpinLimit)
+	authHandler := handlers.NewAuthHandler(userSvc, funnelSvc, validator, cfg.RouletteSpinLimit, sessions)
 	userHandler := handlers.NewUserHandler(userSvc, cfg.RouletteSpinLimit)
-	rouletteHandler := handlers.NewRouletteHandler(rouletteSvc, userSvc, adminNotify)
+	adminHandler := handlers.NewAdminHandler(
+		service.NewStatsService(repository.NewStatsRepository(pool), statsLoc),
+		service.NewExportService(repository.NewExportRepository(pool), statsLoc),
+		webhookSvc,
+		service.NewModerationService(repository.NewModerationRepository(pool)),
+	)
+	rouletteHandler := handlers.NewRouletteHandler(rouletteSvc, userSvc, adminNotify, bot.NewUserSpinNotifier(outboxSvc, statsLoc, cfg.ClubAddress), bot.NewFraudAlertNotifier(outboxSvc, templateSvc), webhookSvc)
+
+	checker := health.NewChecker()
+	checker.Add("database", health.DBCheck(pool))
+	checker.Add("migrations", health.MigrationCheck(pool))
+	checker.Add("telegram", health.TelegramCheck(telegram.NewMeChecker(cfg.BotToken, time.Minute)))
+	if cfg.RedisURL != "" {
+		checker.AddOptional("redis", health.RedisCheck(cfg.RedisURL))
+	}
 
-	router := api.NewRouter(authHandler, userHandler, rouletteHandler, cfg.BotToken)
+	security := middleware.SecurityConfig{
+		AllowedOrigins: cfg.CORSAllowedOrigins,
+		CORSMaxAge:     time.Duration(cfg.CORSMaxAgeSec) * time.Second,
+		HSTSMaxAge:     time.Duration(cfg.HSTSMaxAgeSec) * time.Second,
+	}
+	router := api.NewRouter(authHandler, userHandler, rouletteHandler, adminHandler, middleware.NewAuthMiddleware(sessions, nonces), checker, security, cfg.AdminAPIToken)
 
-	app := gin.Default()
+	app := gin.New()
+	if err := api.ConfigureClientIP(app, cfg.TrustedProxies, cfg.TrustedPlatform); err != nil {
+		return err
+	}
+	app.Use(gin.Recovery(), middleware.RequestID(), middleware.AccessLog())
 	router.Setup(app)
 
+	metricsSrv := metrics.Serve(fmt.Sprintf(":%d", cfg.MetricsPort))
+	defer metricsSrv.Shutdown(context.Background())
+
 	addr := fmt.Sprintf(":%d", cfg.APIPort)
 	srv := &http.Server{Addr: addr, Handler: app}
 
 	go func() {
-		log.Printf("API listening on %s", addr)
+		slog.Info("API listening", "addr", addr)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Printf("API error: %v", err)
+			slog.Error("API server failed", "error", err)
 		}
 	}()
 
-	sig := make(chan os.Signal, 1)
-	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
-	<-sig
+	var wg sync.WaitGroup
+	if spinBatcher != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			spinBatcher.Run(ctx)
+		}()
+	}
+
+	<-ctx.Done()
 
-	shutdownCtx := context.Background()
-	return srv.Shutdown(shutdownCtx)
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer shutdownCancel()
+	err = srv.Shutdown(shutdownCtx)
+	wg.Wait()
+	return err
 }
@@ -0,0 +1,27 @@
+// Команда apiclientgen генерирует типизированный клиент API для Mini App (web/api.js)
+// из internal/api/openapi/openapi.json. Запускается через go generate ./web.
+//
+//	go run ./cmd/apiclientgen -o web/api.js
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"era_sporta_bot_ruletka/internal/api/openapi"
+	"era_sporta_bot_ruletka/internal/api/openapi/jsclient"
+)
+
+func main() {
+	out := flag.String("o", "web/api.js", "куда записать клиент")
+	flag.Parse()
+
+	src, err := jsclient.Generate(openapi.Spec)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,246 @@
+// Всё в одном процессе: API, бот и Mini App (web/ встроен в бинарник).
+// Запуск из корня проекта: go run ./cmd/app
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"era_sporta_bot_ruletka/config"
+	"era_sporta_bot_ruletka/internal/api"
+	"era_sporta_bot_ruletka/internal/api/handlers"
+	"era_sporta_bot_ruletka/internal/api/middleware"
+	"era_sporta_bot_ruletka/internal/bot"
+	"era_sporta_bot_ruletka/internal/db"
+	"era_sporta_bot_ruletka/internal/fraud"
+	"era_sporta_bot_ruletka/internal/health"
+	"era_sporta_bot_ruletka/internal/iphash"
+	"era_sporta_bot_ruletka/internal/logger"
+	"era_sporta_bot_ruletka/internal/metrics"
+	"era_sporta_bot_ruletka/internal/notifier"
+	"era_sporta_bot_ruletka/internal/repository"
+	"era_sporta_bot_ruletka/internal/service"
+	"era_sporta_bot_ruletka/internal/session"
+	"era_sporta_bot_ruletka/internal/telegram"
+	"era_sporta_bot_ruletka/internal/webhook"
+	"era_sporta_bot_ruletka/web"
+
+	"github.com/gin-gonic/gin"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/joho/godotenv"
+)
+
+const shutdownTimeout = 15 * time.Second
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	_ = godotenv.Load()
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	logger.Setup(cfg.LogLevel, cfg.LogFormat)
+	if cfg.BotToken == "" {
+		return errors.New("BOT_TOKEN is required")
+	}
+	if cfg.DatabaseURL == "" {
+		return errors.New("DATABASE_URL is required")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	pool, err := db.NewPool(ctx, cfg.DatabaseURL)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+	if err := metrics.RegisterPool(pool); err != nil {
+		return err
+	}
+
+	// Один BotAPI на бота и уведомления админу
+	tgBot, err := tgbotapi.NewBotAPI(cfg.BotToken)
+	if err != nil {
+		return err
+	}
+	slog.Info("bot authorized", "username", tgBot.Self.UserName)
+
+	userRepo := repository.NewUserRepository(pool)
+	prizeRepo := repository.NewPrizeRepository(pool)
+	spinRepo := repository.NewSpinRepository(pool)
+
+	userSvc := service.NewUserService(userRepo, spinRepo)
+	funnelSvc := service.NewFunnelService(repository.NewEventRepository(pool))
+	fraudRules, err := fraud.ParseRules(cfg.FraudRules)
+	if err != nil {
+		return err
+	}
+	ipHasher, err := iphash.New(cfg.IPHashSecret, cfg.BotToken, time.Duration(cfg.IPHashRotationDays)*24*time.Hour)
+	if err != nil {
+		return err
+	}
+	rouletteSvc := service.NewRouletteService(pool, prizeRepo, spinRepo, userRepo, fraud.NewScorer(fraudRules, spinRepo, ipHasher), ipHasher, cfg.RouletteSpinLimit, time.Duration(cfg.VoucherTTLDays)*24*time.Hour,
+		time.Duration(cfg.RouletteLockTTLSec)*time.Second)
+
+	statsLoc, err := time.LoadLocation(cfg.StatsTimezone)
+	if err != nil {
+		return fmt.Errorf("STATS_TIMEZONE: %w", err)
+	}
+	statsSvc := service.NewStatsService(repository.NewStatsRepository(pool), statsLoc)
+	exportSvc := service.NewExportService(repository.NewExportRepository(pool), statsLoc)
+	digestSchedule, err := bot.ParseDigestSchedule(cfg.DigestAt, cfg.DigestWeeklyDay)
+	if err != nil {
+		return err
+	}
+	reminderDays, err := bot.ParseReminderDays(cfg.ReminderDaysBefore)
+	if err != nil {
+		return err
+	}
+
+	notifyRouter, err := notifier.ParseRouter(cfg.AdminTelegramChatID, cfg.NotifyDestinations, cfg.NotifyRoutes)
+	if err != nil {
+		return err
+	}
+	adminNotifier := bot.NewNotifier(tgBot, notifyRouter)
+	templateSvc := service.NewTemplateService(repository.NewTemplateRepository(pool), statsLoc)
+	outboxSvc := service.NewOutboxService(repository.NewOutboxRepository(pool), notifyRouter)
+	outbox := bot.NewOutboxDispatcher(tgBot, outboxSvc, adminNotifier, userSvc, funnelSvc)
+	webhookSvc, err := service.NewWebhookService(repository.NewWebhookRepository(pool), cfg.WebhookURLs, cfg.WebhookEvents)
+	if err != nil {
+		return err
+	}
+	spinNotify, spinBatcher, err := bot.NewSpinNotifier(outboxSvc, templateSvc, cfg.AdminSpinNotifyMode, time.Duration(cfg.AdminSpinBatchMin)*time.Minute, statsLoc)
+	if err != nil {
+		return err
+	}
+	broadcastSvc := service.NewBroadcastService(repository.NewBroadcastRepository(pool))
+	// Рассылки, прерванные прошлым перезапуском, не продолжаются, чтобы не слать сообщения дважды
+	if n, err := broadcastSvc.FailInterrupted(ctx); err != nil {
+		slog.Warn("fail interrupted broadcasts", "error", err)
+	} else if n > 0 {
+		slog.Warn("interrupted broadcasts marked as failed", "count", n)
+	}
+	broadcaster := bot.NewBroadcaster(tgBot, broadcastSvc, userSvc, funnelSvc, adminNotifier, cfg.BroadcastRatePerSec)
+	reminderSvc := service.NewReminderService(repository.NewReminderRepository(pool), reminderDays, statsLoc)
+	reminders := bot.NewReminderScheduler(tgBot, reminderSvc, userSvc, funnelSvc, cfg.ClubAddress, time.Duration(cfg.ReminderIntervalMin)*time.Minute)
+	moderationSvc := service.NewModerationService(repository.NewModerationRepository(pool))
+	spinActions := bot.NewSpinActions(service.NewVoucherService(spinRepo, userRepo), moderationSvc, templateSvc, webhookSvc)
+	botHandler := bot.NewHandler(tgBot, userSvc, funnelSvc, webhookSvc, exportSvc, templateSvc, moderationSvc, spinActions, broadcaster, adminNotifier, cfg.WebAppURL, cfg.TelegramChannelID, cfg.TelegramChannelURL)
+
+	initDataMaxAge := time.Duration(cfg.InitDataMaxAgeSec) * time.Second
+	sessions := session.NewManager(cfg.SessionSecret, cfg.BotToken,
+		time.Duration(cfg.SessionTTLSec)*time.Second, time.Duration(cfg.SessionRefreshTTLSec)*time.Second,
+		repository.NewSessionRepository(pool))
+	var nonces *session.NonceStore
+	if cfg.SpinNonceRequired {
+		nonces = session.NewNonceStore(sessions.TTL())
+	}
+
+	validator := telegram.NewValidator(cfg.BotToken, initDataMaxAge, cfg.TelegramTestEnv)
+
+	authHandler := handlers.NewAuthHandler(userSvc, funnelSvc, validator, cfg.RouletteSpinLimit, sessions)
+	userHandler := handlers.NewUserHandler(userSvc, cfg.RouletteSpinLimit)
+	adminHandler := handlers.NewAdminHandler(statsSvc, exportSvc, webhookSvc, moderationSvc)
+	rouletteHandler := handlers.NewRouletteHandler(rouletteSvc, userSvc, spinNotify, bot.NewUserSpinNotifier(outboxSvc, statsLoc, cfg.ClubAddress), bot.NewFraudAlertNotifier(outboxSvc, templateSvc), webhookSvc)
+
+	checker := health.NewChecker()
+	checker.Add("database", health.DBCheck(pool))
+	checker.Add("migrations", health.MigrationCheck(pool))
+	checker.Add("telegram", health.TelegramCheck(telegram.NewMeChecker(cfg.BotToken, time.Minute)))
+	if cfg.RedisURL != "" {
+		checker.AddOptional("redis", health.RedisCheck(cfg.RedisURL))
+	}
+
+	security := middleware.SecurityConfig{
+		AllowedOrigins: cfg.CORSAllowedOrigins,
+		CORSMaxAge:     time.Duration(cfg.CORSMaxAgeSec) * time.Second,
+		HSTSMaxAge:     time.Duration(cfg.HSTSMaxAgeSec) * time.Second,
+	}
+	router := api.NewRouter(authHandler, userHandler, rouletteHandler, adminHandler, middleware.NewAuthMiddleware(sessions, nonces), checker, security, cfg.AdminAPIToken)
+
+	app := gin.New()
+	if err := api.ConfigureClientIP(app, cfg.TrustedProxies, cfg.TrustedPlatform); err != nil {
+		return err
+	}
+	app.Use(gin.Recovery(), middleware.RequestID(), middleware.AccessLog())
+	router.Setup(app)
+	if err := api.ServeWeb(app, web.FS); err != nil {
+		return err
+	}
+
+	metricsSrv := metrics.Serve(fmt.Sprintf(":%d", cfg.MetricsPort))
+	defer metricsSrv.Shutdown(context.Background())
+
+	addr := fmt.Sprintf(":%d", cfg.APIPort)
+	srv := &http.Server{Addr: addr, Handler: app}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		slog.Info("app listening", "addr", addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("HTTP server failed", "error", err)
+			cancel()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		bot.Run(logger.WithAttrs(ctx, slog.String(logger.KeyComponent, "bot")), tgBot, botHandler)
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		bot.NewDigestScheduler(adminNotifier, statsSvc, digestSchedule).Run(logger.WithAttrs(ctx, slog.String(logger.KeyComponent, "digest")))
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		outbox.Run(logger.WithAttrs(ctx, slog.String(logger.KeyComponent, "outbox")))
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		reminders.Run(logger.WithAttrs(ctx, slog.String(logger.KeyComponent, "reminders")))
+	}()
+	if webhookSvc.Enabled() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			webhook.NewDispatcher(webhookSvc, cfg.WebhookSecret).Run(logger.WithAttrs(ctx, slog.String(logger.KeyComponent, "webhooks")))
+		}()
+	}
+	if spinBatcher != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			spinBatcher.Run(ctx)
+		}()
+	}
+
+	<-ctx.Done()
+	slog.Info("shutting down")
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer shutdownCancel()
+	err = srv.Shutdown(shutdownCtx)
+	wg.Wait()
+	broadcaster.Wait()
+	return err
+}
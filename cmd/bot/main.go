@@ -2,16 +2,28 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 
 	"era_sporta_bot_ruletka/config"
 	"era_sporta_bot_ruletka/internal/bot"
 	"era_sporta_bot_ruletka/internal/db"
+	"era_sporta_bot_ruletka/internal/health"
+	"era_sporta_bot_ruletka/internal/logger"
+	"era_sporta_bot_ruletka/internal/metrics"
+	"era_sporta_bot_ruletka/internal/notifier"
 	"era_sporta_bot_ruletka/internal/repository"
 	"era_sporta_bot_ruletka/internal/service"
+	"era_sporta_bot_ruletka/internal/telegram"
+	"era_sporta_bot_ruletka/internal/webhook"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/joho/godotenv"
@@ -30,53 +42,123 @@ func run() error {
 	if err != nil {
 		return err
 	}
+	logger.Setup(cfg.LogLevel, cfg.LogFormat)
 	if cfg.BotToken == "" {
-		log.Fatal("BOT_TOKEN is required")
+		return errors.New("BOT_TOKEN is required")
 	}
 	if cfg.DatabaseURL == "" {
-		log.Fatal("DATABASE_URL is required")
+		return errors.New("DATABASE_URL is required")
 	}
 
-	ctx := context.Background()
+	ctx := logger.WithAttrs(context.Background(), slog.String(logger.KeyComponent, "bot"))
 	pool, err := db.NewPool(ctx, cfg.DatabaseURL)
 	if err != nil {
 		return err
 	}
 	defer pool.Close()
+	if err := metrics.RegisterPool(pool); err != nil {
+		return err
+	}
 
 	tgBot, err := tgbotapi.NewBotAPI(cfg.BotToken)
 	if err != nil {
 		return err
 	}
-	log.Printf("Bot authorized as @%s", tgBot.Self.UserName)
+	slog.Info("bot authorized", "username", tgBot.Self.UserName)
 
 	userRepo := repository.NewUserRepository(pool)
 	spinRepo := repository.NewSpinRepository(pool)
 	userSvc := service.NewUserService(userRepo, spinRepo)
+	funnelSvc := service.NewFunnelService(repository.NewEventRepository(pool))
 
-	notifier := bot.NewNotifier(tgBot, cfg.AdminTelegramChatID)
-	handler := bot.NewHandler(tgBot, userSvc, notifier, cfg.WebAppURL, cfg.TelegramChannelID, cfg.TelegramChannelURL)
+	statsLoc, err := time.LoadLocation(cfg.StatsTimezone)
+	if err != nil {
+		return fmt.Errorf("STATS_TIMEZONE: %w", err)
+	}
+	digestSchedule, err := bot.ParseDigestSchedule(cfg.DigestAt, cfg.DigestWeeklyDay)
+	if err != nil {
+		return err
+	}
+	reminderDays, err := bot.ParseReminderDays(cfg.ReminderDaysBefore)
+	if err != nil {
+		return err
+	}
 
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
-	updates := tgBot.GetUpdatesChan(u)
+	exportSvc := service.NewExportService(repository.NewExportRepository(pool), statsLoc)
+	notifyRouter, err := notifier.ParseRouter(cfg.AdminTelegramChatID, cfg.NotifyDestinations, cfg.NotifyRoutes)
+	if err != nil {
+		return err
+	}
+	adminNotifier := bot.NewNotifier(tgBot, notifyRouter)
+	broadcastSvc := service.NewBroadcastService(repository.NewBroadcastRepository(pool))
+	// Рассылки, прерванные прошлым перезапуском, не продолжаются, чтобы не слать сообщения дважды
+	if n, err := broadcastSvc.FailInterrupted(ctx); err != nil {
+		slog.Warn("fail interrupted broadcasts", "error", err)
+	} else if n > 0 {
+		slog.Warn("interrupted broadcasts marked as failed", "count", n)
+	}
+	broadcaster := bot.NewBroadcaster(tgBot, broadcastSvc, userSvc, funnelSvc, adminNotifier, cfg.BroadcastRatePerSec)
+	templateSvc := service.NewTemplateService(repository.NewTemplateRepository(pool), statsLoc)
+	webhookSvc, err := service.NewWebhookService(repository.NewWebhookRepository(pool), cfg.WebhookURLs, cfg.WebhookEvents)
+	if err != nil {
+		return err
+	}
+	moderationSvc := service.NewModerationService(repository.NewModerationRepository(pool))
+	spinActions := bot.NewSpinActions(service.NewVoucherService(spinRepo, userRepo), moderationSvc, templateSvc, webhookSvc)
+	handler := bot.NewHandler(tgBot, userSvc, funnelSvc, webhookSvc, exportSvc, templateSvc, moderationSvc, spinActions, broadcaster, adminNotifier, cfg.WebAppURL, cfg.TelegramChannelID, cfg.TelegramChannelURL)
 
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
+	checker := health.NewChecker()
+	checker.Add("database", health.DBCheck(pool))
+	checker.Add("migrations", health.MigrationCheck(pool))
+	checker.Add("telegram", health.TelegramCheck(telegram.NewMeChecker(cfg.BotToken, time.Minute)))
 
+	// Служебный HTTP: /metrics для Prometheus, /healthz и /readyz для оркестратора
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	mux.Handle("/healthz", health.LivenessHandler())
+	mux.Handle("/readyz", checker.ReadinessHandler())
+	srv := &http.Server{Addr: fmt.Sprintf(":%d", cfg.BotHTTPPort), Handler: mux}
 	go func() {
-		sig := make(chan os.Signal, 1)
-		signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
-		<-sig
-		cancel()
+		slog.Info("bot HTTP listening", "addr", srv.Addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("bot HTTP server failed", "error", err)
+		}
 	}()
+	defer srv.Shutdown(context.Background())
 
-	for {
-		select {
-		case <-ctx.Done():
-			return nil
-		case update := <-updates:
-			handler.HandleUpdate(ctx, update)
-		}
+	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	// Сводки в админский чат шлёт процесс бота
+	digest := bot.NewDigestScheduler(adminNotifier, service.NewStatsService(repository.NewStatsRepository(pool), statsLoc), digestSchedule)
+	reminderSvc := service.NewReminderService(repository.NewReminderRepository(pool), reminderDays, statsLoc)
+	reminders := bot.NewReminderScheduler(tgBot, reminderSvc, userSvc, funnelSvc, cfg.ClubAddress, time.Duration(cfg.ReminderIntervalMin)*time.Minute)
+	outbox := bot.NewOutboxDispatcher(tgBot, service.NewOutboxService(repository.NewOutboxRepository(pool), notifyRouter), adminNotifier, userSvc, funnelSvc)
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		digest.Run(logger.WithAttrs(ctx, slog.String(logger.KeyComponent, "digest")))
+	}()
+	go func() {
+		defer wg.Done()
+		reminders.Run(logger.WithAttrs(ctx, slog.String(logger.KeyComponent, "reminders")))
+	}()
+	go func() {
+		defer wg.Done()
+		outbox.Run(logger.WithAttrs(ctx, slog.String(logger.KeyComponent, "outbox")))
+	}()
+	// Webhook в CRM, поставленные в очередь API и ботом
+	if webhookSvc.Enabled() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			webhook.NewDispatcher(webhookSvc, cfg.WebhookSecret).Run(logger.WithAttrs(ctx, slog.String(logger.KeyComponent, "webhooks")))
+		}()
 	}
+
+	bot.Run(ctx, tgBot, handler)
+	wg.Wait()
+	broadcaster.Wait()
+	return nil
 }
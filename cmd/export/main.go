@@ -0,0 +1,94 @@
+// Команда export выгружает пользователей и спины в CSV или XLSX.
+//
+//	go run ./cmd/export -format xlsx -from 2026-01-01 -to 2026-01-31 -campaign promo -o january.xlsx
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"era_sporta_bot_ruletka/config"
+	"era_sporta_bot_ruletka/internal/db"
+	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/export"
+	"era_sporta_bot_ruletka/internal/repository"
+	"era_sporta_bot_ruletka/internal/service"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	formatFlag := flag.String("format", "csv", "формат: csv или xlsx")
+	fromFlag := flag.String("from", "", "начало периода: YYYY-MM-DD или RFC 3339")
+	toFlag := flag.String("to", "", "конец периода включительно: YYYY-MM-DD (или RFC 3339, не включительно)")
+	campaign := flag.String("campaign", "", "только пользователи и спины этой кампании")
+	out := flag.String("o", "", "файл для записи (по умолчанию era_sporta_export_<дата>.<формат>, \"-\" — stdout)")
+	flag.Parse()
+
+	_ = godotenv.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if cfg.DatabaseURL == "" {
+		return fmt.Errorf("DATABASE_URL is required")
+	}
+	loc, err := time.LoadLocation(cfg.StatsTimezone)
+	if err != nil {
+		return fmt.Errorf("STATS_TIMEZONE: %w", err)
+	}
+
+	format, err := export.ParseFormat(*formatFlag)
+	if err != nil {
+		return err
+	}
+	filter := domain.ExportFilter{Campaign: *campaign}
+	if filter.From, err = domain.ParseDateBound(*fromFlag, false, loc); err != nil {
+		return err
+	}
+	if filter.To, err = domain.ParseDateBound(*toFlag, true, loc); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	pool, err := db.NewPool(ctx, cfg.DatabaseURL)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	exportSvc := service.NewExportService(repository.NewExportRepository(pool), loc)
+
+	path := *out
+	if path == "" {
+		path = format.FileName(time.Now().In(loc))
+	}
+	if path == "-" {
+		_, err := exportSvc.Write(ctx, os.Stdout, format, filter)
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	n, err := exportSvc.Write(ctx, f, format, filter)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Выгружено строк: %d → %s\n", n, path)
+	return nil
+}
@@ -6,6 +6,8 @@ import (
 	"log"
 	"os"
 
+	"era_sporta_bot_ruletka/scripts"
+
 	"github.com/jackc/pgx/v5"
 	"github.com/joho/godotenv"
 )
@@ -67,68 +69,11 @@ func main() {
 	fmt.Println("✓ Подключение установлено")
 	fmt.Println()
 
-	// Применение миграций
-	migrations := []string{
-		`-- Migration 001: Create users table
-CREATE TABLE IF NOT EXISTS users (
-    id BIGSERIAL PRIMARY KEY,
-    telegram_user_id BIGINT UNIQUE NOT NULL,
-    phone VARCHAR(20) UNIQUE NOT NULL,
-    first_name VARCHAR(100),
-    last_name VARCHAR(100),
-    username VARCHAR(100),
-    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-);
-CREATE INDEX IF NOT EXISTS idx_users_telegram_user_id ON users(telegram_user_id);
-CREATE INDEX IF NOT EXISTS idx_users_phone ON users(phone);
-CREATE INDEX IF NOT EXISTS idx_users_created_at ON users(created_at);`,
-
-		`-- Migration 002: Create prizes table
-CREATE TABLE IF NOT EXISTS prizes (
-    id SERIAL PRIMARY KEY,
-    name VARCHAR(100) NOT NULL,
-    type VARCHAR(20) NOT NULL,
-    value DECIMAL(10,2) NOT NULL DEFAULT 0,
-    probability_weight INT NOT NULL DEFAULT 1,
-    is_active BOOLEAN NOT NULL DEFAULT true,
-    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-);`,
-
-		`-- Insert default prizes if table is empty
-INSERT INTO prizes (name, type, value, probability_weight, is_active)
-SELECT * FROM (VALUES
-    ('БЕСПЛАТНЫЕ 7 ДНЕЙ ФИТНЕСА',                           'free_days',  7,  20, true),
-    ('БЕСПЛАТНЫЕ 7 ДНЕЙ ФИТНЕСА',                           'free_days',  7,  20, true),
-    ('ЗАРЯЖЕННЫЙ ФИТНЕС-ИНТЕНСИВ 🔥',                       'bonus',      1,  25, true),
-    ('ШЕЙПИНГ — ГРУППОВАЯ ТРЕНИРОВКА ДЛЯ ФОРМЫ И РЕЛЬЕФА', 'bonus',      1,  25, true),
-    ('БЕЗЛИМИТ ПОСЕЩЕНИЙ НА 1 МЕСЯЦ',                       'free_month', 1,   1, true),
-    ('1 ДЕНЬ В ЭРА СПОРТА + МИНИ-ПРОГРАММА ТРЕНИРОВОК',     'free_days',  1,  25, true),
-    ('СКИДКА НА ГОДОВОЙ АБОНЕМЕНТ',                         'discount',   1,  15, true),
-    ('10% НА МАССАЖ / ВОССТАНОВЛЕНИЕ',                      'discount',   10, 25, true)
-) AS v(name, type, value, probability_weight, is_active)
-WHERE NOT EXISTS (SELECT 1 FROM prizes LIMIT 1);`,
-
-		`-- Migration 003: Create spins table
-CREATE TABLE IF NOT EXISTS spins (
-    id BIGSERIAL PRIMARY KEY,
-    user_id BIGINT NOT NULL REFERENCES users(id),
-    prize_id INT NOT NULL REFERENCES prizes(id),
-    result_value DECIMAL(10,2) NOT NULL,
-    ip_hash VARCHAR(64),
-    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-);
-CREATE INDEX IF NOT EXISTS idx_spins_user_id ON spins(user_id);
-CREATE INDEX IF NOT EXISTS idx_spins_created_at ON spins(created_at);
-CREATE INDEX IF NOT EXISTS idx_spins_user_created ON spins(user_id, created_at);`,
-	}
-
-	fmt.Println("Применение миграций...")
-	for i, migration := range migrations {
-		fmt.Printf("  Миграция %d/%d...\n", i+1, len(migrations))
-		if _, err := conn.Exec(ctx, migration); err != nil {
-			log.Fatalf("Ошибка при выполнении миграции %d: %v", i+1, err)
-		}
+	// Применение полной схемы: тот же scripts/init_db_all.sql, что и для psql, встроен в бинарник.
+	// Скрипт идемпотентен, поэтому повторный запуск доводит существующую базу до текущей схемы.
+	fmt.Println("Применение миграций (scripts/init_db_all.sql)...")
+	if _, err := conn.Exec(ctx, scripts.InitDBAll); err != nil {
+		log.Fatalf("Ошибка при применении схемы: %v", err)
 	}
 
 	fmt.Println("✓ Все миграции применены")
@@ -5,10 +5,30 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"time"
+
+	"era_sporta_bot_ruletka/config"
+	"era_sporta_bot_ruletka/internal/api/middleware"
+
+	"github.com/joho/godotenv"
 )
 
 func main() {
-	http.Handle("/", http.FileServer(http.Dir("web")))
+	_ = godotenv.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	security := middleware.SecurityConfig{
+		AllowedOrigins: cfg.CORSAllowedOrigins,
+		HSTSMaxAge:     time.Duration(cfg.HSTSMaxAgeSec) * time.Second,
+	}
+
+	files := http.FileServer(http.Dir("web"))
+	http.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		middleware.WriteSecurityHeaders(w.Header(), security, r.TLS != nil)
+		files.ServeHTTP(w, r)
+	}))
 	log.Println("Mini App: http://localhost:5173")
 	if ip := localIP(); ip != "" {
 		log.Printf("Для телефона в той же Wi-Fi: http://%s:5173", ip)
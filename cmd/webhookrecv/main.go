@@ -0,0 +1,77 @@
+// Команда webhookrecv — локальный приёмник исходящих webhook для проверки интеграции без CRM:
+// проверяет подпись и печатает события. -fail N отвечает 500 на первые N запросов, чтобы увидеть повторы.
+//
+//	go run ./cmd/webhookrecv -addr :9090 -secret "$WEBHOOK_SECRET"
+//	WEBHOOK_URLS=http://localhost:9090/webhook go run ./cmd/app
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"era_sporta_bot_ruletka/internal/webhook"
+
+	"github.com/joho/godotenv"
+)
+
+// maxBody — ограничение размера тела запроса.
+const maxBody = 1 << 20
+
+func main() {
+	_ = godotenv.Load()
+	addr := flag.String("addr", ":9090", "адрес для приёма запросов")
+	secret := flag.String("secret", os.Getenv("WEBHOOK_SECRET"), "секрет подписи (по умолчанию WEBHOOK_SECRET)")
+	failFirst := flag.Int64("fail", 0, "отвечать 500 на первые N запросов")
+	flag.Parse()
+	if *secret == "" {
+		log.Fatal("secret is required: -secret or WEBHOOK_SECRET")
+	}
+
+	var received atomic.Int64
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBody))
+		if err != nil {
+			http.Error(w, "read body", http.StatusBadRequest)
+			return
+		}
+		n := received.Add(1)
+		event, delivery := r.Header.Get(webhook.HeaderEvent), r.Header.Get(webhook.HeaderDelivery)
+
+		if err := webhook.Verify(*secret, r.Header.Get(webhook.HeaderTimestamp), r.Header.Get(webhook.HeaderSignature),
+			body, time.Now(), webhook.DefaultTolerance); err != nil {
+			log.Printf("#%d %s delivery=%s: отклонён: %v", n, event, delivery, err)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if n <= *failFirst {
+			log.Printf("#%d %s delivery=%s: подпись верна, отвечаем 500 (-fail %d)", n, event, delivery, *failFirst)
+			http.Error(w, "simulated failure", http.StatusInternalServerError)
+			return
+		}
+
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, body, "", "  "); err != nil {
+			pretty.Reset()
+			pretty.Write(body)
+		}
+		log.Printf("#%d %s delivery=%s event_id=%s: подпись верна\n%s", n, event, delivery,
+			r.Header.Get(webhook.HeaderEventID), pretty.String())
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	log.Printf("Приём webhook на http://localhost%s/", *addr)
+	if err := http.ListenAndServe(*addr, nil); err != nil {
+		log.Fatal(err)
+	}
+}
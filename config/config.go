@@ -1,40 +1,127 @@
 package config
 
 import (
+	"errors"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
 )
 
 type Config struct {
-	BotToken            string
-	AdminTelegramChatID int64
-	TelegramChannelID   int64
-	TelegramChannelURL  string
-	WebAppURL           string
-	APIPort             int
-	DatabaseURL         string
-	RedisURL            string
-	RouletteSpinLimit   int
-	RouletteLockTTLSec  int
+	BotToken             string
+	AdminTelegramChatID  int64
+	TelegramChannelID    int64
+	TelegramChannelURL   string
+	WebAppURL            string
+	APIPort              int
+	BotHTTPPort          int
+	MetricsPort          int
+	DatabaseURL          string
+	RedisURL             string
+	RouletteSpinLimit    int
+	RouletteLockTTLSec   int
+	VoucherTTLDays       int
+	LogLevel             string
+	LogFormat            string
+	CORSAllowedOrigins   []string
+	CORSMaxAgeSec        int
+	HSTSMaxAgeSec        int
+	InitDataMaxAgeSec    int
+	TelegramTestEnv      bool
+	SessionSecret        string
+	SessionTTLSec        int
+	SessionRefreshTTLSec int
+	SpinNonceRequired    bool
+	AdminAPIToken        string
+	StatsTimezone        string
+	AdminSpinNotifyMode  string
+	AdminSpinBatchMin    int
+	DigestAt             string
+	DigestWeeklyDay      string
+	BroadcastRatePerSec  int
+	ReminderDaysBefore   string
+	ReminderIntervalMin  int
+	ClubAddress          string
+	NotifyDestinations   string
+	NotifyRoutes         string
+	WebhookURLs          []string
+	WebhookSecret        string
+	WebhookEvents        []string
+	FraudRules           string
+	IPHashSecret         string
+	IPHashRotationDays   int
+	TrustedProxies       []string
+	TrustedPlatform      string
 }
 
 func Load() (*Config, error) {
 	c := &Config{
-		BotToken:            getEnv("BOT_TOKEN", ""),
-		AdminTelegramChatID: getEnvInt64("ADMIN_TELEGRAM_CHAT_ID", -5197400174),
-		TelegramChannelID:   getEnvInt64("TELEGRAM_CHANNEL_ID", 0),
-		TelegramChannelURL:  getEnv("TELEGRAM_CHANNEL_URL", ""),
-		WebAppURL:           getEnv("WEBAPP_URL", "https://your-domain.com/webapp"),
-		APIPort:             getEnvInt("API_PORT", 8080),
-		DatabaseURL:         getEnv("DATABASE_URL", ""),
-		RedisURL:            getEnv("REDIS_URL", ""),
-		RouletteSpinLimit:   getEnvInt("ROULETTE_SPIN_LIMIT_PER_USER", 1),
-		RouletteLockTTLSec:  getEnvInt("ROULETTE_LOCK_TTL_SEC", 10),
+		BotToken:             getEnv("BOT_TOKEN", ""),
+		AdminTelegramChatID:  getEnvInt64("ADMIN_TELEGRAM_CHAT_ID", -5197400174),
+		TelegramChannelID:    getEnvInt64("TELEGRAM_CHANNEL_ID", 0),
+		TelegramChannelURL:   getEnv("TELEGRAM_CHANNEL_URL", ""),
+		WebAppURL:            getEnv("WEBAPP_URL", "https://your-domain.com/webapp"),
+		APIPort:              getEnvInt("API_PORT", 8080),
+		BotHTTPPort:          getEnvInt("BOT_HTTP_PORT", 8081),
+		MetricsPort:          getEnvInt("METRICS_PORT", 9090),
+		DatabaseURL:          getEnv("DATABASE_URL", ""),
+		RedisURL:             getEnv("REDIS_URL", ""),
+		RouletteSpinLimit:    getEnvInt("ROULETTE_SPIN_LIMIT_PER_USER", 1),
+		RouletteLockTTLSec:   getEnvInt("ROULETTE_LOCK_TTL_SEC", 10),
+		VoucherTTLDays:       getEnvInt("VOUCHER_TTL_DAYS", 30),
+		LogLevel:             getEnv("LOG_LEVEL", "info"),
+		LogFormat:            getEnv("LOG_FORMAT", "json"),
+		CORSAllowedOrigins:   getEnvList("CORS_ALLOWED_ORIGINS"),
+		CORSMaxAgeSec:        getEnvInt("CORS_MAX_AGE_SEC", 600),
+		HSTSMaxAgeSec:        getEnvInt("HSTS_MAX_AGE_SEC", 31536000),
+		InitDataMaxAgeSec:    getEnvInt("INIT_DATA_MAX_AGE_SEC", 86400),
+		TelegramTestEnv:      getEnvBool("TELEGRAM_TEST_ENV", false),
+		SessionSecret:        getEnv("SESSION_SECRET", ""),
+		SessionTTLSec:        getEnvInt("SESSION_TTL_SEC", 900),
+		SessionRefreshTTLSec: getEnvInt("SESSION_REFRESH_TTL_SEC", 604800),
+		SpinNonceRequired:    getEnvBool("SPIN_NONCE_REQUIRED", false),
+		AdminAPIToken:        getEnv("ADMIN_API_TOKEN", ""),
+		StatsTimezone:        getEnv("STATS_TIMEZONE", "Europe/Moscow"),
+		AdminSpinNotifyMode:  getEnv("ADMIN_SPIN_NOTIFY_MODE", "each"),
+		AdminSpinBatchMin:    getEnvInt("ADMIN_SPIN_BATCH_INTERVAL_MIN", 60),
+		DigestAt:             getEnv("DIGEST_AT", "09:00"),
+		DigestWeeklyDay:      getEnv("DIGEST_WEEKLY_DAY", "monday"),
+		BroadcastRatePerSec:  getEnvInt("BROADCAST_RATE_PER_SEC", 25),
+		ReminderDaysBefore:   getEnv("REMINDER_DAYS_BEFORE", "3,1"),
+		ReminderIntervalMin:  getEnvInt("REMINDER_INTERVAL_MIN", 60),
+		ClubAddress:          getEnv("CLUB_ADDRESS", ""),
+		NotifyDestinations:   getEnv("NOTIFY_DESTINATIONS", ""),
+		NotifyRoutes:         getEnv("NOTIFY_ROUTES", ""),
+		WebhookURLs:          getEnvList("WEBHOOK_URLS"),
+		WebhookSecret:        getEnv("WEBHOOK_SECRET", ""),
+		WebhookEvents:        getEnvList("WEBHOOK_EVENTS"),
+		FraudRules:           getEnv("FRAUD_RULES", ""),
+		IPHashSecret:         getEnv("IP_HASH_SECRET", ""),
+		IPHashRotationDays:   getEnvInt("IP_HASH_ROTATION_DAYS", 30),
+		TrustedProxies:       getEnvList("TRUSTED_PROXIES"),
+		TrustedPlatform:      getEnv("TRUSTED_PLATFORM", ""),
+	}
+	if len(c.CORSAllowedOrigins) == 0 {
+		if origin := originOf(c.WebAppURL); origin != "" {
+			c.CORSAllowedOrigins = []string{origin}
+		}
+	}
+	if len(c.WebhookURLs) > 0 && c.WebhookSecret == "" {
+		return nil, errors.New("WEBHOOK_SECRET is required when WEBHOOK_URLS is set")
 	}
 	return c, nil
 }
 
+// originOf возвращает scheme://host[:port] из URL или пустую строку.
+func originOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
+
 func getEnv(key, defaultVal string) string {
 	if v := os.Getenv(key); v != "" {
 		return strings.TrimSpace(v)
@@ -42,6 +129,17 @@ func getEnv(key, defaultVal string) string {
 	return defaultVal
 }
 
+// getEnvList читает список значений через запятую.
+func getEnvList(key string) []string {
+	var out []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
 func getEnvInt(key string, defaultVal int) int {
 	if v := os.Getenv(key); v != "" {
 		if i, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
@@ -51,6 +149,15 @@ func getEnvInt(key string, defaultVal int) int {
 	return defaultVal
 }
 
+func getEnvBool(key string, defaultVal bool) bool {
+	if v := os.Getenv(key); v != "" {
+		if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+			return b
+		}
+	}
+	return defaultVal
+}
+
 func getEnvInt64(key string, defaultVal int64) int64 {
 	if v := os.Getenv(key); v != "" {
 		if i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
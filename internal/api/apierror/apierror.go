@@ -0,0 +1,236 @@
+// Package apierror — единая модель ошибок API: стабильный машиночитаемый код,
+// HTTP-статус и локализованное сообщение для Mini App.
+//
+// Формат ответа: {"error": "<code>", "message": "<текст>", "request_id": "<id>"}.
+package apierror
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"era_sporta_bot_ruletka/internal/logger"
+	"era_sporta_bot_ruletka/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Code — стабильный код ошибки. Меняться не должен: на него завязан фронтенд.
+type Code string
+
+const (
+	CodeBadRequest        Code = "bad_request"
+	CodeInitDataRequired  Code = "init_data_required"
+	CodeInvalidInitData   Code = "invalid_init_data"
+	CodeUnauthorized      Code = "unauthorized"
+	CodeInvalidToken      Code = "invalid_token"
+	CodeForbidden         Code = "forbidden"
+	CodeNotFound          Code = "not_found"
+	CodeUserNotFound      Code = "user_not_found"
+	CodePhoneRequired     Code = "phone_required"
+	CodeSpinLimitExceeded Code = "spin_limit_exceeded"
+	CodeCampaignClosed    Code = "campaign_closed"
+	CodeSpinRejected      Code = "spin_rejected"
+	CodeUserBlocked       Code = "user_blocked"
+	CodeRateLimited       Code = "rate_limited"
+	CodeNonceRequired     Code = "nonce_required"
+	CodeNonceReused       Code = "nonce_reused"
+	CodeInternal          Code = "internal_error"
+)
+
+const defaultLang = "ru"
+
+type definition struct {
+	status   int
+	messages map[string]string
+}
+
+var definitions = map[Code]definition{
+	CodeBadRequest: {http.StatusBadRequest, map[string]string{
+		"ru": "Некорректный запрос.",
+		"en": "Bad request.",
+	}},
+	CodeInitDataRequired: {http.StatusBadRequest, map[string]string{
+		"ru": "Откройте приложение из Telegram (кнопка в боте).",
+		"en": "Open the app from Telegram (button in the bot).",
+	}},
+	CodeInvalidInitData: {http.StatusUnauthorized, map[string]string{
+		"ru": "Не удалось подтвердить данные Telegram. Откройте приложение заново.",
+		"en": "Telegram data could not be verified. Please reopen the app.",
+	}},
+	CodeUnauthorized: {http.StatusUnauthorized, map[string]string{
+		"ru": "Требуется авторизация.",
+		"en": "Authorization required.",
+	}},
+	CodeInvalidToken: {http.StatusUnauthorized, map[string]string{
+		"ru": "Сессия истекла. Откройте приложение заново.",
+		"en": "Session expired. Please reopen the app.",
+	}},
+	CodeForbidden: {http.StatusForbidden, map[string]string{
+		"ru": "Доступ запрещён.",
+		"en": "Access denied.",
+	}},
+	CodeNotFound: {http.StatusNotFound, map[string]string{
+		"ru": "Не найдено.",
+		"en": "Not found.",
+	}},
+	CodeUserNotFound: {http.StatusNotFound, map[string]string{
+		"ru": "Пользователь не найден.",
+		"en": "User not found.",
+	}},
+	CodePhoneRequired: {http.StatusForbidden, map[string]string{
+		"ru": "Сначала поделитесь номером телефона в боте",
+		"en": "Please share your phone number in the bot first",
+	}},
+	CodeSpinLimitExceeded: {http.StatusConflict, map[string]string{
+		"ru": "Вы уже использовали свой спин",
+		"en": "You have already used your spin",
+	}},
+	CodeCampaignClosed: {http.StatusGone, map[string]string{
+		"ru": "Розыгрыш завершён. Следите за новостями в нашем канале!",
+		"en": "The giveaway is over. Follow our channel for news!",
+	}},
+	CodeSpinRejected: {http.StatusForbidden, map[string]string{
+		"ru": "Спин недоступен. Обратитесь к администратору клуба.",
+		"en": "Spinning is not available. Please contact the club administrator.",
+	}},
+	CodeUserBlocked: {http.StatusForbidden, map[string]string{
+		"ru": "Доступ к розыгрышу закрыт. Обратитесь к администратору клуба.",
+		"en": "Access to the giveaway is blocked. Please contact the club administrator.",
+	}},
+	CodeRateLimited: {http.StatusTooManyRequests, map[string]string{
+		"ru": "Слишком много запросов. Попробуйте чуть позже.",
+		"en": "Too many requests. Please try again later.",
+	}},
+	CodeNonceRequired: {http.StatusBadRequest, map[string]string{
+		"ru": "Обновите приложение и попробуйте ещё раз.",
+		"en": "Please update the app and try again.",
+	}},
+	CodeNonceReused: {http.StatusConflict, map[string]string{
+		"ru": "Этот запрос уже обработан.",
+		"en": "This request has already been processed.",
+	}},
+	CodeInternal: {http.StatusInternalServerError, map[string]string{
+		"ru": "Что-то пошло не так. Попробуйте позже.",
+		"en": "Something went wrong. Please try again later.",
+	}},
+}
+
+// serviceErrors — соответствие ошибок сервисов кодам API.
+var serviceErrors = []struct {
+	err  error
+	code Code
+}{
+	{service.ErrSpinLimitExceeded, CodeSpinLimitExceeded},
+	{service.ErrUserNotFound, CodeUserNotFound},
+	{service.ErrPhoneRequired, CodePhoneRequired},
+	{service.ErrCampaignClosed, CodeCampaignClosed},
+	{service.ErrRateLimited, CodeRateLimited},
+	{service.ErrSpinRejected, CodeSpinRejected},
+	{service.ErrUserBlocked, CodeUserBlocked},
+	{service.ErrInvalidPhone, CodeBadRequest},
+}
+
+// Error — ошибка API с кодом. Err (если есть) пишется в лог, но не уходит клиенту.
+type Error struct {
+	Code Code
+	Err  error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return string(e.Code) + ": " + e.Err.Error()
+	}
+	return string(e.Code)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New создаёт ошибку с кодом.
+func New(code Code) *Error {
+	return &Error{Code: code}
+}
+
+// Wrap создаёт ошибку с кодом и причиной для лога.
+func Wrap(code Code, err error) *Error {
+	return &Error{Code: code, Err: err}
+}
+
+// Status возвращает HTTP-статус кода.
+func (c Code) Status() int {
+	if d, ok := definitions[c]; ok {
+		return d.status
+	}
+	return http.StatusInternalServerError
+}
+
+// Message возвращает сообщение на языке lang (ru, если перевода нет).
+func (c Code) Message(lang string) string {
+	d, ok := definitions[c]
+	if !ok {
+		d = definitions[CodeInternal]
+	}
+	if m, ok := d.messages[lang]; ok {
+		return m
+	}
+	return d.messages[defaultLang]
+}
+
+// CodeOf определяет код для произвольной ошибки: *Error, ошибка сервиса или internal_error.
+func CodeOf(err error) Code {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	for _, se := range serviceErrors {
+		if errors.Is(err, se.err) {
+			return se.code
+		}
+	}
+	return CodeInternal
+}
+
+// Response — тело ответа с ошибкой.
+type Response struct {
+	Code      Code   `json:"error"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Respond пишет ответ с ошибкой и прерывает цепочку handlers. Внутренние ошибки логируются.
+func Respond(c *gin.Context, err error) {
+	code := CodeOf(err)
+	ctx := c.Request.Context()
+	if code == CodeInternal {
+		slog.ErrorContext(ctx, "request failed", "error", err)
+	}
+	c.AbortWithStatusJSON(code.Status(), Response{
+		Code:      code,
+		Message:   code.Message(Lang(c)),
+		RequestID: logger.RequestID(ctx),
+	})
+}
+
+// Abort — Respond для ошибки без причины.
+func Abort(c *gin.Context, code Code) {
+	Respond(c, New(code))
+}
+
+// Lang выбирает язык сообщений: ?lang=, затем Accept-Language. По умолчанию ru.
+func Lang(c *gin.Context) string {
+	lang := c.Query("lang")
+	if lang == "" {
+		lang = c.GetHeader("Accept-Language")
+	}
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, ",;-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	if _, ok := definitions[CodeInternal].messages[lang]; ok {
+		return lang
+	}
+	return defaultLang
+}
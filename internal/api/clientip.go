@@ -0,0 +1,37 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultTrustedProxies — прокси по умолчанию: nginx или другой балансировщик на той же машине.
+var DefaultTrustedProxies = []string{"127.0.0.1", "::1"}
+
+// ConfigureClientIP задаёт, кому gin верит при определении адреса клиента (ClientIP):
+// X-Forwarded-For и X-Real-IP читаются только от trustedProxies (адреса или подсети CIDR),
+// "none" — не верить заголовкам вовсе. platform — заголовок платформы перед сервисом:
+// "cloudflare", "google" или имя заголовка; пусто — не используется.
+func ConfigureClientIP(app *gin.Engine, trustedProxies []string, platform string) error {
+	if len(trustedProxies) == 0 {
+		trustedProxies = DefaultTrustedProxies
+	}
+	if len(trustedProxies) == 1 && strings.EqualFold(trustedProxies[0], "none") {
+		trustedProxies = nil
+	}
+	if err := app.SetTrustedProxies(trustedProxies); err != nil {
+		return fmt.Errorf("TRUSTED_PROXIES: %w", err)
+	}
+	switch strings.ToLower(platform) {
+	case "":
+	case "cloudflare":
+		app.TrustedPlatform = gin.PlatformCloudflare
+	case "google":
+		app.TrustedPlatform = gin.PlatformGoogleAppEngine
+	default:
+		app.TrustedPlatform = platform
+	}
+	return nil
+}
@@ -0,0 +1,405 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"era_sporta_bot_ruletka/internal/api/apierror"
+	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/export"
+	"era_sporta_bot_ruletka/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultStatsDays = 30
+	maxStatsDays     = 366
+
+	defaultWebhookLogLimit = 50
+	maxWebhookLogLimit     = 500
+
+	defaultBlocklistLimit = 50
+	maxBlocklistLimit     = 500
+)
+
+type AdminHandler struct {
+	statsSvc      *service.StatsService
+	exportSvc     *service.ExportService
+	webhookSvc    *service.WebhookService
+	moderationSvc *service.ModerationService
+}
+
+func NewAdminHandler(statsSvc *service.StatsService, exportSvc *service.ExportService, webhookSvc *service.WebhookService, moderationSvc *service.ModerationService) *AdminHandler {
+	return &AdminHandler{statsSvc: statsSvc, exportSvc: exportSvc, webhookSvc: webhookSvc, moderationSvc: moderationSvc}
+}
+
+type FunnelStepDTO struct {
+	Step            string  `json:"step"`
+	Users           int     `json:"users"`
+	ConversionPrev  float64 `json:"conversion_prev"`
+	ConversionStart float64 `json:"conversion_start"`
+}
+
+type DailyStatsDTO struct {
+	Date  string         `json:"date"`
+	Steps map[string]int `json:"steps"`
+	Spins int            `json:"spins"`
+}
+
+type PrizeStatsDTO struct {
+	PrizeID        int     `json:"prize_id"`
+	Name           string  `json:"name"`
+	IsActive       bool    `json:"is_active"`
+	Weight         int     `json:"weight"`
+	Spins          int     `json:"spins"`
+	ConfiguredRate float64 `json:"configured_rate"`
+	ActualRate     float64 `json:"actual_rate"`
+}
+
+type StatsResponse struct {
+	From        time.Time       `json:"from"`
+	To          time.Time       `json:"to"`
+	Timezone    string          `json:"timezone"`
+	UniqueUsers int             `json:"unique_users"`
+	Funnel      []FunnelStepDTO `json:"funnel"`
+	Daily       []DailyStatsDTO `json:"daily"`
+	Prizes      []PrizeStatsDTO `json:"prizes"`
+}
+
+// Stats отдаёт воронку, дневную статистику и распределение призов.
+// Query: from, to (RFC 3339 или YYYY-MM-DD в часовом поясе статистики; to не включается). По умолчанию — последние 30 дней.
+func (h *AdminHandler) Stats(c *gin.Context) {
+	from, to, err := h.statsPeriod(c)
+	if err != nil {
+		apierror.Respond(c, apierror.Wrap(apierror.CodeBadRequest, err))
+		return
+	}
+
+	stats, err := h.statsSvc.Get(c.Request.Context(), from, to)
+	if err != nil {
+		apierror.Respond(c, fmt.Errorf("get stats: %w", err))
+		return
+	}
+
+	resp := StatsResponse{
+		From:        stats.From,
+		To:          stats.To,
+		Timezone:    h.statsSvc.Location().String(),
+		UniqueUsers: stats.UniqueUsers,
+		Funnel:      make([]FunnelStepDTO, len(stats.Funnel)),
+		Daily:       make([]DailyStatsDTO, len(stats.Daily)),
+		Prizes:      make([]PrizeStatsDTO, len(stats.Prizes)),
+	}
+	for i, f := range stats.Funnel {
+		resp.Funnel[i] = FunnelStepDTO{Step: f.Step, Users: f.Users, ConversionPrev: f.ConversionPrev, ConversionStart: f.ConversionStart}
+	}
+	for i, d := range stats.Daily {
+		resp.Daily[i] = DailyStatsDTO{Date: d.Date.Format(time.DateOnly), Steps: d.Steps, Spins: d.Spins}
+	}
+	for i, p := range stats.Prizes {
+		resp.Prizes[i] = PrizeStatsDTO{
+			PrizeID:        p.PrizeID,
+			Name:           p.Name,
+			IsActive:       p.IsActive,
+			Weight:         p.Weight,
+			Spins:          p.Spins,
+			ConfiguredRate: p.ConfiguredRate,
+			ActualRate:     p.ActualRate,
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *AdminHandler) statsPeriod(c *gin.Context) (time.Time, time.Time, error) {
+	loc := h.statsSvc.Location()
+	from, err := domain.ParseDateBound(c.Query("from"), false, loc)
+	if err != nil {
+		return from, from, err
+	}
+	to, err := domain.ParseDateBound(c.Query("to"), true, loc)
+	if err != nil {
+		return from, to, err
+	}
+	if to.IsZero() {
+		now := time.Now().In(loc)
+		to = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1)
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -defaultStatsDays)
+	}
+	if !from.Before(to) {
+		return from, to, errors.New("from must be before to")
+	}
+	if to.Sub(from) > maxStatsDays*24*time.Hour {
+		return from, to, fmt.Errorf("period is longer than %d days", maxStatsDays)
+	}
+	return from, to, nil
+}
+
+// Export отдаёт выгрузку пользователей и спинов файлом.
+// Query: format (csv, xlsx), from, to (как в Stats, но без периода по умолчанию), campaign.
+func (h *AdminHandler) Export(c *gin.Context) {
+	format, err := export.ParseFormat(c.Query("format"))
+	if err != nil {
+		apierror.Respond(c, apierror.Wrap(apierror.CodeBadRequest, err))
+		return
+	}
+	loc := h.exportSvc.Location()
+	filter := domain.ExportFilter{Campaign: c.Query("campaign")}
+	if filter.From, err = domain.ParseDateBound(c.Query("from"), false, loc); err != nil {
+		apierror.Respond(c, apierror.Wrap(apierror.CodeBadRequest, err))
+		return
+	}
+	if filter.To, err = domain.ParseDateBound(c.Query("to"), true, loc); err != nil {
+		apierror.Respond(c, apierror.Wrap(apierror.CodeBadRequest, err))
+		return
+	}
+
+	// Пишем в буфер, чтобы ошибка посреди выгрузки вернулась JSON-ответом, а не обрезанным файлом
+	var buf bytes.Buffer
+	if _, err := h.exportSvc.Write(c.Request.Context(), &buf, format, filter); err != nil {
+		apierror.Respond(c, fmt.Errorf("export: %w", err))
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, format.FileName(time.Now().In(loc))))
+	c.Data(http.StatusOK, format.ContentType(), buf.Bytes())
+}
+
+// WebhookDeliveryDTO — запись журнала исходящих webhook.
+type WebhookDeliveryDTO struct {
+	ID           int64           `json:"id"`
+	EventID      string          `json:"event_id"`
+	Event        string          `json:"event"`
+	URL          string          `json:"url"`
+	Status       string          `json:"status"`
+	Attempts     int             `json:"attempts"`
+	ResponseCode int             `json:"response_code,omitempty"`
+	LastError    string          `json:"last_error,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+	DeliveredAt  *time.Time      `json:"delivered_at,omitempty"`
+	Payload      json.RawMessage `json:"payload"`
+}
+
+type WebhookLogResponse struct {
+	Deliveries []WebhookDeliveryDTO `json:"deliveries"`
+}
+
+// Webhooks отдаёт журнал исходящих webhook, новые первыми.
+// Query: status (pending, delivered, failed; по умолчанию все), limit (по умолчанию 50, не больше 500).
+func (h *AdminHandler) Webhooks(c *gin.Context) {
+	var status domain.WebhookStatus
+	switch st := domain.WebhookStatus(c.Query("status")); st {
+	case "", domain.WebhookPending, domain.WebhookDelivered, domain.WebhookFailed:
+		status = st
+	default:
+		apierror.Respond(c, apierror.Wrap(apierror.CodeBadRequest, fmt.Errorf("unknown status %q", st)))
+		return
+	}
+	limit := defaultWebhookLogLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > maxWebhookLogLimit {
+			apierror.Respond(c, apierror.Wrap(apierror.CodeBadRequest, fmt.Errorf("limit must be between 1 and %d", maxWebhookLogLimit)))
+			return
+		}
+		limit = n
+	}
+
+	deliveries, err := h.webhookSvc.List(c.Request.Context(), status, limit)
+	if err != nil {
+		apierror.Respond(c, fmt.Errorf("list webhook deliveries: %w", err))
+		return
+	}
+	resp := WebhookLogResponse{Deliveries: make([]WebhookDeliveryDTO, len(deliveries))}
+	for i, d := range deliveries {
+		resp.Deliveries[i] = WebhookDeliveryDTO{
+			ID:           d.ID,
+			EventID:      d.EventID,
+			Event:        string(d.Event),
+			URL:          d.URL,
+			Status:       string(d.Status),
+			Attempts:     d.Attempts,
+			ResponseCode: d.ResponseCode,
+			LastError:    d.LastError,
+			CreatedAt:    d.CreatedAt,
+			DeliveredAt:  d.DeliveredAt,
+			Payload:      d.Payload,
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// adminAPIActor — автор блокировки через API, если в запросе не указан actor.
+const adminAPIActor = "admin_api"
+
+// BlockRequest — тело запросов блокировки пользователя и бана номера.
+type BlockRequest struct {
+	Reason string `json:"reason"`
+	Actor  string `json:"actor"`
+}
+
+// BlockedUserDTO — пользователь и состояние его блокировки.
+type BlockedUserDTO struct {
+	ID             int64      `json:"id"`
+	TelegramUserID int64      `json:"telegram_user_id"`
+	Phone          string     `json:"phone"`
+	FirstName      string     `json:"first_name"`
+	LastName       string     `json:"last_name"`
+	Username       string     `json:"username"`
+	Blocked        bool       `json:"blocked"`
+	BlockedAt      *time.Time `json:"blocked_at,omitempty"`
+	BlockReason    string     `json:"block_reason,omitempty"`
+	BlockedBy      string     `json:"blocked_by,omitempty"`
+}
+
+// PhoneBanDTO — забаненный номер (только цифры).
+type PhoneBanDTO struct {
+	Phone     string    `json:"phone"`
+	Reason    string    `json:"reason,omitempty"`
+	BannedBy  string    `json:"banned_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PhoneBanResponse struct {
+	Phone        string `json:"phone"`
+	BlockedUsers int    `json:"blocked_users"`
+}
+
+type BlocklistResponse struct {
+	Users  []BlockedUserDTO `json:"users"`
+	Phones []PhoneBanDTO    `json:"phones"`
+}
+
+// Blocks отдаёт заблокированных пользователей и забаненные номера, новые первыми.
+// Query: limit (по умолчанию 50, не больше 500) — отдельно для пользователей и номеров.
+func (h *AdminHandler) Blocks(c *gin.Context) {
+	limit := defaultBlocklistLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > maxBlocklistLimit {
+			apierror.Respond(c, apierror.Wrap(apierror.CodeBadRequest, fmt.Errorf("limit must be between 1 and %d", maxBlocklistLimit)))
+			return
+		}
+		limit = n
+	}
+	users, bans, err := h.moderationSvc.ListBlocked(c.Request.Context(), limit)
+	if err != nil {
+		apierror.Respond(c, fmt.Errorf("list blocked: %w", err))
+		return
+	}
+	resp := BlocklistResponse{Users: make([]BlockedUserDTO, len(users)), Phones: make([]PhoneBanDTO, len(bans))}
+	for i, u := range users {
+		resp.Users[i] = toBlockedUserDTO(u)
+	}
+	for i, b := range bans {
+		resp.Phones[i] = PhoneBanDTO{Phone: b.Phone, Reason: b.Reason, BannedBy: b.BannedBy, CreatedAt: b.CreatedAt}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// BlockUser блокирует пользователя по Telegram ID и банит его номер. Тело (необязательно): {"reason", "actor"}.
+func (h *AdminHandler) BlockUser(c *gin.Context) {
+	telegramID, ok := telegramIDParam(c)
+	if !ok {
+		return
+	}
+	req, ok := bindBlockRequest(c)
+	if !ok {
+		return
+	}
+	user, err := h.moderationSvc.BlockUser(c.Request.Context(), telegramID, req.Reason, req.Actor)
+	if err != nil {
+		apierror.Respond(c, fmt.Errorf("block user: %w", err))
+		return
+	}
+	c.JSON(http.StatusOK, toBlockedUserDTO(user))
+}
+
+// UnblockUser снимает блокировку с пользователя и бан с его номера.
+func (h *AdminHandler) UnblockUser(c *gin.Context) {
+	telegramID, ok := telegramIDParam(c)
+	if !ok {
+		return
+	}
+	user, err := h.moderationSvc.UnblockUser(c.Request.Context(), telegramID, adminAPIActor)
+	if err != nil {
+		apierror.Respond(c, fmt.Errorf("unblock user: %w", err))
+		return
+	}
+	c.JSON(http.StatusOK, toBlockedUserDTO(user))
+}
+
+// BanPhone банит номер из пути и блокирует все аккаунты с ним, в том числе будущие.
+func (h *AdminHandler) BanPhone(c *gin.Context) {
+	req, ok := bindBlockRequest(c)
+	if !ok {
+		return
+	}
+	phone, blocked, err := h.moderationSvc.BanPhone(c.Request.Context(), c.Param("phone"), req.Reason, req.Actor)
+	if err != nil {
+		apierror.Respond(c, fmt.Errorf("ban phone: %w", err))
+		return
+	}
+	c.JSON(http.StatusOK, PhoneBanResponse{Phone: phone, BlockedUsers: blocked})
+}
+
+// UnbanPhone снимает бан с номера и блокировку с аккаунтов с ним; 404, если номер не забанен.
+func (h *AdminHandler) UnbanPhone(c *gin.Context) {
+	found, err := h.moderationSvc.UnbanPhone(c.Request.Context(), c.Param("phone"), adminAPIActor)
+	if err != nil {
+		apierror.Respond(c, fmt.Errorf("unban phone: %w", err))
+		return
+	}
+	if !found {
+		apierror.Abort(c, apierror.CodeNotFound)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func telegramIDParam(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("telegram_user_id"), 10, 64)
+	if err != nil || id <= 0 {
+		apierror.Respond(c, apierror.Wrap(apierror.CodeBadRequest, fmt.Errorf("invalid telegram_user_id %q", c.Param("telegram_user_id"))))
+		return 0, false
+	}
+	return id, true
+}
+
+// bindBlockRequest читает необязательное тело {"reason", "actor"}; actor по умолчанию — admin_api.
+func bindBlockRequest(c *gin.Context) (BlockRequest, bool) {
+	var req BlockRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		apierror.Respond(c, apierror.Wrap(apierror.CodeBadRequest, err))
+		return req, false
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	req.Actor = strings.TrimSpace(req.Actor)
+	if req.Actor == "" {
+		req.Actor = adminAPIActor
+	}
+	return req, true
+}
+
+func toBlockedUserDTO(u *domain.User) BlockedUserDTO {
+	return BlockedUserDTO{
+		ID:             u.ID,
+		TelegramUserID: u.TelegramUserID,
+		Phone:          u.Phone,
+		FirstName:      u.FirstName,
+		LastName:       u.LastName,
+		Username:       u.Username,
+		Blocked:        u.IsBlocked(),
+		BlockedAt:      u.BlockedAt,
+		BlockReason:    u.BlockReason,
+		BlockedBy:      u.BlockedBy,
+	}
+}
@@ -1,10 +1,19 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"time"
 
+	"era_sporta_bot_ruletka/internal/api/apierror"
+	"era_sporta_bot_ruletka/internal/api/middleware"
 	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/logger"
+	"era_sporta_bot_ruletka/internal/metrics"
 	"era_sporta_bot_ruletka/internal/service"
+	"era_sporta_bot_ruletka/internal/session"
 	"era_sporta_bot_ruletka/internal/telegram"
 
 	"github.com/gin-gonic/gin"
@@ -12,21 +21,38 @@ import (
 
 type AuthHandler struct {
 	userSvc   *service.UserService
-	botToken  string
+	funnel    *service.FunnelService
+	validator *telegram.Validator
 	spinLimit int
+	sessions  *session.Manager
 }
 
-func NewAuthHandler(userSvc *service.UserService, botToken string, spinLimit int) *AuthHandler {
-	return &AuthHandler{userSvc: userSvc, botToken: botToken, spinLimit: spinLimit}
+func NewAuthHandler(userSvc *service.UserService, funnel *service.FunnelService, validator *telegram.Validator, spinLimit int, sessions *session.Manager) *AuthHandler {
+	return &AuthHandler{
+		userSvc:   userSvc,
+		funnel:    funnel,
+		validator: validator,
+		spinLimit: spinLimit,
+		sessions:  sessions,
+	}
 }
 
 type AuthRequest struct {
 	InitData string `json:"initData"`
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
 type AuthResponse struct {
-	User  *UserDTO      `json:"user"`
-	State *UserStateDTO `json:"state"`
+	User             *UserDTO      `json:"user"`
+	State            *UserStateDTO `json:"state"`
+	Token            string        `json:"token"`
+	ExpiresAt        time.Time     `json:"expires_at"`
+	RefreshToken     string        `json:"refresh_token"`
+	RefreshExpiresAt time.Time     `json:"refresh_expires_at"`
+	StartParam       string        `json:"start_param,omitempty"`
 }
 
 type UserDTO struct {
@@ -47,40 +73,108 @@ type UserStateDTO struct {
 func (h *AuthHandler) Auth(c *gin.Context) {
 	var req AuthRequest
 	if err := c.ShouldBindJSON(&req); err != nil || req.InitData == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "initData required"})
+		metrics.ObserveInitDataFailure("missing")
+		apierror.Abort(c, apierror.CodeInitDataRequired)
 		return
 	}
 
-	result, err := telegram.ValidateInitData(req.InitData, h.botToken)
+	result, err := h.validator.Validate(req.InitData)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "validation failed"})
+		metrics.ObserveInitDataFailure("error")
+		apierror.Respond(c, apierror.Wrap(apierror.CodeInvalidInitData, err))
 		return
 	}
 	if !result.Valid || result.User == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid initData"})
+		metrics.ObserveInitDataFailure("invalid")
+		apierror.Abort(c, apierror.CodeInvalidInitData)
 		return
 	}
 
+	middleware.SetLogContext(c, slog.Int64(logger.KeyTelegramUserID, result.User.ID))
 	ctx := c.Request.Context()
-	user, err := h.userSvc.GetByTelegramID(ctx, result.User.ID)
+	h.funnel.Track(ctx, result.User.ID, domain.FunnelEventAppOpened, result.StartParam)
+
+	user, err := h.userSvc.GetRegistered(ctx, result.User.ID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		apierror.Respond(c, fmt.Errorf("auth: %w", err))
 		return
 	}
-	if user == nil || user.Phone == "" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "phone required", "message": "Сначала поделитесь номером телефона в боте"})
+
+	// Первое открытие по ссылке startapp закрепляет за пользователем кампанию или реферера
+	if err := h.userSvc.Attribute(ctx, user, domain.ParseStartParam(result.StartParam)); err != nil {
+		slog.WarnContext(ctx, "save attribution failed", "error", err, "start_param", result.StartParam)
+	}
+	// Premium есть только в initData — запоминаем его для оценки риска спинов
+	if err := h.userSvc.SetPremium(ctx, user, result.User.IsPremium); err != nil {
+		slog.WarnContext(ctx, "save premium flag failed", "error", err)
+	}
+
+	h.respondWithSession(c, user, result.StartParam)
+}
+
+// Refresh обменивает refresh-токен на новую пару токенов. Токен одноразовый: повторный обмен
+// отклоняется. Пользователь перечитывается из БД, поэтому claim phone_verified обновляется,
+// а удалённый пользователь сессию не продлит.
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+		apierror.Abort(c, apierror.CodeBadRequest)
 		return
 	}
+	ctx := c.Request.Context()
+	claims, err := h.sessions.ParseRefresh(ctx, req.RefreshToken)
+	switch {
+	case errors.Is(err, session.ErrTokenReused):
+		metrics.ObserveInitDataFailure("reused_refresh_token")
+		apierror.Abort(c, apierror.CodeInvalidToken)
+		return
+	case errors.Is(err, session.ErrInvalidToken):
+		metrics.ObserveInitDataFailure("invalid_refresh_token")
+		apierror.Abort(c, apierror.CodeInvalidToken)
+		return
+	case err != nil:
+		apierror.Respond(c, fmt.Errorf("refresh: %w", err))
+		return
+	}
+
+	middleware.SetLogContext(c, slog.Int64(logger.KeyTelegramUserID, claims.TelegramUserID))
+	user, err := h.userSvc.GetRegistered(ctx, claims.TelegramUserID)
+	if err != nil {
+		apierror.Respond(c, fmt.Errorf("refresh: %w", err))
+		return
+	}
+
+	h.respondWithSession(c, user, claims.StartParam)
+}
 
+func (h *AuthHandler) respondWithSession(c *gin.Context, user *domain.User, startParam string) {
+	ctx := c.Request.Context()
 	state, err := h.userSvc.GetUserState(ctx, user, h.spinLimit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		apierror.Respond(c, fmt.Errorf("get user state: %w", err))
+		return
+	}
+
+	// Дальше Mini App ходит с сессионным токеном, а не с сырой initData
+	tokens, err := h.sessions.Issue(session.Identity{
+		TelegramUserID: user.TelegramUserID,
+		UserID:         user.ID,
+		PhoneVerified:  user.Phone != "",
+		StartParam:     startParam,
+	})
+	if err != nil {
+		apierror.Respond(c, fmt.Errorf("issue session token: %w", err))
 		return
 	}
 
 	c.JSON(http.StatusOK, AuthResponse{
-		User:  toUserDTO(user),
-		State: toUserStateDTO(state),
+		User:             toUserDTO(user),
+		State:            toUserStateDTO(state),
+		Token:            tokens.AccessToken,
+		ExpiresAt:        tokens.AccessExpiresAt,
+		RefreshToken:     tokens.RefreshToken,
+		RefreshExpiresAt: tokens.RefreshExpiresAt,
+		StartParam:       startParam,
 	})
 }
 
@@ -2,14 +2,20 @@ package handlers
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
+	"encoding/base64"
 	"errors"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"era_sporta_bot_ruletka/internal/api/apierror"
 	"era_sporta_bot_ruletka/internal/api/middleware"
+	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/logger"
+	"era_sporta_bot_ruletka/internal/metrics"
 	"era_sporta_bot_ruletka/internal/notifier"
 	"era_sporta_bot_ruletka/internal/service"
 
@@ -21,143 +27,259 @@ const disabledWheelPrizeName = "БЕЗЛИМИТ ПОСЕЩЕНИЙ НА 1 МЕ
 const disabledWheelPrizeNameAlt = "1 МЕСЯЦ БЕСПЛАТНО"
 const disabledWheelPrizeType = "free_month"
 
+// PrizeDTO — приз в конфигурации колеса.
+type PrizeDTO struct {
+	ID     int     `json:"id"`
+	Name   string  `json:"name"`
+	Type   string  `json:"type"`
+	Value  float64 `json:"value"`
+	Weight int     `json:"weight"`
+}
+
+// SpinDTO — результат спина.
+type SpinDTO struct {
+	ID          int64      `json:"id"`
+	PrizeID     int        `json:"prize_id"`
+	PrizeName   string     `json:"prize_name"`
+	PrizeType   string     `json:"prize_type"`
+	Value       float64    `json:"value"`
+	CreatedAt   time.Time  `json:"created_at"`
+	StopSegment int        `json:"stop_segment"`
+	Campaign    string     `json:"campaign"`
+	Status      string     `json:"status"`
+	VoucherCode string     `json:"voucher_code,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// SpinHistoryDTO — запись истории спинов с информацией о ваучере.
+type SpinHistoryDTO struct {
+	ID          int64      `json:"id"`
+	PrizeName   string     `json:"prize_name"`
+	PrizeType   string     `json:"prize_type"`
+	Value       float64    `json:"value"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Status      string     `json:"status"`
+	VoucherCode string     `json:"voucher_code,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	ClaimedAt   *time.Time `json:"claimed_at,omitempty"`
+	RedeemedAt  *time.Time `json:"redeemed_at,omitempty"`
+}
+
+type ConfigResponse struct {
+	Prizes []PrizeDTO `json:"prizes"`
+}
+
+type SpinResponse struct {
+	Spin SpinDTO `json:"spin"`
+}
+
+type HistoryResponse struct {
+	History    []SpinHistoryDTO `json:"history"`
+	Total      int              `json:"total"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+const defaultHistoryLimit = 20
+
+var errInvalidCursor = errors.New("invalid cursor")
+
 type RouletteHandler struct {
 	rouletteSvc *service.RouletteService
 	userSvc     *service.UserService
 	adminNotify notifier.AdminNotifier
+	userNotify  notifier.UserNotifier
+	fraudNotify notifier.FraudNotifier
+	webhooks    *service.WebhookService
 }
 
-func NewRouletteHandler(rouletteSvc *service.RouletteService, userSvc *service.UserService, adminNotify notifier.AdminNotifier) *RouletteHandler {
+func NewRouletteHandler(rouletteSvc *service.RouletteService, userSvc *service.UserService, adminNotify notifier.AdminNotifier, userNotify notifier.UserNotifier, fraudNotify notifier.FraudNotifier, webhooks *service.WebhookService) *RouletteHandler {
 	return &RouletteHandler{
 		rouletteSvc: rouletteSvc,
 		userSvc:     userSvc,
 		adminNotify: adminNotify,
+		userNotify:  userNotify,
+		fraudNotify: fraudNotify,
+		webhooks:    webhooks,
 	}
 }
 
 func (h *RouletteHandler) Spin(c *gin.Context) {
-	telegramUserID, ok := c.Get(middleware.TelegramUserIDKey)
+	user, ok := currentUser(c, h.userSvc)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
-	tid := telegramUserID.(int64)
-
+	middleware.SetLogContext(c, slog.Int64(logger.KeyUserID, user.ID))
 	ctx := c.Request.Context()
-	user, err := h.userSvc.GetByTelegramID(ctx, tid)
-	if err != nil || user == nil || user.Phone == "" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "phone required"})
-		return
-	}
 
-	ipHash := hashIP(c.ClientIP())
+	// Спин помечается источником текущего открытия, иначе — первым источником пользователя
+	source := domain.ParseStartParam(middleware.StartParam(c))
+	if source.IsZero() {
+		source = user.Attribution
+	}
 
-	result, err := h.rouletteSvc.Spin(ctx, user.ID, ipHash)
+	result, assessment, err := h.rouletteSvc.Spin(ctx, user, c.ClientIP(), source)
+	if assessment.Action != "" {
+		metrics.FraudAssessments.WithLabelValues(string(assessment.Action)).Inc()
+	}
+	// Менеджерам сообщаем только о состоявшихся и отклонённых правилами спинах, а не о сбоях
+	if assessment.Flagged() && h.fraudNotify != nil && (err == nil || errors.Is(err, service.ErrSpinRejected)) {
+		h.fraudNotify.NotifyFraud(ctx, user, result, assessment.Score, assessment.Reasons)
+	}
 	if err != nil {
-		if errors.Is(err, service.ErrSpinLimitExceeded) {
-			c.JSON(http.StatusConflict, gin.H{"error": "spin limit exceeded", "message": "Вы уже использовали свой спин"})
-			return
+		switch {
+		case errors.Is(err, service.ErrSpinLimitExceeded):
+			metrics.ObserveSpin("", metrics.SpinOutcomeLimitExceeded)
+		case errors.Is(err, service.ErrSpinRejected), errors.Is(err, service.ErrUserBlocked):
+			metrics.ObserveSpin("", metrics.SpinOutcomeRejected)
+		case errors.Is(err, service.ErrRateLimited):
+			metrics.ObserveSpin("", metrics.SpinOutcomeRateLimited)
+		default:
+			metrics.ObserveSpin("", metrics.SpinOutcomeError)
 		}
-		log.Printf("[roulette] spin error for user_id=%d telegram_id=%d: %v", user.ID, tid, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		apierror.Respond(c, fmt.Errorf("spin: %w", err))
 		return
 	}
 
+	metrics.ObserveSpin(result.Prize.Name, metrics.SpinOutcomeSuccess)
+	middleware.SetLogContext(c, slog.Int64(logger.KeySpinID, result.ID))
+	ctx = c.Request.Context()
+
 	// Notify admin
 	if h.adminNotify != nil {
-		h.adminNotify.NotifySpin(ctx, user, result.Prize.Name)
+		h.adminNotify.NotifySpin(ctx, user, result)
 	}
+	// Результат дублируется в чат с ботом, чтобы код ваучера не потерялся после закрытия Mini App
+	if h.userNotify != nil {
+		h.userNotify.NotifySpinResult(ctx, user, result)
+	}
+	h.webhooks.SpinCreated(ctx, user, result)
 
 	stopSegment := h.resolveStopSegment(ctx, result.Prize.Name, result.ID)
 
-	c.JSON(http.StatusOK, gin.H{
-		"spin": gin.H{
-			"id":           result.ID,
-			"prize_id":     result.PrizeID,
-			"prize_name":   result.Prize.Name,
-			"prize_type":   result.Prize.Type,
-			"value":        result.ResultValue,
-			"created_at":   result.CreatedAt,
-			"stop_segment": stopSegment,
-		},
-	})
+	c.JSON(http.StatusOK, SpinResponse{Spin: SpinDTO{
+		ID:          result.ID,
+		PrizeID:     result.PrizeID,
+		PrizeName:   result.Prize.Name,
+		PrizeType:   result.Prize.Type,
+		Value:       result.ResultValue,
+		CreatedAt:   result.CreatedAt,
+		StopSegment: stopSegment,
+		Campaign:    result.Campaign,
+		Status:      string(result.Status),
+		VoucherCode: result.VoucherCode,
+		ExpiresAt:   result.ExpiresAt,
+	}})
 }
 
 func (h *RouletteHandler) Config(c *gin.Context) {
 	ctx := c.Request.Context()
 	prizes, err := h.rouletteSvc.GetConfig(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		apierror.Respond(c, fmt.Errorf("get roulette config: %w", err))
 		return
 	}
 
-	type prizeDTO struct {
-		ID     int     `json:"id"`
-		Name   string  `json:"name"`
-		Type   string  `json:"type"`
-		Value  float64 `json:"value"`
-		Weight int     `json:"weight"`
-	}
-
-	list := make([]prizeDTO, len(prizes))
+	list := make([]PrizeDTO, len(prizes))
 	for i, p := range prizes {
-		list[i] = prizeDTO{ID: p.ID, Name: p.Name, Type: p.Type, Value: p.Value, Weight: p.ProbabilityWeight}
+		list[i] = PrizeDTO{ID: p.ID, Name: p.Name, Type: p.Type, Value: p.Value, Weight: p.ProbabilityWeight}
 	}
-	c.JSON(http.StatusOK, gin.H{"prizes": list})
+	c.JSON(http.StatusOK, ConfigResponse{Prizes: list})
 }
 
+// History отдаёт историю спинов пользователя.
+// Query: limit (1..100), cursor (next_cursor предыдущей страницы), status, from, to (RFC 3339 или YYYY-MM-DD; to не включается).
 func (h *RouletteHandler) History(c *gin.Context) {
-	telegramUserID, ok := c.Get(middleware.TelegramUserIDKey)
+	user, ok := currentUser(c, h.userSvc)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
-	tid := telegramUserID.(int64)
 
-	ctx := c.Request.Context()
-	user, err := h.userSvc.GetByTelegramID(ctx, tid)
-	if err != nil || user == nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "phone required"})
+	filter, err := parseHistoryFilter(c)
+	if err != nil {
+		apierror.Respond(c, apierror.Wrap(apierror.CodeBadRequest, err))
 		return
 	}
 
-	history, err := h.rouletteSvc.GetHistory(ctx, user.ID, 10)
+	page, err := h.rouletteSvc.GetHistory(c.Request.Context(), user.ID, filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		apierror.Respond(c, fmt.Errorf("get spin history: %w", err))
 		return
 	}
 
-	type spinDTO struct {
-		ID        int64   `json:"id"`
-		PrizeName string  `json:"prize_name"`
-		PrizeType string  `json:"prize_type"`
-		Value     float64 `json:"value"`
-		CreatedAt string  `json:"created_at"`
-	}
-	list := make([]spinDTO, len(history))
-	for i, s := range history {
-		list[i] = spinDTO{
-			ID:        s.ID,
-			PrizeName: s.Prize.Name,
-			PrizeType: s.Prize.Type,
-			Value:     s.ResultValue,
-			CreatedAt: s.CreatedAt.Format("2006-01-02 15:04"),
+	list := make([]SpinHistoryDTO, len(page.Spins))
+	for i, s := range page.Spins {
+		list[i] = SpinHistoryDTO{
+			ID:          s.ID,
+			PrizeName:   s.Prize.Name,
+			PrizeType:   s.Prize.Type,
+			Value:       s.ResultValue,
+			CreatedAt:   s.CreatedAt,
+			Status:      string(s.Status),
+			VoucherCode: s.VoucherCode,
+			ExpiresAt:   s.ExpiresAt,
+			ClaimedAt:   s.ClaimedAt,
+			RedeemedAt:  s.RedeemedAt,
 		}
 	}
-	c.JSON(http.StatusOK, gin.H{"history": list})
+	resp := HistoryResponse{History: list, Total: page.Total}
+	if page.NextCursor != nil {
+		resp.NextCursor = encodeSpinCursor(page.NextCursor)
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
-func hashIP(ip string) string {
-	if ip == "" {
-		return ""
+func parseHistoryFilter(c *gin.Context) (domain.SpinFilter, error) {
+	f := domain.SpinFilter{Limit: defaultHistoryLimit}
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 100 {
+			return f, fmt.Errorf("invalid limit %q", v)
+		}
+		f.Limit = n
+	}
+	if v := c.Query("status"); v != "" {
+		st, ok := domain.ParseSpinStatus(v)
+		if !ok {
+			return f, fmt.Errorf("invalid status %q", v)
+		}
+		f.Status = st
+	}
+	var err error
+	if f.From, err = domain.ParseDateBound(c.Query("from"), false, time.UTC); err != nil {
+		return f, err
+	}
+	if f.To, err = domain.ParseDateBound(c.Query("to"), true, time.UTC); err != nil {
+		return f, err
+	}
+	if v := c.Query("cursor"); v != "" {
+		if f.After, err = decodeSpinCursor(v); err != nil {
+			return f, err
+		}
+	}
+	return f, nil
+}
+
+// encodeSpinCursor кодирует позицию (created_at, id) в непрозрачную для клиента строку.
+func encodeSpinCursor(cur *domain.SpinCursor) string {
+	raw := strconv.FormatInt(cur.CreatedAt.UnixMicro(), 10) + "_" + strconv.FormatInt(cur.ID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeSpinCursor(v string) (*domain.SpinCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(v)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	ts, id, ok := strings.Cut(string(raw), "_")
+	if !ok {
+		return nil, errInvalidCursor
 	}
-	// Normalize (e.g. strip port)
-	if idx := strings.LastIndex(ip, ":"); idx > 0 && strings.Contains(ip, ".") {
-		// IPv4 with port
-		ip = ip[:idx]
+	micros, err1 := strconv.ParseInt(ts, 10, 64)
+	spinID, err2 := strconv.ParseInt(id, 10, 64)
+	if err1 != nil || err2 != nil {
+		return nil, errInvalidCursor
 	}
-	h := sha256.Sum256([]byte(ip))
-	return hex.EncodeToString(h[:])
+	return &domain.SpinCursor{CreatedAt: time.UnixMicro(micros), ID: spinID}, nil
 }
 
 func (h *RouletteHandler) resolveStopSegment(ctx context.Context, prizeName string, spinID int64) int {
@@ -1,14 +1,22 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 
+	"era_sporta_bot_ruletka/internal/api/apierror"
 	"era_sporta_bot_ruletka/internal/api/middleware"
+	"era_sporta_bot_ruletka/internal/domain"
 	"era_sporta_bot_ruletka/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
 
+type UserResponse struct {
+	User  *UserDTO      `json:"user"`
+	State *UserStateDTO `json:"state"`
+}
+
 type UserHandler struct {
 	userSvc   *service.UserService
 	spinLimit int
@@ -19,33 +27,20 @@ func NewUserHandler(userSvc *service.UserService, spinLimit int) *UserHandler {
 }
 
 func (h *UserHandler) Me(c *gin.Context) {
-	telegramUserID, ok := c.Get(middleware.TelegramUserIDKey)
+	user, ok := currentUser(c, h.userSvc)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-		return
-	}
-	tid := telegramUserID.(int64)
-
-	ctx := c.Request.Context()
-	user, err := h.userSvc.GetByTelegramID(ctx, tid)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
-		return
-	}
-	if user == nil || user.Phone == "" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "phone required"})
 		return
 	}
 
-	state, err := h.userSvc.GetUserState(ctx, user, h.spinLimit)
+	state, err := h.userSvc.GetUserState(c.Request.Context(), user, h.spinLimit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		apierror.Respond(c, fmt.Errorf("get user state: %w", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"user":  toUserDTO(user),
-		"state": toUserStateDTO(state),
+	c.JSON(http.StatusOK, UserResponse{
+		User:  toUserDTO(user),
+		State: toUserStateDTO(state),
 	})
 }
 
@@ -53,3 +48,19 @@ func (h *UserHandler) State(c *gin.Context) {
 	// Same as Me, returns just state for Mini App
 	h.Me(c)
 }
+
+// currentUser загружает пользователя, поделившегося номером, по telegram_user_id из сессии.
+// При ошибке пишет ответ и возвращает false.
+func currentUser(c *gin.Context, userSvc *service.UserService) (*domain.User, bool) {
+	tid, ok := c.Get(middleware.TelegramUserIDKey)
+	if !ok {
+		apierror.Abort(c, apierror.CodeUnauthorized)
+		return nil, false
+	}
+	user, err := userSvc.GetRegistered(c.Request.Context(), tid.(int64))
+	if err != nil {
+		apierror.Respond(c, fmt.Errorf("get current user: %w", err))
+		return nil, false
+	}
+	return user, true
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"era_sporta_bot_ruletka/internal/api/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuth пропускает запросы с Authorization: Bearer <ADMIN_API_TOKEN>.
+// Пустой token закрывает доступ всем.
+func AdminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		auth := c.GetHeader("Authorization")
+		if !strings.HasPrefix(auth, "Bearer ") {
+			apierror.Abort(c, apierror.CodeUnauthorized)
+			return
+		}
+		got := strings.TrimPrefix(auth, "Bearer ")
+		if token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			apierror.Abort(c, apierror.CodeForbidden)
+			return
+		}
+		c.Next()
+	}
+}
@@ -1,69 +1,97 @@
 package middleware
 
 import (
-	"net/http"
+	"log/slog"
+	"strconv"
 	"strings"
 
-	"era_sporta_bot_ruletka/internal/telegram"
+	"era_sporta_bot_ruletka/internal/api/apierror"
+	"era_sporta_bot_ruletka/internal/logger"
+	"era_sporta_bot_ruletka/internal/metrics"
+	"era_sporta_bot_ruletka/internal/session"
 
 	"github.com/gin-gonic/gin"
 )
 
+// NonceHeader — одноразовый идентификатор запроса на спин (защита от повторной отправки).
+const NonceHeader = "X-Request-Nonce"
+
 type AuthMiddleware struct {
-	botToken string
+	sessions *session.Manager
+	nonces   *session.NonceStore
 }
 
-func NewAuthMiddleware(botToken string) *AuthMiddleware {
-	return &AuthMiddleware{botToken: botToken}
+// NewAuthMiddleware создаёт middleware авторизации. nonces == nil отключает проверку nonce на спине.
+func NewAuthMiddleware(sessions *session.Manager, nonces *session.NonceStore) *AuthMiddleware {
+	return &AuthMiddleware{sessions: sessions, nonces: nonces}
 }
 
-type authKey struct{}
-
 // TelegramUserID is the key for storing telegram user id in context
 const TelegramUserIDKey = "telegram_user_id"
 
-func (m *AuthMiddleware) InitDataAuth() gin.HandlerFunc {
+// SessionKey is the key for storing *session.Claims in context
+const SessionKey = "session"
+
+// StartParamKey is the key for storing start_param from session token in context
+const StartParamKey = "start_param"
+
+// StartParam возвращает start_param, с которым открыто Mini App, или пустую строку.
+func StartParam(c *gin.Context) string {
+	return c.GetString(StartParamKey)
+}
+
+// Session возвращает claims сессионного токена текущего запроса.
+func Session(c *gin.Context) *session.Claims {
+	v, ok := c.Get(SessionKey)
+	if !ok {
+		return nil
+	}
+	claims, _ := v.(*session.Claims)
+	return claims
+}
+
+// SessionAuth пропускает запросы с действующим access-токеном из /api/auth (Authorization: Bearer <token>).
+// initData здесь больше не принимается: её проверяет только /api/auth.
+func (m *AuthMiddleware) SessionAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		initData := c.GetHeader("X-Telegram-Init-Data")
-		if initData == "" {
-			initData = c.Query("initData")
-		}
-		if initData == "" {
-			var body struct {
-				InitData string `json:"initData"`
-			}
-			if err := c.ShouldBindJSON(&body); err == nil && body.InitData != "" {
-				initData = body.InitData
-			}
+		auth := c.GetHeader("Authorization")
+		if !strings.HasPrefix(auth, "Bearer ") {
+			metrics.ObserveInitDataFailure("missing_token")
+			apierror.Abort(c, apierror.CodeUnauthorized)
+			return
 		}
-		// Also check Authorization: Bearer <initData> for compatibility
-		if initData == "" {
-			auth := c.GetHeader("Authorization")
-			if strings.HasPrefix(auth, "Bearer ") {
-				initData = strings.TrimPrefix(auth, "Bearer ")
-			}
+		claims, err := m.sessions.ParseAccess(strings.TrimPrefix(auth, "Bearer "))
+		if err != nil {
+			metrics.ObserveInitDataFailure("invalid_token")
+			apierror.Abort(c, apierror.CodeInvalidToken)
+			return
 		}
 
-		if initData == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "initData required"})
-			c.Abort()
+		SetLogContext(c, slog.Int64(logger.KeyTelegramUserID, claims.TelegramUserID), slog.Int64(logger.KeyUserID, claims.UserID))
+		c.Set(SessionKey, claims)
+		c.Set(StartParamKey, claims.StartParam)
+		c.Set(TelegramUserIDKey, claims.TelegramUserID)
+		c.Next()
+	}
+}
+
+// SpinNonce отклоняет повторно использованный X-Request-Nonce. Работает после SessionAuth.
+func (m *AuthMiddleware) SpinNonce() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if m.nonces == nil {
+			c.Next()
 			return
 		}
-
-		result, err := telegram.ValidateInitData(initData, m.botToken)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "validation failed"})
-			c.Abort()
+		nonce := strings.TrimSpace(c.GetHeader(NonceHeader))
+		if nonce == "" || len(nonce) > 128 {
+			apierror.Abort(c, apierror.CodeNonceRequired)
 			return
 		}
-		if !result.Valid || result.User == nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid initData"})
-			c.Abort()
+		tid := c.GetInt64(TelegramUserIDKey)
+		if !m.nonces.Use(strconv.FormatInt(tid, 10) + ":" + nonce) {
+			apierror.Abort(c, apierror.CodeNonceReused)
 			return
 		}
-
-		c.Set(TelegramUserIDKey, result.User.ID)
-		c.Set("init_data_user", result.User)
 		c.Next()
 	}
 }
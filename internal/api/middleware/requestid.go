@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"time"
+
+	"era_sporta_bot_ruletka/internal/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader — заголовок, через который request ID принимается от Nginx и возвращается клиенту.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID кладёт request ID в context.Context запроса, чтобы он попадал в логи сервисов и репозиториев.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if id == "" || len(id) > 64 {
+			id = newRequestID()
+		}
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// AccessLog пишет структурированную запись о каждом запросе вместо стандартного логгера Gin.
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		level := slog.LevelInfo
+		status := c.Writer.Status()
+		if status >= 500 {
+			level = slog.LevelError
+		}
+		slog.Log(c.Request.Context(), level, "http request",
+			"method", c.Request.Method,
+			"route", c.FullPath(),
+			"path", c.Request.URL.Path,
+			"status", status,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"client_ip", c.ClientIP(),
+		)
+	}
+}
+
+// SetLogContext добавляет поля в лог-контекст текущего запроса.
+func SetLogContext(c *gin.Context, attrs ...slog.Attr) {
+	c.Request = c.Request.WithContext(logger.WithAttrs(c.Request.Context(), attrs...))
+}
+
+func newRequestID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SecurityConfig — настройки CORS и заголовков безопасности.
+type SecurityConfig struct {
+	// AllowedOrigins — origin'ы, которым разрешены CORS-запросы. "*" разрешает всех.
+	AllowedOrigins []string
+	// CORSMaxAge — сколько браузер кеширует ответ на preflight.
+	CORSMaxAge time.Duration
+	// HSTSMaxAge — max-age для Strict-Transport-Security; 0 отключает заголовок.
+	HSTSMaxAge time.Duration
+}
+
+const (
+	corsAllowMethods = "GET, POST, PUT, DELETE, OPTIONS"
+	corsAllowHeaders = "Content-Type, Authorization, X-Request-Nonce, X-Request-ID"
+)
+
+// CORS отвечает на preflight и выставляет Access-Control-* только для разрешённых origin'ов.
+func CORS(cfg SecurityConfig) gin.HandlerFunc {
+	allowAll := false
+	allowed := make(map[string]struct{}, len(cfg.AllowedOrigins))
+	for _, o := range cfg.AllowedOrigins {
+		o = strings.TrimRight(strings.TrimSpace(o), "/")
+		if o == "*" {
+			allowAll = true
+		}
+		if o != "" {
+			allowed[strings.ToLower(o)] = struct{}{}
+		}
+	}
+	maxAge := strconv.Itoa(int(cfg.CORSMaxAge.Seconds()))
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Origin")
+		_, ok := allowed[strings.ToLower(origin)]
+		if !ok && !allowAll {
+			if c.Request.Method == http.MethodOptions {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		c.Header("Access-Control-Allow-Origin", origin)
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			c.Header("Access-Control-Allow-Methods", corsAllowMethods)
+			c.Header("Access-Control-Allow-Headers", corsAllowHeaders)
+			c.Header("Access-Control-Max-Age", maxAge)
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Header("Access-Control-Expose-Headers", RequestIDHeader)
+		c.Next()
+	}
+}
+
+// SecurityHeaders выставляет CSP, HSTS и прочие заголовки для ответов API и статики Mini App.
+func SecurityHeaders(cfg SecurityConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		WriteSecurityHeaders(c.Writer.Header(), cfg, c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https")
+		c.Next()
+	}
+}
+
+// WriteSecurityHeaders — то же для обычного net/http (cmd/serveweb).
+func WriteSecurityHeaders(h http.Header, cfg SecurityConfig, https bool) {
+	h.Set("Content-Security-Policy", contentSecurityPolicy(cfg.AllowedOrigins))
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+	if https && cfg.HSTSMaxAge > 0 {
+		h.Set("Strict-Transport-Security", "max-age="+strconv.Itoa(int(cfg.HSTSMaxAge.Seconds()))+"; includeSubDomains")
+	}
+}
+
+// contentSecurityPolicy разрешает скрипт Telegram WebApp, inline-скрипты index.html
+// и встраивание Mini App во фрейм веб-клиентов Telegram (X-Frame-Options поэтому не ставим).
+func contentSecurityPolicy(allowedOrigins []string) string {
+	connect := []string{"'self'"}
+	for _, o := range allowedOrigins {
+		o = strings.TrimSpace(o)
+		if o != "" && o != "*" {
+			connect = append(connect, o)
+		}
+	}
+	return strings.Join([]string{
+		"default-src 'self'",
+		"script-src 'self' 'unsafe-inline' https://telegram.org",
+		"style-src 'self' 'unsafe-inline'",
+		"img-src 'self' data: https:",
+		"connect-src " + strings.Join(connect, " "),
+		"frame-ancestors 'self' https://web.telegram.org https://*.telegram.org",
+		"base-uri 'self'",
+		"object-src 'none'",
+	}, "; ")
+}
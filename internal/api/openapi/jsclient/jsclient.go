@@ -0,0 +1,385 @@
+// Package jsclient генерирует из openapi.json типизированный клиент API для Mini App (web/api.js):
+// JSDoc-типы схем и по функции на каждую операцию /api/*, кроме админских.
+// Запуск: go generate ./web
+package jsclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// adminScheme — схема безопасности админских операций; они в клиент Mini App не попадают.
+const adminScheme = "admin"
+
+// sessionScheme — операции с этой схемой получают Authorization: Bearer <access-токен>.
+const sessionScheme = "session"
+
+// renamed — схемы, имена которых заняты встроенными типами JS.
+var renamed = map[string]string{"Error": "ErrorResponse"}
+
+var methodOrder = map[string]int{
+	http.MethodGet: 0, http.MethodPost: 1, http.MethodPut: 2, http.MethodPatch: 3, http.MethodDelete: 4,
+}
+
+var (
+	spaces     = regexp.MustCompile(`\s+`)
+	identifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+)
+
+// Generate возвращает исходник web/api.js для спецификации spec.
+func Generate(spec []byte) ([]byte, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("load spec: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid spec: %w", err)
+	}
+
+	var b bytes.Buffer
+	b.WriteString(header)
+	writeTypedefs(&b, doc)
+	b.WriteString(runtimeStart)
+	for i, op := range operations(doc) {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		if err := writeOperation(&b, op); err != nil {
+			return nil, err
+		}
+	}
+	b.WriteString(runtimeEnd)
+	return b.Bytes(), nil
+}
+
+type operation struct {
+	method  string
+	path    string
+	item    *openapi3.PathItem
+	op      *openapi3.Operation
+	session bool
+}
+
+// operations возвращает операции Mini App в стабильном порядке: по пути, затем по методу.
+func operations(doc *openapi3.T) []operation {
+	var out []operation
+	for path, item := range doc.Paths.Map() {
+		if !strings.HasPrefix(path, "/api/") {
+			continue
+		}
+		for method, op := range item.Operations() {
+			if requiresScheme(doc, op, adminScheme) {
+				continue
+			}
+			out = append(out, operation{method: method, path: path, item: item, op: op, session: requiresScheme(doc, op, sessionScheme)})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].path != out[j].path {
+			return out[i].path < out[j].path
+		}
+		return methodOrder[out[i].method] < methodOrder[out[j].method]
+	})
+	return out
+}
+
+func requiresScheme(doc *openapi3.T, op *openapi3.Operation, scheme string) bool {
+	security := op.Security
+	if security == nil {
+		security = &doc.Security
+	}
+	for _, req := range *security {
+		if _, ok := req[scheme]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func writeTypedefs(b *bytes.Buffer, doc *openapi3.T) {
+	names := make([]string, 0, len(doc.Components.Schemas))
+	for name := range doc.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s := doc.Components.Schemas[name].Value
+		b.WriteString("/**\n")
+		if s.Description != "" {
+			fmt.Fprintf(b, " * %s\n", oneLine(s.Description))
+		}
+		if !s.Type.Is(openapi3.TypeObject) || len(s.Properties) == 0 {
+			fmt.Fprintf(b, " * @typedef {%s} %s\n */\n\n", jsType(&openapi3.SchemaRef{Value: s}), typeName(name))
+			continue
+		}
+		fmt.Fprintf(b, " * @typedef {Object} %s\n", typeName(name))
+		for _, prop := range sortedKeys(s.Properties) {
+			field := prop
+			if !contains(s.Required, prop) {
+				field = "[" + prop + "]"
+			}
+			line := fmt.Sprintf(" * @property {%s} %s", jsType(s.Properties[prop]), field)
+			if d := s.Properties[prop].Value.Description; d != "" && s.Properties[prop].Ref == "" {
+				line += " " + oneLine(d)
+			}
+			b.WriteString(line + "\n")
+		}
+		b.WriteString(" */\n\n")
+	}
+}
+
+func writeOperation(b *bytes.Buffer, o operation) error {
+	if o.op.OperationID == "" {
+		return fmt.Errorf("%s %s: operationId is required", o.method, o.path)
+	}
+	params := append(openapi3.Parameters{}, o.item.Parameters...)
+	params = append(params, o.op.Parameters...)
+
+	var args, paramFields, query, headers []string
+	paramsRequired := false
+	for _, p := range params {
+		v := p.Value
+		name := v.Name
+		if !identifier.MatchString(name) {
+			name = jsString(name)
+		}
+		if !v.Required {
+			name += "?"
+		} else {
+			paramsRequired = true
+		}
+		paramFields = append(paramFields, name+": "+jsType(v.Schema))
+		switch v.In {
+		case openapi3.ParameterInQuery:
+			query = append(query, jsString(v.Name))
+		case openapi3.ParameterInHeader:
+			headers = append(headers, jsString(v.Name))
+		}
+	}
+
+	b.WriteString("      /**\n")
+	if o.op.Summary != "" {
+		fmt.Fprintf(b, "       * %s\n", oneLine(o.op.Summary))
+	}
+	if len(paramFields) > 0 {
+		name := "params"
+		if !paramsRequired {
+			name = "[params]"
+		}
+		fmt.Fprintf(b, "       * @param {{%s}} %s\n", strings.Join(paramFields, ", "), name)
+		args = append(args, "params")
+	}
+	if body := jsonSchema(o.op.RequestBody); body != nil {
+		fmt.Fprintf(b, "       * @param {%s} body\n", jsType(body))
+		args = append(args, "body")
+	}
+	fmt.Fprintf(b, "       * @returns {Promise<ApiResult<%s>>}\n", successType(o.op))
+	b.WriteString("       */\n")
+
+	callParams, callBody := "params", "body"
+	if len(paramFields) == 0 {
+		callParams = "undefined"
+	}
+	if jsonSchema(o.op.RequestBody) == nil {
+		callBody = "undefined"
+	}
+	fmt.Fprintf(b, "      %s(%s) {\n", o.op.OperationID, strings.Join(args, ", "))
+	fmt.Fprintf(b, "        return call(%s, %s, { query: [%s], headers: [%s], session: %t }, %s, %s);\n",
+		jsString(o.method), jsString(o.path), strings.Join(query, ", "), strings.Join(headers, ", "),
+		o.session, callParams, callBody)
+	b.WriteString("      },\n")
+	return nil
+}
+
+func jsonSchema(body *openapi3.RequestBodyRef) *openapi3.SchemaRef {
+	if body == nil || body.Value == nil {
+		return nil
+	}
+	if mt := body.Value.Content.Get("application/json"); mt != nil {
+		return mt.Schema
+	}
+	return nil
+}
+
+// successType — тип тела первого успешного (2xx) JSON-ответа.
+func successType(op *openapi3.Operation) string {
+	codes := sortedKeys(op.Responses.Map())
+	for _, code := range codes {
+		if !strings.HasPrefix(code, "2") {
+			continue
+		}
+		resp := op.Responses.Value(code).Value
+		if mt := resp.Content.Get("application/json"); mt != nil && mt.Schema != nil {
+			return jsType(mt.Schema)
+		}
+		return "undefined"
+	}
+	return "undefined"
+}
+
+// jsType переводит схему в тип JSDoc (синтаксис Closure Compiler, понятный TypeScript).
+func jsType(ref *openapi3.SchemaRef) string {
+	if ref == nil || ref.Value == nil {
+		return "*"
+	}
+	if ref.Ref != "" {
+		return typeName(ref.Ref[strings.LastIndex(ref.Ref, "/")+1:])
+	}
+	s := ref.Value
+	var t string
+	switch {
+	case len(s.Enum) > 0:
+		variants := make([]string, len(s.Enum))
+		for i, v := range s.Enum {
+			if str, ok := v.(string); ok {
+				variants[i] = jsString(str)
+			} else {
+				variants[i] = fmt.Sprint(v)
+			}
+		}
+		t = "(" + strings.Join(variants, "|") + ")"
+	case s.Type.Is(openapi3.TypeString):
+		t = "string"
+	case s.Type.Is(openapi3.TypeInteger), s.Type.Is(openapi3.TypeNumber):
+		t = "number"
+	case s.Type.Is(openapi3.TypeBoolean):
+		t = "boolean"
+	case s.Type.Is(openapi3.TypeArray):
+		t = "Array<" + jsType(s.Items) + ">"
+	case s.Type.Is(openapi3.TypeObject) && len(s.Properties) > 0:
+		fields := make([]string, 0, len(s.Properties))
+		for _, name := range sortedKeys(s.Properties) {
+			field := name
+			if !contains(s.Required, name) {
+				field += "?"
+			}
+			fields = append(fields, field+": "+jsType(s.Properties[name]))
+		}
+		t = "{" + strings.Join(fields, ", ") + "}"
+	case s.Type.Is(openapi3.TypeObject) && s.AdditionalProperties.Schema != nil:
+		t = "Object<string, " + jsType(s.AdditionalProperties.Schema) + ">"
+	case s.Type.Is(openapi3.TypeObject):
+		t = "Object"
+	default:
+		t = "*"
+	}
+	if s.Nullable {
+		t = "?" + t
+	}
+	return t
+}
+
+var jsEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// jsString — строковый литерал JS в одинарных кавычках, как в web/index.html.
+func jsString(s string) string {
+	return "'" + jsEscaper.Replace(s) + "'"
+}
+
+func typeName(schema string) string {
+	if n, ok := renamed[schema]; ok {
+		return n
+	}
+	return schema
+}
+
+func oneLine(s string) string {
+	return strings.TrimSpace(spaces.ReplaceAllString(s, " "))
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+const header = `// Code generated by go run ./cmd/apiclientgen; DO NOT EDIT.
+// Типизированный клиент API Mini App по internal/api/openapi/openapi.json.
+// Для изменения поправьте спецификацию и выполните go generate ./web.
+
+/**
+ * Результат вызова: data — тело успешного ответа, error — тело ошибки API.
+ * status 0 означает, что запрос не дошёл до сервера.
+ * @template T
+ * @typedef {Object} ApiResult
+ * @property {boolean} ok
+ * @property {number} status
+ * @property {T} [data]
+ * @property {ErrorResponse} [error]
+ */
+
+`
+
+const runtimeStart = `(function (global) {
+  'use strict';
+
+  /**
+   * Создаёт клиент API.
+   * @param {{baseUrl?: string, getToken?: function(): string}} [options]
+   *   baseUrl — origin API (по умолчанию тот же), getToken — текущий access-токен сессии.
+   */
+  function createApiClient(options) {
+    const baseUrl = (options && options.baseUrl) || '';
+    const getToken = (options && options.getToken) || (() => '');
+
+    async function call(method, path, spec, params, body) {
+      params = params || {};
+      let url = baseUrl + path.replace(/\{(\w+)\}/g, (_, name) => encodeURIComponent(params[name]));
+      const query = new URLSearchParams();
+      spec.query.forEach(name => {
+        if (params[name] !== undefined && params[name] !== null && params[name] !== '') {
+          query.append(name, String(params[name]));
+        }
+      });
+      if (query.toString()) url += '?' + query.toString();
+
+      const headers = {};
+      spec.headers.forEach(name => {
+        if (params[name] !== undefined && params[name] !== null) headers[name] = String(params[name]);
+      });
+      if (spec.session) {
+        const token = getToken();
+        if (token) headers['Authorization'] = 'Bearer ' + token;
+      }
+      if (body !== undefined) headers['Content-Type'] = 'application/json';
+
+      let res;
+      try {
+        res = await fetch(url, { method, headers, body: body === undefined ? undefined : JSON.stringify(body) });
+      } catch (_) {
+        return { ok: false, status: 0 };
+      }
+      const payload = await res.json().catch(() => undefined);
+      return res.ok
+        ? { ok: true, status: res.status, data: payload }
+        : { ok: false, status: res.status, error: payload };
+    }
+
+    return {
+`
+
+const runtimeEnd = `    };
+  }
+
+  global.EraSportaApi = { createApiClient };
+})(window);
+`
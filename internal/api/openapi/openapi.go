@@ -0,0 +1,22 @@
+// Package openapi содержит спецификацию OpenAPI 3 для API Mini App.
+//
+// openapi.json — контракт между internal/api/handlers и web/index.html:
+// при изменении маршрутов в Router.Setup или DTO хендлеров обновляйте спецификацию
+// и перегенерируйте клиент web/api.js (go generate ./web).
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed openapi.json
+var Spec []byte
+
+// Handler отдаёт спецификацию как application/json.
+func Handler(c *gin.Context) {
+	c.Header("Cache-Control", "no-cache")
+	c.Data(http.StatusOK, "application/json; charset=utf-8", Spec)
+}
@@ -3,58 +3,86 @@ package api
 import (
 	"era_sporta_bot_ruletka/internal/api/handlers"
 	"era_sporta_bot_ruletka/internal/api/middleware"
+	"era_sporta_bot_ruletka/internal/api/openapi"
 	"era_sporta_bot_ruletka/internal/bot"
+	"era_sporta_bot_ruletka/internal/health"
 
 	"github.com/gin-gonic/gin"
 )
 
 type Router struct {
-	authHandler    *handlers.AuthHandler
-	userHandler    *handlers.UserHandler
+	authHandler     *handlers.AuthHandler
+	userHandler     *handlers.UserHandler
 	rouletteHandler *handlers.RouletteHandler
-	authMiddleware *middleware.AuthMiddleware
+	adminHandler    *handlers.AdminHandler
+	authMiddleware  *middleware.AuthMiddleware
+	health          *health.Checker
+	security        middleware.SecurityConfig
+	adminToken      string
 }
 
 func NewRouter(
 	authHandler *handlers.AuthHandler,
 	userHandler *handlers.UserHandler,
 	rouletteHandler *handlers.RouletteHandler,
-	botToken string,
+	adminHandler *handlers.AdminHandler,
+	authMiddleware *middleware.AuthMiddleware,
+	healthChecker *health.Checker,
+	security middleware.SecurityConfig,
+	adminToken string,
 ) *Router {
 	return &Router{
 		authHandler:     authHandler,
 		userHandler:     userHandler,
 		rouletteHandler: rouletteHandler,
-		authMiddleware:  middleware.NewAuthMiddleware(botToken),
+		adminHandler:    adminHandler,
+		authMiddleware:  authMiddleware,
+		health:          healthChecker,
+		security:        security,
+		adminToken:      adminToken,
 	}
 }
 
 func (r *Router) Setup(app *gin.Engine) {
-	app.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Telegram-Init-Data")
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
-		c.Next()
-	})
+	app.Use(middleware.Metrics())
+	app.Use(middleware.SecurityHeaders(r.security))
+	app.Use(middleware.CORS(r.security))
+	app.GET("/healthz", gin.WrapF(health.LivenessHandler()))
+	app.GET("/readyz", gin.WrapF(r.health.ReadinessHandler()))
+
 	api := app.Group("/api")
 	{
 		// Public
 		api.POST("/auth", r.authHandler.Auth)
+		api.POST("/auth/refresh", r.authHandler.Refresh)
 		api.GET("/roulette/config", r.rouletteHandler.Config)
+		api.GET("/openapi.json", openapi.Handler)
 
-		// Protected (require initData)
+		// Protected (require session token from /api/auth)
 		protected := api.Group("")
-		protected.Use(r.authMiddleware.InitDataAuth())
+		protected.Use(r.authMiddleware.SessionAuth())
 		{
 			protected.GET("/user/me", r.userHandler.Me)
 			protected.GET("/user/state", r.userHandler.State)
-			protected.POST("/roulette/spin", r.rouletteHandler.Spin)
+			protected.POST("/roulette/spin", r.authMiddleware.SpinNonce(), r.rouletteHandler.Spin)
 			protected.GET("/roulette/history", r.rouletteHandler.History)
 		}
+
+		// Admin (Authorization: Bearer <ADMIN_API_TOKEN>); без токена маршруты не регистрируются
+		if r.adminToken != "" {
+			admin := api.Group("/admin")
+			admin.Use(middleware.AdminAuth(r.adminToken))
+			{
+				admin.GET("/stats", r.adminHandler.Stats)
+				admin.GET("/export", r.adminHandler.Export)
+				admin.GET("/webhooks", r.adminHandler.Webhooks)
+				admin.GET("/blocks", r.adminHandler.Blocks)
+				admin.PUT("/users/:telegram_user_id/block", r.adminHandler.BlockUser)
+				admin.DELETE("/users/:telegram_user_id/block", r.adminHandler.UnblockUser)
+				admin.PUT("/phone-bans/:phone", r.adminHandler.BanPhone)
+				admin.DELETE("/phone-bans/:phone", r.adminHandler.UnbanPhone)
+			}
+		}
 	}
 }
 
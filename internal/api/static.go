@@ -0,0 +1,81 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"era_sporta_bot_ruletka/internal/api/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Кеширование: index.html всегда перепроверяется (в нём версия фронтенда),
+// остальные файлы кешируются на сутки и валидируются по ETag.
+const (
+	indexCacheControl = "no-cache"
+	assetCacheControl = "public, max-age=86400"
+)
+
+// ServeWeb раздаёт Mini App из files на все GET-пути, не занятые API.
+func ServeWeb(app *gin.Engine, files fs.FS) error {
+	etags, err := computeETags(files)
+	if err != nil {
+		return err
+	}
+	fileServer := http.FileServer(http.FS(files))
+
+	app.NoRoute(func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			apierror.Abort(c, apierror.CodeNotFound)
+			return
+		}
+		if strings.HasPrefix(c.Request.URL.Path, "/api/") {
+			apierror.Abort(c, apierror.CodeNotFound)
+			return
+		}
+
+		name := strings.TrimPrefix(path.Clean(c.Request.URL.Path), "/")
+		if name == "" {
+			name = "index.html"
+		}
+		etag, ok := etags[name]
+		if !ok {
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		if name == "index.html" {
+			c.Header("Cache-Control", indexCacheControl)
+		} else {
+			c.Header("Cache-Control", assetCacheControl)
+		}
+		c.Header("ETag", etag)
+		if c.GetHeader("If-None-Match") == etag {
+			c.Status(http.StatusNotModified)
+			return
+		}
+		fileServer.ServeHTTP(c.Writer, c.Request)
+	})
+	return nil
+}
+
+func computeETags(files fs.FS) (map[string]string, error) {
+	etags := make(map[string]string)
+	err := fs.WalkDir(files, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(files, p)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		etags[p] = `"` + hex.EncodeToString(sum[:8]) + `"`
+		return nil
+	})
+	return etags, err
+}
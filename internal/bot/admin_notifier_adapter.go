@@ -2,24 +2,67 @@ package bot
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"log/slog"
+	"time"
 
 	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/notifier"
+	"era_sporta_bot_ruletka/internal/service"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-// AdminNotifierAdapter adapts Notifier to notifier.AdminNotifier interface
+// AdminNotifierAdapter adapts the notification outbox to notifier.AdminNotifier interface.
+// Сообщение с кнопками действий кладётся в очередь и доставляется процессом бота (OutboxDispatcher).
 type AdminNotifierAdapter struct {
-	notifier *Notifier
+	outbox    *service.OutboxService
+	templates *service.TemplateService
 }
 
-func NewAdminNotifierAdapter(notifier *Notifier) *AdminNotifierAdapter {
-	return &AdminNotifierAdapter{notifier: notifier}
+func NewAdminNotifierAdapter(outbox *service.OutboxService, templates *service.TemplateService) *AdminNotifierAdapter {
+	return &AdminNotifierAdapter{outbox: outbox, templates: templates}
 }
 
-func (a *AdminNotifierAdapter) NotifySpin(ctx context.Context, user *domain.User, prizeName string) {
-	if a.notifier == nil {
+func (a *AdminNotifierAdapter) NotifySpin(ctx context.Context, user *domain.User, spin *domain.SpinWithPrize) {
+	if a.outbox == nil {
+		return
+	}
+	text, err := RenderAdminSpin(ctx, a.templates, user, spin)
+	if err != nil {
+		slog.ErrorContext(ctx, "render admin spin notification failed", "error", err)
 		return
 	}
-	text := fmt.Sprintf("🎰 Новый спин!\nНомер: %s\nЧто выиграл: %s", user.Phone, prizeName)
-	a.notifier.NotifyWithTime(ctx, text)
+	m := domain.OutboxMessage{Text: text, ParseMode: tgbotapi.ModeHTML}
+	if markup := AdminSpinMarkup(user, spin); markup != nil {
+		raw, err := json.Marshal(markup)
+		if err != nil {
+			slog.ErrorContext(ctx, "encode admin spin buttons failed", "error", err)
+			return
+		}
+		m.ReplyMarkup = raw
+	}
+	if err := a.outbox.EnqueueEvent(ctx, notifier.EventSpin, spin.Prize, m); err != nil {
+		slog.ErrorContext(ctx, "enqueue admin notification failed", "error", err)
+	}
+}
+
+// NewSpinNotifier возвращает уведомитель о спинах для режима ADMIN_SPIN_NOTIFY_MODE:
+// each — сообщение на каждый спин, batch — сводка раз в batchInterval (батчер нужно запустить через Run),
+// off — без уведомлений (nil). Время спинов в сводке показывается в loc.
+func NewSpinNotifier(outbox *service.OutboxService, templates *service.TemplateService, mode string, batchInterval time.Duration, loc *time.Location) (notifier.AdminNotifier, *SpinBatcher, error) {
+	switch mode {
+	case SpinNotifyEach, "":
+		return NewAdminNotifierAdapter(outbox, templates), nil, nil
+	case SpinNotifyBatch:
+		if batchInterval <= 0 {
+			return nil, nil, fmt.Errorf("ADMIN_SPIN_BATCH_INTERVAL_MIN must be positive")
+		}
+		b := NewSpinBatcher(outbox, batchInterval, loc)
+		return b, b, nil
+	case SpinNotifyOff:
+		return nil, nil, nil
+	}
+	return nil, nil, fmt.Errorf("ADMIN_SPIN_NOTIFY_MODE: unknown mode %q", mode)
 }
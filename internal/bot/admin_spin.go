@@ -0,0 +1,230 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/logger"
+	"era_sporta_bot_ruletka/internal/notifier"
+	"era_sporta_bot_ruletka/internal/service"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Кнопки под уведомлением о спине в админском чате; после префикса — id спина.
+const (
+	callbackSpinCalled   = "spin_called:"
+	callbackSpinRedeemed = "spin_redeemed:"
+	callbackSpinBlock    = "spin_block:"
+)
+
+var spinStatusIcons = map[domain.SpinStatus]string{
+	domain.SpinStatusIssued:   "🆕",
+	domain.SpinStatusClaimed:  "📞",
+	domain.SpinStatusRedeemed: "🎟",
+	domain.SpinStatusExpired:  "⌛",
+}
+
+// RenderAdminSpin отрисовывает уведомление о спине для админского чата по шаблону spin (HTML).
+func RenderAdminSpin(ctx context.Context, templates *service.TemplateService, user *domain.User, spin *domain.SpinWithPrize) (string, error) {
+	loc := templates.Location()
+	data := notifier.SpinData{
+		Name:       html.EscapeString(userName(user)),
+		Username:   html.EscapeString(user.Username),
+		Phone:      html.EscapeString(user.Phone),
+		TelegramID: user.TelegramUserID,
+		UserLink:   userLinkHTML(user),
+		Prize:      html.EscapeString(spin.Prize.Name),
+		Voucher:    html.EscapeString(spin.VoucherCode),
+		Source:     html.EscapeString(spinSource(spin)),
+		Status:     spinStatusIcons[spin.Status] + " " + spin.Status.Label(),
+		Blocked:    user.BlockedAt != nil,
+		Time:       spin.CreatedAt.In(loc).Format("02.01.2006 15:04"),
+	}
+	if spin.ExpiresAt != nil {
+		data.ExpiresAt = spin.ExpiresAt.In(loc).Format("02.01.2006")
+	}
+	return templates.Render(ctx, notifier.EventSpin, data)
+}
+
+// AdminSpinMarkup возвращает кнопки, доступные для спина в его текущем статусе, или nil.
+func AdminSpinMarkup(user *domain.User, spin *domain.SpinWithPrize) *tgbotapi.InlineKeyboardMarkup {
+	id := strconv.FormatInt(spin.ID, 10)
+	var row []tgbotapi.InlineKeyboardButton
+	if spin.Status == domain.SpinStatusIssued {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("Позвонили ✅", callbackSpinCalled+id))
+	}
+	if spin.Status == domain.SpinStatusIssued || spin.Status == domain.SpinStatusClaimed {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("Погашен 🎟", callbackSpinRedeemed+id))
+	}
+	if user.BlockedAt == nil {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("Заблокировать ⛔", callbackSpinBlock+id))
+	}
+	if len(row) == 0 {
+		return nil
+	}
+	markup := tgbotapi.NewInlineKeyboardMarkup(row)
+	return &markup
+}
+
+// userName — имя и фамилия пользователя.
+func userName(u *domain.User) string {
+	name := strings.TrimSpace(u.FirstName + " " + u.LastName)
+	if name == "" {
+		name = "Без имени"
+	}
+	return name
+}
+
+// userLinkHTML — имя пользователя со ссылкой на профиль: t.me по username или tg://user по id.
+func userLinkHTML(u *domain.User) string {
+	name := html.EscapeString(userName(u))
+	if u.Username != "" {
+		return fmt.Sprintf(`%s (<a href="https://t.me/%s">@%s</a>)`, name, html.EscapeString(u.Username), html.EscapeString(u.Username))
+	}
+	return fmt.Sprintf(`<a href="tg://user?id=%d">%s</a>`, u.TelegramUserID, name)
+}
+
+// spinSource описывает, откуда пришёл пользователь, открывший рулетку.
+func spinSource(spin *domain.SpinWithPrize) string {
+	a := domain.ParseStartParam(spin.StartParam)
+	switch {
+	case a.ReferrerTelegramID != 0:
+		return fmt.Sprintf("реферал от %d", a.ReferrerTelegramID)
+	case spin.Campaign != "":
+		return "кампания " + spin.Campaign
+	}
+	return "напрямую"
+}
+
+// SpinActions обрабатывает кнопки под уведомлениями о спинах в админском чате.
+type SpinActions struct {
+	vouchers   *service.VoucherService
+	moderation *service.ModerationService
+	templates  *service.TemplateService
+	webhooks   *service.WebhookService
+}
+
+func NewSpinActions(vouchers *service.VoucherService, moderation *service.ModerationService, templates *service.TemplateService, webhooks *service.WebhookService) *SpinActions {
+	return &SpinActions{vouchers: vouchers, moderation: moderation, templates: templates, webhooks: webhooks}
+}
+
+// isSpinActionCallback сообщает, что data — кнопка действия со спином.
+func isSpinActionCallback(data string) bool {
+	return strings.HasPrefix(data, callbackSpinCalled) ||
+		strings.HasPrefix(data, callbackSpinRedeemed) ||
+		strings.HasPrefix(data, callbackSpinBlock)
+}
+
+// handleSpinActionCallback выполняет действие и перерисовывает исходное уведомление.
+// Возвращает текст всплывающего ответа на нажатие.
+func (h *Handler) handleSpinActionCallback(ctx context.Context, q *tgbotapi.CallbackQuery) string {
+	var action, rawID string
+	for _, prefix := range []string{callbackSpinCalled, callbackSpinRedeemed, callbackSpinBlock} {
+		if strings.HasPrefix(q.Data, prefix) {
+			action, rawID = prefix, strings.TrimPrefix(q.Data, prefix)
+		}
+	}
+	spinID, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil {
+		return ""
+	}
+	ctx = logger.WithAttrs(ctx, slog.Int64(logger.KeySpinID, spinID))
+	vouchers := h.spinActions.vouchers
+
+	spin, user, err := vouchers.Details(ctx, spinID)
+	if err != nil {
+		slog.ErrorContext(ctx, "load spin for admin action failed", "error", err)
+		return "Спин не найден"
+	}
+
+	var done string
+	switch action {
+	case callbackSpinCalled:
+		err, done = vouchers.MarkCalled(ctx, spinID), "Позвонили ✅"
+	case callbackSpinRedeemed:
+		err, done = vouchers.MarkRedeemed(ctx, spinID), "Погашен 🎟"
+	case callbackSpinBlock:
+		// Блокируется и номер: с новым аккаунтом на тот же номер крутить не получится
+		_, err = h.spinActions.moderation.BlockUser(ctx, user.TelegramUserID, fmt.Sprintf("спин #%d", spinID), adminName(q.From))
+		done = "Пользователь заблокирован ⛔"
+	}
+	applied := err == nil
+	answer := done
+	switch {
+	case errors.Is(err, service.ErrVoucherStatusConflict):
+		answer = "Статус уже изменён или ваучер истёк"
+	case err != nil:
+		slog.ErrorContext(ctx, "admin spin action failed", "error", err, "action", action)
+		return "Не удалось выполнить действие"
+	default:
+		slog.InfoContext(ctx, "admin spin action", "action", action, "admin_telegram_id", q.From.ID)
+	}
+
+	// Перечитываем спин, чтобы сообщение отражало актуальный статус (в т.ч. после чужого нажатия)
+	spin, user, err = vouchers.Details(ctx, spinID)
+	if err != nil {
+		slog.ErrorContext(ctx, "reload spin after admin action failed", "error", err)
+		return answer
+	}
+	text, err := RenderAdminSpin(ctx, h.spinActions.templates, user, spin)
+	if err != nil {
+		slog.ErrorContext(ctx, "render admin spin message failed", "error", err)
+		return answer
+	}
+	if applied {
+		text += fmt.Sprintf("\n\n%s — %s", html.EscapeString(done), html.EscapeString(adminName(q.From)))
+	}
+	edit := tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, text)
+	edit.ParseMode = tgbotapi.ModeHTML
+	edit.DisableWebPagePreview = true
+	edit.ReplyMarkup = AdminSpinMarkup(user, spin)
+	if _, err := h.bot.Request(edit); err != nil {
+		slog.WarnContext(ctx, "edit admin spin message failed", "error", err)
+	}
+	if applied && action == callbackSpinRedeemed {
+		h.sendRedemption(ctx, q, user, spin)
+		h.spinActions.webhooks.VoucherRedeemed(ctx, user, spin, adminName(q.From))
+	}
+	return answer
+}
+
+// adminName — как подписать менеджера, нажавшего кнопку.
+func adminName(u *tgbotapi.User) string {
+	if u.UserName != "" {
+		return "@" + u.UserName
+	}
+	return strings.TrimSpace(u.FirstName + " " + u.LastName)
+}
+
+// sendRedemption отвечает на уведомление о спине сообщением о погашении (шаблон redemption).
+func (h *Handler) sendRedemption(ctx context.Context, q *tgbotapi.CallbackQuery, user *domain.User, spin *domain.SpinWithPrize) {
+	templates := h.spinActions.templates
+	text, err := templates.Render(ctx, notifier.EventRedemption, notifier.RedemptionData{
+		Name:     html.EscapeString(userName(user)),
+		Phone:    html.EscapeString(user.Phone),
+		UserLink: userLinkHTML(user),
+		Prize:    html.EscapeString(spin.Prize.Name),
+		Voucher:  html.EscapeString(spin.VoucherCode),
+		Admin:    html.EscapeString(adminName(q.From)),
+		Time:     time.Now().In(templates.Location()).Format("02.01.2006 15:04"),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "render redemption message failed", "error", err)
+		return
+	}
+	msg := tgbotapi.NewMessage(q.Message.Chat.ID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.DisableWebPagePreview = true
+	msg.ReplyToMessageID = q.Message.MessageID
+	if _, err := h.bot.Send(msg); err != nil {
+		slog.ErrorContext(ctx, "send redemption message failed", "error", err)
+	}
+}
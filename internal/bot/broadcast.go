@@ -0,0 +1,223 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/metrics"
+	"era_sporta_bot_ruletka/internal/service"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// progressEvery — как часто сохранять счётчики доставки идущей рассылки.
+	progressEvery = 100
+	// maxFloodRetries — сколько раз повторять сообщение после 429 Too Many Requests.
+	maxFloodRetries = 3
+)
+
+// Результаты доставки одного сообщения рассылки.
+const (
+	deliverySent    = "sent"
+	deliveryFailed  = "failed"
+	deliveryBlocked = "blocked"
+)
+
+// Broadcaster отправляет рассылки пользователям с ограничением скорости и учётом заблокировавших бота.
+type Broadcaster struct {
+	bot        *tgbotapi.BotAPI
+	broadcasts *service.BroadcastService
+	userSvc    *service.UserService
+	funnel     *service.FunnelService
+	notifier   *Notifier
+	interval   time.Duration
+
+	mu      sync.Mutex
+	running map[int64]context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewBroadcaster создаёт рассыльщик. ratePerSec — не больше ~30, это лимит Telegram на бота.
+func NewBroadcaster(bot *tgbotapi.BotAPI, broadcasts *service.BroadcastService, userSvc *service.UserService, funnel *service.FunnelService, notifier *Notifier, ratePerSec int) *Broadcaster {
+	if ratePerSec <= 0 {
+		ratePerSec = 1
+	}
+	return &Broadcaster{
+		bot:        bot,
+		broadcasts: broadcasts,
+		userSvc:    userSvc,
+		funnel:     funnel,
+		notifier:   notifier,
+		interval:   time.Second / time.Duration(ratePerSec),
+		running:    make(map[int64]context.CancelFunc),
+	}
+}
+
+// Start запускает черновик рассылки в фоне. ctx должен жить до остановки процесса.
+func (br *Broadcaster) Start(ctx context.Context, id int64) (*domain.Broadcast, error) {
+	b, recipients, err := br.broadcasts.Start(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	br.mu.Lock()
+	br.running[id] = cancel
+	br.mu.Unlock()
+
+	br.wg.Add(1)
+	go func() {
+		defer br.wg.Done()
+		defer func() {
+			br.mu.Lock()
+			delete(br.running, id)
+			br.mu.Unlock()
+			cancel()
+		}()
+		br.run(runCtx, b, recipients)
+	}()
+	return b, nil
+}
+
+// Cancel отменяет черновик или останавливает идущую рассылку.
+func (br *Broadcaster) Cancel(ctx context.Context, id int64) error {
+	if err := br.broadcasts.Cancel(ctx, id); err != nil {
+		return err
+	}
+	br.mu.Lock()
+	if cancel, ok := br.running[id]; ok {
+		cancel()
+	}
+	br.mu.Unlock()
+	return nil
+}
+
+// Wait ждёт завершения идущих рассылок (после отмены их ctx).
+func (br *Broadcaster) Wait() {
+	br.wg.Wait()
+}
+
+func (br *Broadcaster) run(ctx context.Context, b *domain.Broadcast, recipients []domain.Recipient) {
+	slog.InfoContext(ctx, "broadcast started", "broadcast_id", b.ID, "recipients", len(recipients))
+	ticker := time.NewTicker(br.interval)
+	defer ticker.Stop()
+
+	b.Status = domain.BroadcastDone
+	for i, rc := range recipients {
+		select {
+		case <-ctx.Done():
+			b.Status = domain.BroadcastCancelled
+		case <-ticker.C:
+		}
+		if b.Status == domain.BroadcastCancelled {
+			break
+		}
+
+		switch result := br.deliver(ctx, b, rc); result {
+		case deliverySent:
+			b.Sent++
+		case deliveryBlocked:
+			b.Blocked++
+		default:
+			b.Failed++
+		}
+		if (i+1)%progressEvery == 0 {
+			if err := br.broadcasts.UpdateProgress(ctx, b); err != nil {
+				slog.WarnContext(ctx, "save broadcast progress failed", "error", err, "broadcast_id", b.ID)
+			}
+		}
+	}
+
+	// При отмене ctx уже закрыт — итог сохраняем без него
+	if err := br.broadcasts.Finish(context.WithoutCancel(ctx), b); err != nil {
+		slog.ErrorContext(ctx, "finish broadcast failed", "error", err, "broadcast_id", b.ID)
+	}
+	slog.InfoContext(ctx, "broadcast finished", "broadcast_id", b.ID, "status", string(b.Status),
+		"sent", b.Sent, "failed", b.Failed, "blocked", b.Blocked)
+	br.notifier.Notify(context.WithoutCancel(ctx), FormatBroadcastStats(b))
+}
+
+// deliver отправляет сообщение одному получателю, повторяя его после 429 с retry_after.
+func (br *Broadcaster) deliver(ctx context.Context, b *domain.Broadcast, rc domain.Recipient) string {
+	for attempt := 0; ; attempt++ {
+		_, err := br.bot.Send(broadcastMessage(b, rc.TelegramUserID))
+		if err == nil {
+			metrics.BroadcastDeliveries.WithLabelValues(deliverySent).Inc()
+			return deliverySent
+		}
+
+		var apiErr *tgbotapi.Error
+		if errors.As(err, &apiErr) {
+			switch {
+			case apiErr.Code == 403:
+				// Бот заблокирован или аккаунт удалён — больше не пишем этому пользователю
+				if err := br.userSvc.SetBotBlocked(ctx, rc.TelegramUserID, true); err != nil {
+					slog.WarnContext(ctx, "mark bot blocked failed", "error", err, "telegram_user_id", rc.TelegramUserID)
+				}
+				br.funnel.Track(ctx, rc.TelegramUserID, domain.FunnelEventBotBlocked, "")
+				metrics.BroadcastDeliveries.WithLabelValues(deliveryBlocked).Inc()
+				return deliveryBlocked
+			case apiErr.Code == 429 && apiErr.RetryAfter > 0 && attempt < maxFloodRetries:
+				select {
+				case <-ctx.Done():
+				case <-time.After(time.Duration(apiErr.RetryAfter) * time.Second):
+					continue
+				}
+			}
+		}
+		slog.WarnContext(ctx, "broadcast delivery failed", "error", err, "broadcast_id", b.ID, "telegram_user_id", rc.TelegramUserID)
+		metrics.BroadcastDeliveries.WithLabelValues(deliveryFailed).Inc()
+		return deliveryFailed
+	}
+}
+
+// broadcastMessage собирает сообщение рассылки: фото с подписью или текст, опционально с кнопкой-ссылкой.
+func broadcastMessage(b *domain.Broadcast, chatID int64) tgbotapi.Chattable {
+	var markup any
+	if b.ButtonText != "" && b.ButtonURL != "" {
+		markup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonURL(b.ButtonText, b.ButtonURL),
+		))
+	}
+	if b.PhotoFileID != "" {
+		photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileID(b.PhotoFileID))
+		photo.Caption = b.Text
+		photo.ReplyMarkup = markup
+		return photo
+	}
+	msg := tgbotapi.NewMessage(chatID, b.Text)
+	msg.ReplyMarkup = markup
+	return msg
+}
+
+var audienceNames = map[domain.BroadcastAudience]string{
+	domain.AudienceAll:        "все пользователи",
+	domain.AudienceSpun:       "крутили рулетку",
+	domain.AudienceNotSpun:    "ещё не крутили",
+	domain.AudiencePrize:      "выиграли приз",
+	domain.AudienceUnredeemed: "есть непогашенный ваучер",
+}
+
+var broadcastStatusNames = map[domain.BroadcastStatus]string{
+	domain.BroadcastDraft:     "черновик",
+	domain.BroadcastRunning:   "идёт",
+	domain.BroadcastDone:      "завершена",
+	domain.BroadcastCancelled: "отменена",
+	domain.BroadcastFailed:    "прервана",
+}
+
+// FormatBroadcastStats форматирует статистику рассылки для админского чата.
+func FormatBroadcastStats(b *domain.Broadcast) string {
+	audience := audienceNames[b.Audience]
+	if b.Audience == domain.AudiencePrize {
+		audience = fmt.Sprintf("%s #%d", audience, b.PrizeID)
+	}
+	return fmt.Sprintf("📣 Рассылка #%d — %s\nАудитория: %s\nВсего: %d\n✅ Доставлено: %d\n🚫 Заблокировали бота: %d\n⚠️ Ошибки: %d",
+		b.ID, broadcastStatusNames[b.Status], audience, b.Total, b.Sent, b.Blocked, b.Failed)
+}
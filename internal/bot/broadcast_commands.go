@@ -0,0 +1,165 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/logger"
+	"era_sporta_bot_ruletka/internal/service"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	callbackBroadcastStart  = "bc_start:"
+	callbackBroadcastCancel = "bc_cancel:"
+)
+
+const msgBroadcastUsage = "Ответьте на сообщение (текст или фото с подписью) командой:\n" +
+	"/broadcast <аудитория> [id приза] [| текст кнопки | https://ссылка]\n\n" +
+	"Аудитории: all, spun, not_spun, prize <id>, unredeemed\n" +
+	"Например: /broadcast unredeemed | Открыть | https://t.me/erasporta_apsheronsk"
+
+// handleBroadcastCommand создаёт черновик рассылки из сообщения, на которое ответил админ,
+// и присылает превью с кнопками «Отправить» и «Отмена».
+func (h *Handler) handleBroadcastCommand(ctx context.Context, msg *tgbotapi.Message) {
+	chatID := msg.Chat.ID
+	src := msg.ReplyToMessage
+	if src == nil || (src.Text == "" && len(src.Photo) == 0) {
+		h.send(ctx, chatID, msgBroadcastUsage)
+		return
+	}
+
+	parts := strings.Split(msg.CommandArguments(), "|")
+	audience, prizeID, err := domain.ParseAudience(strings.Fields(parts[0]))
+	if err != nil {
+		h.send(ctx, chatID, "Не удалось разобрать аудиторию: "+err.Error()+"\n\n"+msgBroadcastUsage)
+		return
+	}
+	b := &domain.Broadcast{
+		Audience:  audience,
+		PrizeID:   prizeID,
+		Text:      src.Text,
+		CreatedBy: msg.From.ID,
+	}
+	if len(src.Photo) > 0 {
+		b.PhotoFileID = src.Photo[len(src.Photo)-1].FileID
+		b.Text = src.Caption
+	}
+	if len(parts) == 3 {
+		b.ButtonText = strings.TrimSpace(parts[1])
+		b.ButtonURL = strings.TrimSpace(parts[2])
+		if !strings.HasPrefix(b.ButtonURL, "https://") {
+			h.send(ctx, chatID, "Ссылка кнопки должна начинаться с https://")
+			return
+		}
+	} else if len(parts) != 1 {
+		h.send(ctx, chatID, msgBroadcastUsage)
+		return
+	}
+
+	if err := h.broadcaster.broadcasts.CreateDraft(ctx, b); err != nil {
+		slog.ErrorContext(ctx, "create broadcast failed", "error", err)
+		h.send(ctx, chatID, "Не удалось создать рассылку. Попробуйте позже.")
+		return
+	}
+
+	reply := tgbotapi.NewMessage(chatID, FormatBroadcastStats(b)+"\n\nОтправить?")
+	reply.ReplyToMessageID = src.MessageID
+	reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("📣 Отправить (%d)", b.Total), callbackBroadcastStart+strconv.FormatInt(b.ID, 10)),
+		tgbotapi.NewInlineKeyboardButtonData("Отмена", callbackBroadcastCancel+strconv.FormatInt(b.ID, 10)),
+	))
+	if _, err := h.bot.Send(reply); err != nil {
+		slog.ErrorContext(ctx, "send message failed", "error", err)
+	}
+}
+
+// handleBroadcastsCommand показывает статистику последних рассылок.
+func (h *Handler) handleBroadcastsCommand(ctx context.Context, chatID int64) {
+	list, err := h.broadcaster.broadcasts.ListRecent(ctx, 5)
+	if err != nil {
+		slog.ErrorContext(ctx, "list broadcasts failed", "error", err)
+		h.send(ctx, chatID, "Не удалось получить рассылки. Попробуйте позже.")
+		return
+	}
+	if len(list) == 0 {
+		h.send(ctx, chatID, "Рассылок ещё не было.\n\n"+msgBroadcastUsage)
+		return
+	}
+	texts := make([]string, len(list))
+	for i, b := range list {
+		texts[i] = FormatBroadcastStats(b)
+	}
+	h.send(ctx, chatID, strings.Join(texts, "\n\n"))
+}
+
+// handleBroadcastCallback обрабатывает кнопки «Отправить» и «Отмена» под превью рассылки.
+func (h *Handler) handleBroadcastCallback(ctx context.Context, q *tgbotapi.CallbackQuery) {
+	chatID := q.Message.Chat.ID
+	var action, rawID string
+	switch {
+	case strings.HasPrefix(q.Data, callbackBroadcastStart):
+		action, rawID = callbackBroadcastStart, strings.TrimPrefix(q.Data, callbackBroadcastStart)
+	default:
+		action, rawID = callbackBroadcastCancel, strings.TrimPrefix(q.Data, callbackBroadcastCancel)
+	}
+	id, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil {
+		return
+	}
+	ctx = logger.WithAttrs(ctx, slog.Int64("broadcast_id", id))
+
+	// Кнопки больше не нужны: повторное нажатие не запустит рассылку второй раз
+	edit := tgbotapi.NewEditMessageReplyMarkup(chatID, q.Message.MessageID, tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})
+	if _, err := h.bot.Request(edit); err != nil {
+		slog.WarnContext(ctx, "remove broadcast buttons failed", "error", err)
+	}
+
+	if action == callbackBroadcastCancel {
+		if err := h.broadcaster.Cancel(ctx, id); err != nil {
+			h.send(ctx, chatID, broadcastErrorText(err))
+			return
+		}
+		h.send(ctx, chatID, fmt.Sprintf("Рассылка #%d отменена.", id))
+		return
+	}
+
+	b, err := h.broadcaster.Start(ctx, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "start broadcast failed", "error", err)
+		h.send(ctx, chatID, broadcastErrorText(err))
+		return
+	}
+	slog.InfoContext(ctx, "broadcast confirmed", "admin_telegram_id", q.From.ID)
+	h.send(ctx, chatID, fmt.Sprintf("📣 Рассылка #%d запущена: %d получателей. Остановить: /broadcast_cancel %d", b.ID, b.Total, b.ID))
+}
+
+// handleBroadcastCancelCommand останавливает рассылку по id.
+func (h *Handler) handleBroadcastCancelCommand(ctx context.Context, chatID int64, args string) {
+	id, err := strconv.ParseInt(strings.TrimSpace(args), 10, 64)
+	if err != nil {
+		h.send(ctx, chatID, "Использование: /broadcast_cancel <id>")
+		return
+	}
+	if err := h.broadcaster.Cancel(ctx, id); err != nil {
+		h.send(ctx, chatID, broadcastErrorText(err))
+		return
+	}
+	h.send(ctx, chatID, fmt.Sprintf("Рассылка #%d остановлена.", id))
+}
+
+func broadcastErrorText(err error) string {
+	switch {
+	case errors.Is(err, service.ErrBroadcastNotFound):
+		return "Рассылка не найдена."
+	case errors.Is(err, service.ErrBroadcastNotDraft):
+		return "Рассылка уже запущена, завершена или отменена."
+	}
+	return "Не удалось выполнить действие. Попробуйте позже."
+}
@@ -0,0 +1,123 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/service"
+)
+
+// DigestSchedule — когда отправлять сводки: ежедневно в Hour:Minute и раз в неделю в Weekday в то же время.
+type DigestSchedule struct {
+	Daily   bool
+	Weekly  bool
+	Hour    int
+	Minute  int
+	Weekday time.Weekday
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+// ParseDigestSchedule разбирает DIGEST_AT ("HH:MM", пусто — сводки отключены) и
+// DIGEST_WEEKLY_DAY ("monday".."sunday", пусто — без недельной сводки).
+func ParseDigestSchedule(at, weeklyDay string) (DigestSchedule, error) {
+	var s DigestSchedule
+	if at == "" {
+		return s, nil
+	}
+	t, err := time.Parse("15:04", at)
+	if err != nil {
+		return s, fmt.Errorf("DIGEST_AT: %q is not HH:MM", at)
+	}
+	s.Daily = true
+	s.Hour, s.Minute = t.Hour(), t.Minute()
+	if weeklyDay != "" {
+		wd, ok := weekdays[strings.ToLower(weeklyDay)]
+		if !ok {
+			return s, fmt.Errorf("DIGEST_WEEKLY_DAY: unknown weekday %q", weeklyDay)
+		}
+		s.Weekly = true
+		s.Weekday = wd
+	}
+	return s, nil
+}
+
+// DigestScheduler отправляет в админский чат дневную и недельную сводку.
+type DigestScheduler struct {
+	notifier *Notifier
+	stats    *service.StatsService
+	schedule DigestSchedule
+}
+
+func NewDigestScheduler(notifier *Notifier, stats *service.StatsService, schedule DigestSchedule) *DigestScheduler {
+	return &DigestScheduler{notifier: notifier, stats: stats, schedule: schedule}
+}
+
+// Run ждёт очередного времени отправки и шлёт сводки, пока не отменён ctx.
+func (s *DigestScheduler) Run(ctx context.Context) {
+	if !s.schedule.Daily {
+		return
+	}
+	loc := s.stats.Location()
+	for {
+		next := s.nextRun(time.Now().In(loc))
+		slog.DebugContext(ctx, "next digest scheduled", "at", next)
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		// Сводка за прошедшие полные сутки (и неделю) до начала текущего дня
+		today := time.Date(next.Year(), next.Month(), next.Day(), 0, 0, 0, 0, loc)
+		s.send(ctx, "📊 Сводка за день", today.AddDate(0, 0, -1), today)
+		if s.schedule.Weekly && next.Weekday() == s.schedule.Weekday {
+			s.send(ctx, "📈 Сводка за неделю", today.AddDate(0, 0, -7), today)
+		}
+	}
+}
+
+func (s *DigestScheduler) nextRun(now time.Time) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), s.schedule.Hour, s.schedule.Minute, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+func (s *DigestScheduler) send(ctx context.Context, title string, from, to time.Time) {
+	d, err := s.stats.Digest(ctx, from, to)
+	if err != nil {
+		slog.ErrorContext(ctx, "build digest failed", "error", err, "title", title)
+		return
+	}
+	s.notifier.Notify(ctx, FormatDigest(title, d))
+}
+
+// FormatDigest форматирует сводку для Telegram.
+func FormatDigest(title string, d *domain.Digest) string {
+	var b strings.Builder
+	last := d.To.AddDate(0, 0, -1)
+	if d.From.Equal(last) {
+		fmt.Fprintf(&b, "%s %s\n\n", title, d.From.Format("02.01.2006"))
+	} else {
+		fmt.Fprintf(&b, "%s %s — %s\n\n", title, d.From.Format("02.01.2006"), last.Format("02.01.2006"))
+	}
+	fmt.Fprintf(&b, "👤 Новые пользователи: %d\n", d.NewUsers)
+	fmt.Fprintf(&b, "🎰 Спины: %d\n", d.Spins)
+	for _, p := range d.SpinsByPrize {
+		fmt.Fprintf(&b, "   • %s — %d\n", p.Name, p.Count)
+	}
+	fmt.Fprintf(&b, "🎟 Погашено ваучеров: %d\n", d.Redemptions)
+	fmt.Fprintf(&b, "🚫 Отписались от бота: %d", d.Unsubscribes)
+	return b.String()
+}
@@ -0,0 +1,77 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/export"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const msgExportUsage = "Использование: /export [csv|xlsx] [с YYYY-MM-DD] [по YYYY-MM-DD] [кампания]\nНапример: /export xlsx 2026-01-01 2026-01-31 promo"
+
+// handleExport отправляет выгрузку пользователей и спинов файлом.
+// Аргументы в любом порядке: формат, до двух дат (с, по) и кампания.
+func (h *Handler) handleExport(ctx context.Context, chatID int64, args string) {
+	if h.exportSvc == nil {
+		h.send(ctx, chatID, "Выгрузка недоступна.")
+		return
+	}
+	format, filter, err := parseExportArgs(args, h.exportSvc.Location())
+	if err != nil {
+		h.send(ctx, chatID, err.Error()+"\n\n"+msgExportUsage)
+		return
+	}
+
+	var buf bytes.Buffer
+	n, err := h.exportSvc.Write(ctx, &buf, format, filter)
+	if err != nil {
+		slog.ErrorContext(ctx, "export failed", "error", err)
+		h.send(ctx, chatID, "Не удалось сформировать выгрузку. Попробуйте позже.")
+		return
+	}
+
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{
+		Name:  format.FileName(time.Now().In(h.exportSvc.Location())),
+		Bytes: buf.Bytes(),
+	})
+	doc.Caption = fmt.Sprintf("Выгрузка: %d строк", n)
+	if _, err := h.bot.Send(doc); err != nil {
+		slog.ErrorContext(ctx, "send export failed", "error", err)
+	}
+}
+
+func parseExportArgs(args string, loc *time.Location) (export.Format, domain.ExportFilter, error) {
+	format := export.FormatCSV
+	var filter domain.ExportFilter
+	var dates int
+	for _, arg := range strings.Fields(args) {
+		if f, err := export.ParseFormat(arg); err == nil {
+			format = f
+			continue
+		}
+		if _, err := time.Parse(time.DateOnly, arg); err == nil {
+			dates++
+			switch dates {
+			case 1:
+				filter.From, _ = domain.ParseDateBound(arg, false, loc)
+			case 2:
+				filter.To, _ = domain.ParseDateBound(arg, true, loc)
+			default:
+				return format, filter, fmt.Errorf("Слишком много дат: %s", arg)
+			}
+			continue
+		}
+		if filter.Campaign != "" {
+			return format, filter, fmt.Errorf("Непонятный аргумент: %s", arg)
+		}
+		filter.Campaign = arg
+	}
+	return format, filter, nil
+}
@@ -0,0 +1,81 @@
+package bot
+
+import (
+	"context"
+	"html"
+	"log/slog"
+	"sync"
+	"time"
+
+	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/notifier"
+	"era_sporta_bot_ruletka/internal/service"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// rejectedAlertInterval — не чаще стольких предупреждений об отклонённых спинах одного пользователя:
+// отклонённый пользователь обычно пробует снова.
+const rejectedAlertInterval = time.Hour
+
+// FraudAlertNotifier кладёт в очередь уведомлений предупреждение fraud_alert о подозрительном
+// или отклонённом спине; получатели выбираются маршрутизацией. Реализует notifier.FraudNotifier.
+type FraudAlertNotifier struct {
+	outbox    *service.OutboxService
+	templates *service.TemplateService
+
+	mu       sync.Mutex
+	rejected map[int64]time.Time
+}
+
+func NewFraudAlertNotifier(outbox *service.OutboxService, templates *service.TemplateService) *FraudAlertNotifier {
+	return &FraudAlertNotifier{outbox: outbox, templates: templates, rejected: make(map[int64]time.Time)}
+}
+
+func (n *FraudAlertNotifier) NotifyFraud(ctx context.Context, user *domain.User, spin *domain.SpinWithPrize, score int, reasons []string) {
+	now := time.Now()
+	if spin == nil && !n.allowRejected(user.ID, now) {
+		return
+	}
+	data := notifier.FraudAlertData{
+		Name:     html.EscapeString(userName(user)),
+		Phone:    html.EscapeString(user.Phone),
+		UserLink: userLinkHTML(user),
+		Score:    score,
+		Rejected: spin == nil,
+		Time:     now.In(n.templates.Location()).Format("02.01.2006 15:04"),
+	}
+	for _, r := range reasons {
+		data.Reasons = append(data.Reasons, html.EscapeString(r))
+	}
+	var prize *domain.Prize
+	if spin != nil {
+		prize = spin.Prize
+		data.Prize = html.EscapeString(spin.Prize.Name)
+	}
+	text, err := n.templates.Render(ctx, notifier.EventFraudAlert, data)
+	if err != nil {
+		slog.ErrorContext(ctx, "render fraud alert failed", "error", err)
+		return
+	}
+	m := domain.OutboxMessage{Text: text, ParseMode: tgbotapi.ModeHTML}
+	if err := n.outbox.EnqueueEvent(ctx, notifier.EventFraudAlert, prize, m); err != nil {
+		slog.ErrorContext(ctx, "enqueue fraud alert failed", "error", err)
+	}
+}
+
+// allowRejected сообщает, можно ли снова предупредить об отклонённом спине пользователя.
+func (n *FraudAlertNotifier) allowRejected(userID int64, now time.Time) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for id, at := range n.rejected {
+		if now.Sub(at) >= rejectedAlertInterval {
+			delete(n.rejected, id)
+		}
+	}
+	if _, ok := n.rejected[userID]; ok {
+		return false
+	}
+	n.rejected[userID] = now
+	return true
+}
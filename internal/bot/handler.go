@@ -3,17 +3,21 @@ package bot
 import (
 	"context"
 	"errors"
-	"fmt"
-	"log"
+	"html"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/logger"
+	"era_sporta_bot_ruletka/internal/metrics"
+	"era_sporta_bot_ruletka/internal/notifier"
 	"era_sporta_bot_ruletka/internal/service"
 	"era_sporta_bot_ruletka/internal/telegram"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
-	"github.com/jackc/pgx/v5"
 )
 
 const (
@@ -21,29 +25,59 @@ const (
 	msgShareOfficial = "Шаг 2 — номер телефона 📱\n\nНомер нужен, чтобы наш менеджер мог\nсвязаться с вами и подтвердить результат.\n\nМы используем только официальный способ Telegram\nи не передаём номер третьим лицам 🤝\n\nНажмите «Поделиться номером» ниже 👇"
 	msgPhoneSaved    = "✅ Отлично! Номер сохранён. Нажмите кнопку ниже, чтобы открыть приложение и крутить рулетку."
 	msgWelcomeBack   = "👋 С возвращением! Нажмите кнопку ниже, чтобы открыть приложение."
+	msgBlocked       = "⛔ Доступ к розыгрышу закрыт. Если это ошибка, обратитесь к администратору клуба."
 )
 
 type Handler struct {
-	bot        *tgbotapi.BotAPI
-	userSvc    *service.UserService
-	notifier   *Notifier
-	webAppURL  string
-	channelID  int64
-	channelURL string
+	bot         *tgbotapi.BotAPI
+	userSvc     *service.UserService
+	funnel      *service.FunnelService
+	webhooks    *service.WebhookService
+	exportSvc   *service.ExportService
+	templates   *service.TemplateService
+	moderation  *service.ModerationService
+	spinActions *SpinActions
+	broadcaster *Broadcaster
+	notifier    *Notifier
+	webAppURL   string
+	channelID   int64
+	channelURL  string
 }
 
-func NewHandler(bot *tgbotapi.BotAPI, userSvc *service.UserService, notifier *Notifier, webAppURL string, channelID int64, channelURL string) *Handler {
+func NewHandler(bot *tgbotapi.BotAPI, userSvc *service.UserService, funnel *service.FunnelService, webhooks *service.WebhookService, exportSvc *service.ExportService, templates *service.TemplateService, moderation *service.ModerationService, spinActions *SpinActions, broadcaster *Broadcaster, notifier *Notifier, webAppURL string, channelID int64, channelURL string) *Handler {
 	return &Handler{
-		bot:        bot,
-		userSvc:    userSvc,
-		notifier:   notifier,
-		webAppURL:  webAppURL,
-		channelID:  channelID,
-		channelURL: channelURL,
+		bot:         bot,
+		userSvc:     userSvc,
+		funnel:      funnel,
+		webhooks:    webhooks,
+		exportSvc:   exportSvc,
+		templates:   templates,
+		moderation:  moderation,
+		spinActions: spinActions,
+		broadcaster: broadcaster,
+		notifier:    notifier,
+		webAppURL:   webAppURL,
+		channelID:   channelID,
+		channelURL:  channelURL,
 	}
 }
 
 func (h *Handler) HandleUpdate(ctx context.Context, update tgbotapi.Update) {
+	defer func(start time.Time) {
+		metrics.BotUpdateDuration.WithLabelValues(updateType(update)).Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	ctx = logger.WithAttrs(ctx, slog.Int(logger.KeyUpdateID, update.UpdateID))
+	if from := update.SentFrom(); from != nil {
+		ctx = logger.WithAttrs(ctx, slog.Int64(logger.KeyTelegramUserID, from.ID))
+	}
+
+	// Пользователь заблокировал или разблокировал бота
+	if update.MyChatMember != nil {
+		h.handleMyChatMember(ctx, update.MyChatMember)
+		return
+	}
+
 	// Inline-кнопка «Поделиться номером»
 	if update.CallbackQuery != nil {
 		h.handleCallback(ctx, update.CallbackQuery)
@@ -58,10 +92,53 @@ func (h *Handler) HandleUpdate(ctx context.Context, update tgbotapi.Update) {
 
 	// /start
 	if msg.IsCommand() && msg.Command() == "start" {
+		h.funnel.Track(ctx, msg.From.ID, domain.FunnelEventStart, msg.CommandArguments())
 		h.handleStart(ctx, chatID, msg.From)
 		return
 	}
 
+	// Напоминания о сгорающих ваучерах: /reminders on|off
+	if msg.IsCommand() && msg.Command() == "reminders" && msg.Chat.IsPrivate() {
+		h.handleRemindersCommand(ctx, chatID, msg.From.ID, msg.CommandArguments())
+		return
+	}
+
+	// Команды менеджеров — только в админском чате
+	if msg.IsCommand() && h.notifier.IsAdminChat(chatID) {
+		switch msg.Command() {
+		case "export":
+			h.handleExport(ctx, chatID, msg.CommandArguments())
+			return
+		case "broadcast":
+			h.handleBroadcastCommand(ctx, msg)
+			return
+		case "broadcasts":
+			h.handleBroadcastsCommand(ctx, chatID)
+			return
+		case "broadcast_cancel":
+			h.handleBroadcastCancelCommand(ctx, chatID, msg.CommandArguments())
+			return
+		case "templates":
+			h.handleTemplatesCommand(ctx, chatID)
+			return
+		case "template":
+			h.handleTemplateCommand(ctx, chatID, msg.CommandArguments())
+			return
+		case "template_set":
+			h.handleTemplateSetCommand(ctx, chatID, msg.From.ID, msg.CommandArguments())
+			return
+		case "block":
+			h.handleBlockCommand(ctx, chatID, msg.From, msg.CommandArguments())
+			return
+		case "unblock":
+			h.handleUnblockCommand(ctx, chatID, msg.From, msg.CommandArguments())
+			return
+		case "blocked":
+			h.handleBlockedCommand(ctx, chatID)
+			return
+		}
+	}
+
 	// Контакт из Telegram (только так принимаем номер — подделать нельзя)
 	if msg.Contact != nil {
 		h.handleContact(ctx, chatID, msg.From, msg.Contact)
@@ -69,50 +146,114 @@ func (h *Handler) HandleUpdate(ctx context.Context, update tgbotapi.Update) {
 	}
 }
 
+// updateType возвращает короткую метку типа update для метрик.
+func updateType(update tgbotapi.Update) string {
+	switch {
+	case update.MyChatMember != nil:
+		return "my_chat_member"
+	case update.CallbackQuery != nil:
+		return "callback"
+	case update.Message == nil:
+		return "other"
+	case update.Message.IsCommand():
+		return "command"
+	case update.Message.Contact != nil:
+		return "contact"
+	default:
+		return "message"
+	}
+}
+
 func (h *Handler) handleStart(ctx context.Context, chatID int64, from *tgbotapi.User) {
 	user, err := h.userSvc.GetByTelegramID(ctx, from.ID)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
+		if errors.Is(err, service.ErrUserNotFound) {
 			// Новый пользователь — приветствие с inline-кнопкой
 			if h.channelURL == "" {
-				h.send(chatID, "Канал для подписки не настроен. Напишите администратору.")
+				h.send(ctx, chatID, "Канал для подписки не настроен. Напишите администратору.")
 				return
 			}
 			msg := tgbotapi.NewMessage(chatID, msgSubscribe)
 			msg.ReplyMarkup = SubscribeInlineMarkup(h.channelURL)
 			if _, sendErr := h.bot.Send(msg); sendErr != nil {
-				log.Printf("[bot] Send error: %v", sendErr)
+				slog.ErrorContext(ctx, "send message failed", "error", sendErr)
 			}
 			return
 		}
-		log.Printf("[bot] GetByTelegramID error: %v", err)
-		h.send(chatID, "Произошла ошибка. Попробуйте позже.")
+		slog.ErrorContext(ctx, "get user by telegram id failed", "error", err)
+		h.send(ctx, chatID, "Произошла ошибка. Попробуйте позже.")
 		return
 	}
 
+	if user != nil {
+		ctx = logger.WithAttrs(ctx, slog.Int64(logger.KeyUserID, user.ID))
+	}
+	if user != nil && user.IsBlocked() {
+		slog.InfoContext(ctx, "blocked user opened the bot")
+		h.send(ctx, chatID, msgBlocked)
+		return
+	}
 	if user != nil && user.Phone != "" {
 		// Already has phone — показываем кнопку открытия приложения
-		h.sendAppCard(chatID)
+		h.sendAppCard(ctx, chatID)
 		return
 	}
 
 	// Need phone — приветствие с inline-кнопкой
 	if h.channelURL == "" {
-		h.send(chatID, "Канал для подписки не настроен. Напишите администратору.")
+		h.send(ctx, chatID, "Канал для подписки не настроен. Напишите администратору.")
 		return
 	}
 	msg := tgbotapi.NewMessage(chatID, msgSubscribe)
 	msg.ReplyMarkup = SubscribeInlineMarkup(h.channelURL)
 	if _, err := h.bot.Send(msg); err != nil {
-		log.Printf("[bot] Send error: %v", err)
+		slog.ErrorContext(ctx, "send message failed", "error", err)
+	}
+}
+
+// handleMyChatMember фиксирует блокировку и разблокировку бота в личном чате (для сводок об отписках).
+func (h *Handler) handleMyChatMember(ctx context.Context, m *tgbotapi.ChatMemberUpdated) {
+	if m.Chat.Type != "private" {
+		return
+	}
+	switch {
+	case m.NewChatMember.WasKicked():
+		slog.InfoContext(ctx, "bot blocked by user")
+		h.funnel.Track(ctx, m.From.ID, domain.FunnelEventBotBlocked, "")
+		if err := h.userSvc.SetBotBlocked(ctx, m.From.ID, true); err != nil {
+			slog.WarnContext(ctx, "mark bot blocked failed", "error", err)
+		}
+	case m.OldChatMember.WasKicked() && m.NewChatMember.Status == "member":
+		slog.InfoContext(ctx, "bot unblocked by user")
+		h.funnel.Track(ctx, m.From.ID, domain.FunnelEventBotUnblocked, "")
+		if err := h.userSvc.SetBotBlocked(ctx, m.From.ID, false); err != nil {
+			slog.WarnContext(ctx, "mark bot unblocked failed", "error", err)
+		}
 	}
 }
 
 func (h *Handler) handleCallback(ctx context.Context, q *tgbotapi.CallbackQuery) {
+	if q.Message != nil && h.notifier.IsAdminChat(q.Message.Chat.ID) &&
+		(strings.HasPrefix(q.Data, callbackBroadcastStart) || strings.HasPrefix(q.Data, callbackBroadcastCancel)) {
+		h.handleBroadcastCallback(ctx, q)
+		if _, err := h.bot.Request(tgbotapi.NewCallback(q.ID, "")); err != nil {
+			slog.ErrorContext(ctx, "answer callback failed", "error", err)
+		}
+		return
+	}
+
+	if q.Message != nil && h.notifier.IsDestinationChat(q.Message.Chat.ID) && isSpinActionCallback(q.Data) {
+		answer := h.handleSpinActionCallback(ctx, q)
+		if _, err := h.bot.Request(tgbotapi.NewCallback(q.ID, answer)); err != nil {
+			slog.ErrorContext(ctx, "answer callback failed", "error", err)
+		}
+		return
+	}
+
 	switch q.Data {
 	case "check_subscribe":
 		if h.channelID == 0 || h.channelURL == "" {
-			h.send(q.Message.Chat.ID, "Канал для подписки не настроен. Напишите администратору.")
+			h.send(ctx, q.Message.Chat.ID, "Канал для подписки не настроен. Напишите администратору.")
 			break
 		}
 		member, err := telegram.IsUserMember(ctx, h.bot.Token, h.channelID, q.From.ID)
@@ -120,14 +261,15 @@ func (h *Handler) handleCallback(ctx context.Context, q *tgbotapi.CallbackQuery)
 			msg := tgbotapi.NewMessage(q.Message.Chat.ID, "Похоже, вы ещё не подписались. Подпишитесь и нажмите «Я подписался» ещё раз.")
 			msg.ReplyMarkup = SubscribeInlineMarkup(h.channelURL)
 			if _, sendErr := h.bot.Send(msg); sendErr != nil {
-				log.Printf("[bot] Send error: %v", sendErr)
+				slog.ErrorContext(ctx, "send message failed", "error", sendErr)
 			}
 			break
 		}
+		h.funnel.Track(ctx, q.From.ID, domain.FunnelEventSubscribed, "")
 		msg := tgbotapi.NewMessage(q.Message.Chat.ID, msgShareOfficial)
 		msg.ReplyMarkup = SharePhoneKeyboard()
 		if _, err := h.bot.Send(msg); err != nil {
-			log.Printf("[bot] Send error: %v", err)
+			slog.ErrorContext(ctx, "send message failed", "error", err)
 		}
 	case "share_phone":
 		if h.channelID != 0 {
@@ -137,10 +279,10 @@ func (h *Handler) handleCallback(ctx context.Context, q *tgbotapi.CallbackQuery)
 					msg := tgbotapi.NewMessage(q.Message.Chat.ID, msgSubscribe)
 					msg.ReplyMarkup = SubscribeInlineMarkup(h.channelURL)
 					if _, sendErr := h.bot.Send(msg); sendErr != nil {
-						log.Printf("[bot] Send error: %v", sendErr)
+						slog.ErrorContext(ctx, "send message failed", "error", sendErr)
 					}
 				} else {
-					h.send(q.Message.Chat.ID, "Канал для подписки не настроен. Напишите администратору.")
+					h.send(ctx, q.Message.Chat.ID, "Канал для подписки не настроен. Напишите администратору.")
 				}
 				break
 			}
@@ -149,24 +291,51 @@ func (h *Handler) handleCallback(ctx context.Context, q *tgbotapi.CallbackQuery)
 		msg := tgbotapi.NewMessage(q.Message.Chat.ID, msgShareOfficial)
 		msg.ReplyMarkup = SharePhoneKeyboard()
 		if _, err := h.bot.Send(msg); err != nil {
-			log.Printf("[bot] Send error: %v", err)
+			slog.ErrorContext(ctx, "send message failed", "error", err)
 		}
+	case callbackRemindersOff:
+		h.setRemindersOptOut(ctx, q.Message.Chat.ID, q.From.ID, true)
 	}
 	if _, err := h.bot.Request(tgbotapi.NewCallback(q.ID, "")); err != nil {
-		log.Printf("[bot] Answer callback error: %v", err)
+		slog.ErrorContext(ctx, "answer callback failed", "error", err)
 	}
 }
 
+func (h *Handler) handleRemindersCommand(ctx context.Context, chatID, telegramUserID int64, args string) {
+	switch strings.ToLower(strings.TrimSpace(args)) {
+	case "off":
+		h.setRemindersOptOut(ctx, chatID, telegramUserID, true)
+	case "on":
+		h.setRemindersOptOut(ctx, chatID, telegramUserID, false)
+	default:
+		h.send(ctx, chatID, "Напоминания о сгорающих призах: /reminders on — включить, /reminders off — отключить.")
+	}
+}
+
+func (h *Handler) setRemindersOptOut(ctx context.Context, chatID, telegramUserID int64, optOut bool) {
+	if err := h.userSvc.SetRemindersOptOut(ctx, telegramUserID, optOut); err != nil {
+		slog.ErrorContext(ctx, "set reminders opt-out failed", "error", err)
+		h.send(ctx, chatID, "Произошла ошибка. Попробуйте позже.")
+		return
+	}
+	slog.InfoContext(ctx, "reminders opt-out changed", "opt_out", optOut)
+	if optOut {
+		h.send(ctx, chatID, "🔕 Напоминания о сгорающих призах отключены. Включить снова: /reminders on")
+		return
+	}
+	h.send(ctx, chatID, "🔔 Напоминания о сгорающих призах включены.")
+}
+
 func (h *Handler) handleContact(ctx context.Context, chatID int64, from *tgbotapi.User, contact *tgbotapi.Contact) {
 	// Принимаем только контакт от самого пользователя (номер из аккаунта Telegram, подделать нельзя)
 	if contact.UserID != 0 && contact.UserID != from.ID {
-		h.send(chatID, "Пожалуйста, нажмите «Поделиться контактом» и отправьте именно свой номер из Telegram.")
+		h.send(ctx, chatID, "Пожалуйста, нажмите «Поделиться контактом» и отправьте именно свой номер из Telegram.")
 		return
 	}
 
 	phone := normalizePhone(contact.PhoneNumber)
 	if phone == "" {
-		h.send(chatID, "Не удалось распознать номер. Попробуйте ещё раз.")
+		h.send(ctx, chatID, "Не удалось распознать номер. Попробуйте ещё раз.")
 		return
 	}
 
@@ -178,30 +347,57 @@ func (h *Handler) handleContact(ctx context.Context, chatID int64, from *tgbotap
 		Username:       from.UserName,
 	}
 
-	if err := h.userSvc.Upsert(ctx, user); err != nil {
-		log.Printf("[bot] Upsert user error: %v", err)
-		h.send(chatID, "Не удалось сохранить номер. Попробуйте позже.")
+	registered, err := h.userSvc.Upsert(ctx, user)
+	if err != nil {
+		slog.ErrorContext(ctx, "upsert user failed", "error", err)
+		h.send(ctx, chatID, "Не удалось сохранить номер. Попробуйте позже.")
+		return
+	}
+	ctx = logger.WithAttrs(ctx, slog.Int64(logger.KeyUserID, user.ID))
+	slog.InfoContext(ctx, "phone saved", "registered", registered)
+
+	// Номер забанен или пользователь заблокирован раньше — ни приложения, ни уведомлений
+	if user.IsBlocked() {
+		slog.InfoContext(ctx, "blocked user shared phone", "reason", user.BlockReason)
+		blockedMsg := tgbotapi.NewMessage(chatID, msgBlocked)
+		blockedMsg.ReplyMarkup = RemoveKeyboard()
+		if _, err := h.bot.Send(blockedMsg); err != nil {
+			slog.ErrorContext(ctx, "send message failed", "error", err)
+		}
 		return
 	}
 
-	// Уведомление в админский чат о новом пользователе
-	h.notifyNewUser(ctx, phone, from)
+	// Уведомление в админский чат о новом пользователе и лид в CRM — только при регистрации,
+	// повторная отправка того же контакта их не дублирует
+	if registered {
+		h.notifyNewUser(ctx, phone, from)
+		h.webhooks.UserRegistered(ctx, user)
+	}
 
 	// Remove reply keyboard first
 	rmMsg := tgbotapi.NewMessage(chatID, msgPhoneSaved)
 	rmMsg.ReplyMarkup = RemoveKeyboard()
 	if _, err := h.bot.Send(rmMsg); err != nil {
-		log.Printf("[bot] Send error: %v", err)
+		slog.ErrorContext(ctx, "send message failed", "error", err)
 		return
 	}
 	// Then show Open App button
-	h.sendAppCard(chatID)
+	h.sendAppCard(ctx, chatID)
+}
+
+func (h *Handler) send(ctx context.Context, chatID int64, text string) {
+	msg := tgbotapi.NewMessage(chatID, text)
+	if _, err := h.bot.Send(msg); err != nil {
+		slog.ErrorContext(ctx, "send message failed", "error", err)
+	}
 }
 
-func (h *Handler) send(chatID int64, text string) {
+func (h *Handler) sendHTML(ctx context.Context, chatID int64, text string) {
 	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.DisableWebPagePreview = true
 	if _, err := h.bot.Send(msg); err != nil {
-		log.Printf("[bot] Send error: %v", err)
+		slog.ErrorContext(ctx, "send message failed", "error", err)
 	}
 }
 
@@ -224,14 +420,14 @@ func getPromoImagePath() string {
 	return ""
 }
 
-func (h *Handler) sendAppCard(chatID int64) {
+func (h *Handler) sendAppCard(ctx context.Context, chatID int64) {
 	imgPath := getPromoImagePath()
 	if imgPath != "" {
 		photo := tgbotapi.NewPhoto(chatID, tgbotapi.FilePath(imgPath))
 		photo.Caption = msgWelcomeBack
 		photo.ReplyMarkup = OpenAppKeyboard(h.webAppURL)
 		if _, err := h.bot.Send(photo); err != nil {
-			log.Printf("[bot] Send photo error: %v", err)
+			slog.ErrorContext(ctx, "send photo failed", "error", err)
 		} else {
 			return
 		}
@@ -240,7 +436,7 @@ func (h *Handler) sendAppCard(chatID int64) {
 	msg := tgbotapi.NewMessage(chatID, msgWelcomeBack)
 	msg.ReplyMarkup = OpenAppKeyboard(h.webAppURL)
 	if _, err := h.bot.Send(msg); err != nil {
-		log.Printf("[bot] Send error: %v", err)
+		slog.ErrorContext(ctx, "send message failed", "error", err)
 	}
 }
 
@@ -255,7 +451,7 @@ func normalizePhone(phone string) string {
 	return string(b)
 }
 
-// notifyNewUser отправляет в админский чат сообщение о пользователе, который поделился номером.
+// notifyNewUser отправляет в админский чат сообщение о пользователе, который поделился номером (шаблон new_user).
 func (h *Handler) notifyNewUser(ctx context.Context, phone string, from *tgbotapi.User) {
 	if h.notifier == nil {
 		return
@@ -270,15 +466,18 @@ func (h *Handler) notifyNewUser(ctx context.Context, phone string, from *tgbotap
 	if name == "" {
 		name = "—"
 	}
-	text := fmt.Sprintf("Новый пользователь:\nНомер - %s\nИмя - %s\nId - %d", phone, name, from.ID)
-	h.notifier.Notify(ctx, text)
-}
-
-// NotifyAdmin sends spin notification to admin
-func (h *Handler) NotifyAdmin(ctx context.Context, user *domain.User, prizeName string) {
-	if h.notifier != nil {
-		text := fmt.Sprintf("🎰 Новый спин!\nПользователь: %s %s (@%s)\nТелефон: %s\nПриз: %s",
-			user.FirstName, user.LastName, user.Username, user.Phone, prizeName)
-		h.notifier.NotifyWithTime(ctx, text)
+	user := &domain.User{TelegramUserID: from.ID, FirstName: from.FirstName, LastName: from.LastName, Username: from.UserName}
+	text, err := h.templates.Render(ctx, notifier.EventNewUser, notifier.NewUserData{
+		Name:       html.EscapeString(name),
+		Username:   html.EscapeString(from.UserName),
+		Phone:      html.EscapeString(phone),
+		TelegramID: from.ID,
+		UserLink:   userLinkHTML(user),
+		Time:       time.Now().In(h.templates.Location()).Format("02.01.2006 15:04"),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "render new user notification failed", "error", err)
+		return
 	}
+	h.notifier.NotifyEvent(ctx, notifier.EventNewUser, nil, text, tgbotapi.ModeHTML)
 }
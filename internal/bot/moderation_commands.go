@@ -0,0 +1,166 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"era_sporta_bot_ruletka/internal/service"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxBlockedLines — сколько блокировок и банов показывать в /blocked.
+const maxBlockedLines = 30
+
+const msgBlockUsage = "Блокировка пользователей:\n" +
+	"/block <telegram id> [причина] — заблокировать пользователя и забанить его номер\n" +
+	"/block <+номер> [причина] — забанить номер (в том числе для будущих аккаунтов)\n" +
+	"/unblock <telegram id | +номер> — снять блокировку\n" +
+	"/blocked — список блокировок"
+
+// moderationTarget — кого блокируют: пользователя по Telegram ID или номер (начинается с +).
+type moderationTarget struct {
+	telegramID int64
+	phone      string
+}
+
+// parseModerationArgs разбирает «<telegram id | +номер> [причина]».
+func parseModerationArgs(args string) (moderationTarget, string, bool) {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return moderationTarget{}, "", false
+	}
+	reason := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(args), fields[0]))
+	if strings.HasPrefix(fields[0], "+") {
+		return moderationTarget{phone: fields[0]}, reason, true
+	}
+	id, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil || id <= 0 {
+		return moderationTarget{}, "", false
+	}
+	return moderationTarget{telegramID: id}, reason, true
+}
+
+// handleBlockCommand блокирует пользователя или банит номер.
+func (h *Handler) handleBlockCommand(ctx context.Context, chatID int64, from *tgbotapi.User, args string) {
+	target, reason, ok := parseModerationArgs(args)
+	if !ok {
+		h.send(ctx, chatID, msgBlockUsage)
+		return
+	}
+	actor := adminName(from)
+
+	if target.phone != "" {
+		key, blocked, err := h.moderation.BanPhone(ctx, target.phone, reason, actor)
+		switch {
+		case errors.Is(err, service.ErrInvalidPhone):
+			h.send(ctx, chatID, "Не удалось распознать номер: "+target.phone)
+		case err != nil:
+			slog.ErrorContext(ctx, "ban phone failed", "error", err)
+			h.send(ctx, chatID, "Не удалось забанить номер. Попробуйте позже.")
+		default:
+			h.send(ctx, chatID, fmt.Sprintf("⛔ Номер +%s забанен, заблокировано аккаунтов: %d.", key, blocked))
+		}
+		return
+	}
+
+	user, err := h.moderation.BlockUser(ctx, target.telegramID, reason, actor)
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		h.send(ctx, chatID, fmt.Sprintf("Пользователь %d не найден.", target.telegramID))
+	case err != nil:
+		slog.ErrorContext(ctx, "block user failed", "error", err)
+		h.send(ctx, chatID, "Не удалось заблокировать пользователя. Попробуйте позже.")
+	default:
+		text := fmt.Sprintf("⛔ Пользователь %s (%d) заблокирован.", userName(user), user.TelegramUserID)
+		if user.Phone != "" {
+			text += " Номер " + user.Phone + " забанен."
+		}
+		h.send(ctx, chatID, text)
+	}
+}
+
+// handleUnblockCommand снимает блокировку с пользователя или бан с номера.
+func (h *Handler) handleUnblockCommand(ctx context.Context, chatID int64, from *tgbotapi.User, args string) {
+	target, _, ok := parseModerationArgs(args)
+	if !ok {
+		h.send(ctx, chatID, msgBlockUsage)
+		return
+	}
+	actor := adminName(from)
+
+	if target.phone != "" {
+		found, err := h.moderation.UnbanPhone(ctx, target.phone, actor)
+		switch {
+		case errors.Is(err, service.ErrInvalidPhone):
+			h.send(ctx, chatID, "Не удалось распознать номер: "+target.phone)
+		case err != nil:
+			slog.ErrorContext(ctx, "unban phone failed", "error", err)
+			h.send(ctx, chatID, "Не удалось снять бан. Попробуйте позже.")
+		case !found:
+			h.send(ctx, chatID, "Номер "+target.phone+" не забанен.")
+		default:
+			h.send(ctx, chatID, "✅ Бан с номера "+target.phone+" снят.")
+		}
+		return
+	}
+
+	user, err := h.moderation.UnblockUser(ctx, target.telegramID, actor)
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		h.send(ctx, chatID, fmt.Sprintf("Пользователь %d не найден.", target.telegramID))
+	case err != nil:
+		slog.ErrorContext(ctx, "unblock user failed", "error", err)
+		h.send(ctx, chatID, "Не удалось снять блокировку. Попробуйте позже.")
+	default:
+		h.send(ctx, chatID, fmt.Sprintf("✅ Пользователь %s (%d) разблокирован.", userName(user), user.TelegramUserID))
+	}
+}
+
+// handleBlockedCommand показывает заблокированных пользователей и забаненные номера.
+func (h *Handler) handleBlockedCommand(ctx context.Context, chatID int64) {
+	users, bans, err := h.moderation.ListBlocked(ctx, maxBlockedLines)
+	if err != nil {
+		slog.ErrorContext(ctx, "list blocked failed", "error", err)
+		h.send(ctx, chatID, "Не удалось получить список блокировок. Попробуйте позже.")
+		return
+	}
+	if len(users) == 0 && len(bans) == 0 {
+		h.send(ctx, chatID, "Заблокированных пользователей и номеров нет.\n\n"+msgBlockUsage)
+		return
+	}
+	loc := h.templates.Location()
+	var b strings.Builder
+	if len(users) > 0 {
+		b.WriteString("⛔ Заблокированные пользователи:\n")
+		for _, u := range users {
+			fmt.Fprintf(&b, "• %s (%d) %s — %s", userName(u), u.TelegramUserID, u.Phone, u.BlockedAt.In(loc).Format("02.01.2006 15:04"))
+			writeBlockDetails(&b, u.BlockReason, u.BlockedBy)
+		}
+	}
+	if len(bans) > 0 {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("📵 Забаненные номера:\n")
+		for _, ban := range bans {
+			fmt.Fprintf(&b, "• +%s — %s", ban.Phone, ban.CreatedAt.In(loc).Format("02.01.2006 15:04"))
+			writeBlockDetails(&b, ban.Reason, ban.BannedBy)
+		}
+	}
+	h.send(ctx, chatID, strings.TrimRight(b.String(), "\n"))
+}
+
+func writeBlockDetails(b *strings.Builder, reason, actor string) {
+	if actor != "" {
+		b.WriteString(", " + actor)
+	}
+	if reason != "" {
+		b.WriteString(": " + reason)
+	}
+	b.WriteString("\n")
+}
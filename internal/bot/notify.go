@@ -1,70 +1,200 @@
 package bot
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
-	"strconv"
-	"strings"
+	"log/slog"
+	"net/http"
+	"sync"
 	"time"
 
+	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/metrics"
+	"era_sporta_bot_ruletka/internal/notifier"
+
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
+const webhookTimeout = 10 * time.Second
+
+// Notifier доставляет уведомления получателям из маршрутизации (notifier.Router):
+// в чаты Telegram (в том числе в темы форума) и на внешние webhook.
 type Notifier struct {
 	bot    *tgbotapi.BotAPI
-	chatID int64
+	router *notifier.Router
+	client *http.Client
+
+	mu sync.Mutex
+	// migrated — новые id групп, ставших супергруппами (Telegram сообщает их в migrate_to_chat_id)
+	migrated map[int64]int64
 }
 
-func NewNotifier(bot *tgbotapi.BotAPI, adminChatID int64) *Notifier {
-	return &Notifier{bot: bot, chatID: adminChatID}
+func NewNotifier(bot *tgbotapi.BotAPI, router *notifier.Router) *Notifier {
+	return &Notifier{
+		bot:      bot,
+		router:   router,
+		client:   &http.Client{Timeout: webhookTimeout},
+		migrated: make(map[int64]int64),
+	}
 }
 
 func (n *Notifier) Notify(ctx context.Context, text string) {
-	if n.chatID == 0 {
-		return
+	_ = n.Send(ctx, text)
+}
+
+// Send отправляет сообщение получателю по умолчанию и возвращает ошибку.
+// Ошибка уже залогирована; если получатель не настроен, сообщение пропускается.
+func (n *Notifier) Send(ctx context.Context, text string) error {
+	return n.SendMessage(ctx, text, "", nil)
+}
+
+// SendMessage как Send, но с parse mode и клавиатурой (markup может быть nil).
+func (n *Notifier) SendMessage(ctx context.Context, text, parseMode string, markup any) error {
+	return n.SendTo(ctx, notifier.DefaultDestination, "", text, parseMode, markup)
+}
+
+// NotifyEvent отправляет уведомление события всем получателям по правилам маршрутизации.
+func (n *Notifier) NotifyEvent(ctx context.Context, e notifier.Event, prize *domain.Prize, text, parseMode string) {
+	for _, name := range n.router.Resolve(e, prize) {
+		_ = n.SendTo(ctx, name, e, text, parseMode, nil)
 	}
+}
 
-	candidates := candidateChatIDs(n.chatID)
-	for idx, chatID := range candidates {
-		msg := tgbotapi.NewMessage(chatID, text)
-		if _, err := n.bot.Send(msg); err != nil {
-			if idx == len(candidates)-1 || !isRetryableChatIDError(err) {
-				log.Printf("[notify] Send to admin error (chat_id=%d): %v", chatID, err)
-				return
-			}
-			log.Printf("[notify] Retrying notification with fallback chat_id after error (chat_id=%d): %v", chatID, err)
-			continue
-		}
-		if chatID != n.chatID {
-			log.Printf("[notify] Delivered via fallback chat_id=%d (configured=%d)", chatID, n.chatID)
+// SendTo отправляет сообщение получателю name. Кнопки (markup) в webhook не передаются.
+func (n *Notifier) SendTo(ctx context.Context, name string, e notifier.Event, text, parseMode string, markup any) error {
+	dest, ok := n.router.Destination(name)
+	if !ok {
+		if name != notifier.DefaultDestination {
+			slog.WarnContext(ctx, "unknown notification destination", "destination", name)
 		}
-		return
+		return nil
+	}
+	var err error
+	if dest.IsWebhook() {
+		err = n.sendWebhook(ctx, dest, e, text, parseMode)
+	} else {
+		err = n.sendTelegram(ctx, dest, text, parseMode, markup)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "send admin notification failed", "destination", name, "error", err)
+		metrics.ObserveNotifierDelivery(false)
+		return err
 	}
+	metrics.ObserveNotifierDelivery(true)
+	return nil
 }
 
-func (n *Notifier) NotifyWithTime(ctx context.Context, text string) {
-	n.Notify(ctx, text+"\nВремя: "+time.Now().Format("02.01.2006 15:04"))
+// IsAdminChat сообщает, что chatID — чат получателя по умолчанию (там доступны команды менеджеров).
+func (n *Notifier) IsAdminChat(chatID int64) bool {
+	if n == nil {
+		return false
+	}
+	dest, ok := n.router.Destination(notifier.DefaultDestination)
+	return ok && dest.ChatID != 0 && n.sameChat(dest.ChatID, chatID)
 }
 
-func candidateChatIDs(chatID int64) []int64 {
-	ids := []int64{chatID}
-	// Some Telegram groups are returned in supergroup format (-100XXXXXXXXXX).
-	if chatID < 0 && chatID > -1000000000000 {
-		absID := -chatID
-		if supergroupID, err := strconv.ParseInt(fmt.Sprintf("-100%d", absID), 10, 64); err == nil {
-			ids = append(ids, supergroupID)
+// IsDestinationChat сообщает, что chatID — чат любого из получателей уведомлений
+// (там работают кнопки под уведомлениями о спинах).
+func (n *Notifier) IsDestinationChat(chatID int64) bool {
+	if n == nil {
+		return false
+	}
+	for _, id := range n.router.TelegramChats() {
+		if n.sameChat(id, chatID) {
+			return true
 		}
 	}
-	return ids
+	return false
 }
 
-func isRetryableChatIDError(err error) bool {
-	if err == nil {
-		return false
+func (n *Notifier) sameChat(configured, chatID int64) bool {
+	return configured == chatID || n.resolveChat(configured) == chatID
+}
+
+func (n *Notifier) resolveChat(chatID int64) int64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if id, ok := n.migrated[chatID]; ok {
+		return id
+	}
+	return chatID
+}
+
+func (n *Notifier) sendTelegram(ctx context.Context, dest notifier.Destination, text, parseMode string, markup any) error {
+	chatID := n.resolveChat(dest.ChatID)
+	err := n.sendChat(chatID, dest.ThreadID, text, parseMode, markup)
+	// Группа стала супергруппой: Telegram сообщает новый id, повторяем и запоминаем его
+	var apiErr *tgbotapi.Error
+	if errors.As(err, &apiErr) && apiErr.MigrateToChatID != 0 {
+		slog.WarnContext(ctx, "admin chat migrated to supergroup, update the chat_id in settings",
+			"destination", dest.Name, "chat_id", chatID, "new_chat_id", apiErr.MigrateToChatID)
+		n.mu.Lock()
+		n.migrated[dest.ChatID] = apiErr.MigrateToChatID
+		n.mu.Unlock()
+		err = n.sendChat(apiErr.MigrateToChatID, dest.ThreadID, text, parseMode, markup)
+	}
+	return err
+}
+
+// sendChat отправляет сообщение в чат; threadID — тема форума (message_thread_id),
+// которую клиентская библиотека не поддерживает, поэтому такой запрос собирается вручную.
+func (n *Notifier) sendChat(chatID int64, threadID int, text, parseMode string, markup any) error {
+	if threadID == 0 {
+		msg := tgbotapi.NewMessage(chatID, text)
+		msg.ParseMode = parseMode
+		msg.ReplyMarkup = markup
+		msg.DisableWebPagePreview = true
+		_, err := n.bot.Send(msg)
+		return err
+	}
+	params := tgbotapi.Params{}
+	params.AddNonZero64("chat_id", chatID)
+	params.AddNonZero("message_thread_id", threadID)
+	params["text"] = text
+	params.AddNonEmpty("parse_mode", parseMode)
+	params.AddBool("disable_web_page_preview", true)
+	if err := params.AddInterface("reply_markup", markup); err != nil {
+		return err
+	}
+	_, err := n.bot.MakeRequest("sendMessage", params)
+	return err
+}
+
+// webhookPayload — тело POST на webhook получателя.
+type webhookPayload struct {
+	Event       string    `json:"event,omitempty"`
+	Destination string    `json:"destination"`
+	Text        string    `json:"text"`
+	ParseMode   string    `json:"parse_mode,omitempty"`
+	SentAt      time.Time `json:"sent_at"`
+}
+
+func (n *Notifier) sendWebhook(ctx context.Context, dest notifier.Destination, e notifier.Event, text, parseMode string) error {
+	body, err := json.Marshal(webhookPayload{
+		Event:       string(e),
+		Destination: dest.Name,
+		Text:        text,
+		ParseMode:   parseMode,
+		SentAt:      time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dest.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s responded with status %d", dest.Name, resp.StatusCode)
 	}
-	e := strings.ToLower(err.Error())
-	return strings.Contains(e, "chat not found") ||
-		strings.Contains(e, "group chat was upgraded to a supergroup chat") ||
-		strings.Contains(e, "chat was upgraded to a supergroup")
+	return nil
 }
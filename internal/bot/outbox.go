@@ -0,0 +1,155 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/metrics"
+	"era_sporta_bot_ruletka/internal/notifier"
+	"era_sporta_bot_ruletka/internal/service"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	outboxPollInterval    = 2 * time.Second
+	outboxBatchSize       = 50
+	outboxCleanupInterval = time.Hour
+	// outboxSendInterval — пауза между сообщениями пачки: держит отправку ниже лимита Telegram на бота.
+	outboxSendInterval = 50 * time.Millisecond
+)
+
+// OutboxDispatcher доставляет сообщения из очереди уведомлений: в админский чат через Notifier,
+// пользователям — в личный чат. Неудачные попытки повторяются с задержкой.
+type OutboxDispatcher struct {
+	bot      *tgbotapi.BotAPI
+	outbox   *service.OutboxService
+	notifier *Notifier
+	userSvc  *service.UserService
+	funnel   *service.FunnelService
+}
+
+func NewOutboxDispatcher(bot *tgbotapi.BotAPI, outbox *service.OutboxService, notifier *Notifier, userSvc *service.UserService, funnel *service.FunnelService) *OutboxDispatcher {
+	return &OutboxDispatcher{bot: bot, outbox: outbox, notifier: notifier, userSvc: userSvc, funnel: funnel}
+}
+
+// Run опрашивает очередь, пока не отменён ctx.
+func (d *OutboxDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+	var lastCleanup time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		d.dispatch(ctx)
+		if time.Since(lastCleanup) >= outboxCleanupInterval {
+			lastCleanup = time.Now()
+			if n, err := d.outbox.Cleanup(ctx); err != nil {
+				slog.WarnContext(ctx, "outbox cleanup failed", "error", err)
+			} else if n > 0 {
+				slog.DebugContext(ctx, "outbox cleaned up", "deleted", n)
+			}
+		}
+	}
+}
+
+func (d *OutboxDispatcher) dispatch(ctx context.Context) {
+	messages, err := d.outbox.Claim(ctx, outboxBatchSize)
+	if err != nil {
+		slog.ErrorContext(ctx, "claim outbox messages failed", "error", err)
+		return
+	}
+	for i, m := range messages {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				// Незабранные сообщения вернутся в очередь по истечении аренды
+				return
+			case <-time.After(outboxSendInterval):
+			}
+		}
+		d.deliver(ctx, m)
+	}
+}
+
+func (d *OutboxDispatcher) deliver(ctx context.Context, m domain.OutboxMessage) {
+	ctx = context.WithoutCancel(ctx)
+	var markup any
+	if len(m.ReplyMarkup) > 0 {
+		var kb tgbotapi.InlineKeyboardMarkup
+		if err := json.Unmarshal(m.ReplyMarkup, &kb); err != nil {
+			d.fail(ctx, m, fmt.Errorf("decode reply markup: %w", err), deliveryFailed)
+			return
+		}
+		markup = kb
+	}
+	var err error
+	switch m.Kind {
+	case domain.OutboxAdmin:
+		dest := m.Destination
+		if dest == "" {
+			dest = notifier.DefaultDestination
+		}
+		err = d.notifier.SendTo(ctx, dest, notifier.Event(m.Event), m.Text, m.ParseMode, markup)
+	default:
+		msg := tgbotapi.NewMessage(m.ChatID, m.Text)
+		msg.ParseMode = m.ParseMode
+		msg.ReplyMarkup = markup
+		_, err = d.bot.Send(msg)
+	}
+	if err == nil {
+		if err := d.outbox.MarkSent(ctx, m.ID); err != nil {
+			slog.ErrorContext(ctx, "mark outbox message sent failed", "error", err, "outbox_id", m.ID)
+		}
+		metrics.OutboxDeliveries.WithLabelValues(string(m.Kind), deliverySent).Inc()
+		return
+	}
+
+	var retryAfter time.Duration
+	var apiErr *tgbotapi.Error
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.Code == 403 && m.Kind == domain.OutboxUser:
+			// Пользователь заблокировал бота — повторять бессмысленно
+			if err := d.userSvc.SetBotBlocked(ctx, m.ChatID, true); err != nil {
+				slog.WarnContext(ctx, "mark bot blocked failed", "error", err, "telegram_user_id", m.ChatID)
+			}
+			d.funnel.Track(ctx, m.ChatID, domain.FunnelEventBotBlocked, "")
+			d.fail(ctx, m, err, deliveryBlocked)
+			return
+		case apiErr.Code == 400:
+			d.fail(ctx, m, err, deliveryFailed)
+			return
+		case apiErr.Code == 429:
+			retryAfter = time.Duration(apiErr.RetryAfter) * time.Second
+		}
+	}
+
+	retried, rerr := d.outbox.Retry(ctx, m, retryAfter, err)
+	if rerr != nil {
+		slog.ErrorContext(ctx, "reschedule outbox message failed", "error", rerr, "outbox_id", m.ID)
+	}
+	if retried {
+		slog.WarnContext(ctx, "outbox delivery failed, will retry", "error", err, "outbox_id", m.ID, "attempts", m.Attempts)
+		metrics.OutboxDeliveries.WithLabelValues(string(m.Kind), "retry").Inc()
+		return
+	}
+	slog.ErrorContext(ctx, "outbox delivery failed permanently", "error", err, "outbox_id", m.ID, "attempts", m.Attempts)
+	metrics.OutboxDeliveries.WithLabelValues(string(m.Kind), deliveryFailed).Inc()
+}
+
+func (d *OutboxDispatcher) fail(ctx context.Context, m domain.OutboxMessage, cause error, result string) {
+	if err := d.outbox.Fail(ctx, m.ID, cause); err != nil {
+		slog.ErrorContext(ctx, "mark outbox message failed", "error", err, "outbox_id", m.ID)
+	}
+	slog.WarnContext(ctx, "outbox message dropped", "error", cause, "outbox_id", m.ID, "kind", string(m.Kind))
+	metrics.OutboxDeliveries.WithLabelValues(string(m.Kind), result).Inc()
+}
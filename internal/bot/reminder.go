@@ -0,0 +1,144 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/metrics"
+	"era_sporta_bot_ruletka/internal/service"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	callbackRemindersOff = "reminders_off"
+
+	// reminderSendInterval держит отправку напоминаний ниже лимита Telegram на бота.
+	reminderSendInterval = 50 * time.Millisecond
+)
+
+// ParseReminderDays разбирает REMINDER_DAYS_BEFORE — пороги в днях через запятую ("3,1").
+// Пустая строка отключает напоминания.
+func ParseReminderDays(v string) ([]int, error) {
+	var days []int
+	for _, part := range strings.Split(v, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		d, err := strconv.Atoi(part)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("REMINDER_DAYS_BEFORE: %q is not a positive number of days", part)
+		}
+		days = append(days, d)
+	}
+	return days, nil
+}
+
+// ReminderScheduler периодически напоминает пользователям о ваучерах, которые скоро сгорят.
+type ReminderScheduler struct {
+	bot         *tgbotapi.BotAPI
+	reminders   *service.ReminderService
+	userSvc     *service.UserService
+	funnel      *service.FunnelService
+	clubAddress string
+	interval    time.Duration
+}
+
+func NewReminderScheduler(bot *tgbotapi.BotAPI, reminders *service.ReminderService, userSvc *service.UserService, funnel *service.FunnelService, clubAddress string, interval time.Duration) *ReminderScheduler {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return &ReminderScheduler{
+		bot:         bot,
+		reminders:   reminders,
+		userSvc:     userSvc,
+		funnel:      funnel,
+		clubAddress: clubAddress,
+		interval:    interval,
+	}
+}
+
+// Run проверяет ваучеры сразу при старте и затем раз в interval, пока не отменён ctx.
+func (s *ReminderScheduler) Run(ctx context.Context) {
+	if !s.reminders.Enabled() {
+		return
+	}
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.sendDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ReminderScheduler) sendDue(ctx context.Context) {
+	due, err := s.reminders.Due(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "load due reminders failed", "error", err)
+		return
+	}
+	if len(due) == 0 {
+		return
+	}
+	slog.InfoContext(ctx, "sending voucher reminders", "count", len(due))
+	for i, rm := range due {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(reminderSendInterval):
+			}
+		}
+		s.send(ctx, rm)
+	}
+}
+
+func (s *ReminderScheduler) send(ctx context.Context, rm domain.VoucherReminder) {
+	msg := tgbotapi.NewMessage(rm.TelegramUserID, FormatReminder(rm, s.reminders.Location(), s.clubAddress))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔕 Больше не напоминать", callbackRemindersOff),
+	))
+	if _, err := s.bot.Send(msg); err != nil {
+		var apiErr *tgbotapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == 403 {
+			// Бот заблокирован — пользователь выпадет из следующих проходов
+			if err := s.userSvc.SetBotBlocked(ctx, rm.TelegramUserID, true); err != nil {
+				slog.WarnContext(ctx, "mark bot blocked failed", "error", err, "telegram_user_id", rm.TelegramUserID)
+			}
+			s.funnel.Track(ctx, rm.TelegramUserID, domain.FunnelEventBotBlocked, "")
+			metrics.VoucherReminders.WithLabelValues(deliveryBlocked).Inc()
+			return
+		}
+		// Не сохраняем — попробуем снова на следующем проходе
+		slog.WarnContext(ctx, "send voucher reminder failed", "error", err, "spin_id", rm.SpinID)
+		metrics.VoucherReminders.WithLabelValues(deliveryFailed).Inc()
+		return
+	}
+	metrics.VoucherReminders.WithLabelValues(deliverySent).Inc()
+	if err := s.reminders.MarkSent(ctx, rm); err != nil {
+		slog.ErrorContext(ctx, "save voucher reminder failed", "error", err, "spin_id", rm.SpinID)
+	}
+}
+
+// FormatReminder форматирует напоминание о ваучере для пользователя.
+func FormatReminder(rm domain.VoucherReminder, loc *time.Location, clubAddress string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "⏰ Ваш приз «%s» сгорит %s.\n\n", rm.PrizeName, rm.ExpiresAt.In(loc).Format("02.01.2006 в 15:04"))
+	fmt.Fprintf(&b, "🎟 Код ваучера: %s\n", rm.VoucherCode)
+	if clubAddress != "" {
+		fmt.Fprintf(&b, "📍 Ждём вас по адресу: %s\n", clubAddress)
+	}
+	b.WriteString("\nПокажите код администратору клуба, чтобы получить приз.")
+	return b.String()
+}
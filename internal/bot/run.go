@@ -0,0 +1,24 @@
+package bot
+
+import (
+	"context"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Run получает updates через long polling и обрабатывает их, пока не отменён ctx.
+func Run(ctx context.Context, tgBot *tgbotapi.BotAPI, handler *Handler) {
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = 60
+	updates := tgBot.GetUpdatesChan(u)
+	defer tgBot.StopReceivingUpdates()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case update := <-updates:
+			handler.HandleUpdate(ctx, update)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/notifier"
+	"era_sporta_bot_ruletka/internal/service"
+)
+
+// Режимы уведомлений админа о спинах (ADMIN_SPIN_NOTIFY_MODE).
+const (
+	SpinNotifyEach  = "each"
+	SpinNotifyBatch = "batch"
+	SpinNotifyOff   = "off"
+)
+
+// maxBatchLines — сколько спинов перечислять в одной сводке, остальные только считаются.
+const maxBatchLines = 30
+
+type batchedSpin struct {
+	phone string
+	prize string
+	at    time.Time
+}
+
+// SpinBatcher копит уведомления о спинах и раз в interval отправляет одну сводку.
+// Реализует notifier.AdminNotifier.
+type SpinBatcher struct {
+	outbox   *service.OutboxService
+	interval time.Duration
+	loc      *time.Location // часовой пояс времени спинов в сводке (STATS_TIMEZONE)
+
+	mu    sync.Mutex
+	spins []batchedSpin
+}
+
+func NewSpinBatcher(outbox *service.OutboxService, interval time.Duration, loc *time.Location) *SpinBatcher {
+	return &SpinBatcher{outbox: outbox, interval: interval, loc: loc}
+}
+
+func (b *SpinBatcher) NotifySpin(ctx context.Context, user *domain.User, spin *domain.SpinWithPrize) {
+	b.mu.Lock()
+	b.spins = append(b.spins, batchedSpin{phone: user.Phone, prize: spin.Prize.Name, at: time.Now()})
+	b.mu.Unlock()
+}
+
+// Run отправляет накопленные спины раз в interval и при остановке, пока не отменён ctx.
+func (b *SpinBatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// ctx уже отменён — отправляем остаток без него, чтобы не потерять спины
+			b.Flush(context.WithoutCancel(ctx))
+			return
+		case <-ticker.C:
+			b.Flush(ctx)
+		}
+	}
+}
+
+// Flush ставит накопленные спины одной сводкой в очередь уведомлений.
+func (b *SpinBatcher) Flush(ctx context.Context) {
+	b.mu.Lock()
+	spins := b.spins
+	b.spins = nil
+	b.mu.Unlock()
+	if len(spins) == 0 {
+		return
+	}
+	slog.DebugContext(ctx, "flushing spin batch", "spins", len(spins))
+	if err := b.outbox.EnqueueEvent(ctx, notifier.EventSpin, nil, domain.OutboxMessage{Text: formatSpinBatch(spins, b.loc)}); err != nil {
+		slog.ErrorContext(ctx, "enqueue spin batch failed", "error", err, "spins", len(spins))
+	}
+}
+
+func formatSpinBatch(spins []batchedSpin, loc *time.Location) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🎰 Новые спины: %d\n", len(spins))
+	for i, s := range spins {
+		if i == maxBatchLines {
+			fmt.Fprintf(&sb, "…и ещё %d", len(spins)-maxBatchLines)
+			break
+		}
+		fmt.Fprintf(&sb, "%s  %s — %s\n", s.at.In(loc).Format("15:04"), s.phone, s.prize)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,94 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log/slog"
+	"strings"
+
+	"era_sporta_bot_ruletka/internal/notifier"
+)
+
+const msgTemplateUsage = "Шаблоны уведомлений (Go text/template, разметка HTML):\n" +
+	"/templates — список событий\n" +
+	"/template <событие> — показать текущий шаблон\n" +
+	"/template <событие> reset — вернуть шаблон по умолчанию\n" +
+	"/template_set <событие>\n<текст шаблона с новой строки>"
+
+// handleTemplatesCommand показывает события и какие шаблоны изменены.
+func (h *Handler) handleTemplatesCommand(ctx context.Context, chatID int64) {
+	var b strings.Builder
+	b.WriteString("Шаблоны уведомлений:\n")
+	for _, e := range notifier.Events {
+		_, custom, err := h.templates.Body(ctx, e)
+		if err != nil {
+			slog.ErrorContext(ctx, "load notification template failed", "error", err, "event", string(e))
+			h.send(ctx, chatID, "Не удалось получить шаблоны. Попробуйте позже.")
+			return
+		}
+		state := "по умолчанию"
+		if custom {
+			state = "изменён"
+		}
+		fmt.Fprintf(&b, "• %s — %s\n", e, state)
+	}
+	b.WriteString("\n" + msgTemplateUsage)
+	h.send(ctx, chatID, b.String())
+}
+
+// handleTemplateCommand показывает шаблон события или сбрасывает его.
+func (h *Handler) handleTemplateCommand(ctx context.Context, chatID int64, args string) {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		h.send(ctx, chatID, msgTemplateUsage)
+		return
+	}
+	e, ok := notifier.ParseEvent(fields[0])
+	if !ok {
+		h.send(ctx, chatID, "Неизвестное событие: "+fields[0]+"\n\n"+msgTemplateUsage)
+		return
+	}
+
+	if len(fields) > 1 && fields[1] == "reset" {
+		if err := h.templates.Reset(ctx, e); err != nil {
+			slog.ErrorContext(ctx, "reset notification template failed", "error", err, "event", string(e))
+			h.send(ctx, chatID, "Не удалось сбросить шаблон. Попробуйте позже.")
+			return
+		}
+		slog.InfoContext(ctx, "notification template reset", "event", string(e))
+		h.send(ctx, chatID, fmt.Sprintf("Шаблон %s сброшен на шаблон по умолчанию.", e))
+		return
+	}
+
+	body, custom, err := h.templates.Body(ctx, e)
+	if err != nil {
+		slog.ErrorContext(ctx, "load notification template failed", "error", err, "event", string(e))
+		h.send(ctx, chatID, "Не удалось получить шаблон. Попробуйте позже.")
+		return
+	}
+	state := "по умолчанию"
+	if custom {
+		state = "изменён"
+	}
+	// Шаблон показываем как код, чтобы разметка в нём не применилась
+	h.sendHTML(ctx, chatID, fmt.Sprintf("Шаблон <b>%s</b> (%s):\n<pre>%s</pre>", e, state, html.EscapeString(body)))
+}
+
+// handleTemplateSetCommand сохраняет шаблон: первая строка — событие, дальше — текст шаблона.
+func (h *Handler) handleTemplateSetCommand(ctx context.Context, chatID, adminID int64, args string) {
+	name, body, _ := strings.Cut(args, "\n")
+	e, ok := notifier.ParseEvent(strings.TrimSpace(name))
+	body = strings.TrimSpace(body)
+	if !ok || body == "" {
+		h.send(ctx, chatID, msgTemplateUsage)
+		return
+	}
+	if err := h.templates.Set(ctx, e, body, adminID); err != nil {
+		slog.WarnContext(ctx, "set notification template failed", "error", err, "event", string(e))
+		h.send(ctx, chatID, "Шаблон не сохранён: "+err.Error())
+		return
+	}
+	slog.InfoContext(ctx, "notification template updated", "event", string(e), "admin_telegram_id", adminID)
+	h.send(ctx, chatID, fmt.Sprintf("Шаблон %s сохранён. Процессы бота и API подхватят его в течение минуты.", e))
+}
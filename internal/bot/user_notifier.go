@@ -0,0 +1,51 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/service"
+)
+
+// UserSpinNotifier присылает пользователю в чат с ботом результат спина, чтобы приз
+// не терялся после закрытия Mini App. Реализует notifier.UserNotifier через очередь уведомлений.
+type UserSpinNotifier struct {
+	outbox      *service.OutboxService
+	loc         *time.Location
+	clubAddress string
+}
+
+func NewUserSpinNotifier(outbox *service.OutboxService, loc *time.Location, clubAddress string) *UserSpinNotifier {
+	return &UserSpinNotifier{outbox: outbox, loc: loc, clubAddress: clubAddress}
+}
+
+func (n *UserSpinNotifier) NotifySpinResult(ctx context.Context, user *domain.User, spin *domain.SpinWithPrize) {
+	if n == nil || n.outbox == nil {
+		return
+	}
+	if err := n.outbox.EnqueueUser(ctx, user.TelegramUserID, FormatSpinResult(spin, n.loc, n.clubAddress)); err != nil {
+		slog.ErrorContext(ctx, "enqueue spin result failed", "error", err)
+	}
+}
+
+// FormatSpinResult форматирует сообщение пользователю о выигранном призе.
+func FormatSpinResult(spin *domain.SpinWithPrize, loc *time.Location, clubAddress string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🎉 Поздравляем! Ваш приз: «%s»\n\n", spin.Prize.Name)
+	if spin.VoucherCode != "" {
+		fmt.Fprintf(&b, "🎟 Код ваучера: %s\n", spin.VoucherCode)
+	}
+	if spin.ExpiresAt != nil {
+		fmt.Fprintf(&b, "⏳ Действует до: %s\n", spin.ExpiresAt.In(loc).Format("02.01.2006"))
+	}
+	if clubAddress != "" {
+		fmt.Fprintf(&b, "📍 Адрес клуба: %s\n", clubAddress)
+	}
+	b.WriteString("\nКак получить: покажите код администратору на ресепшене клуба «Эра Спорта». " +
+		"Наш менеджер также свяжется с вами по номеру телефона.")
+	return b.String()
+}
@@ -6,6 +6,45 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// ExpectedSchemaVersion — номер последней миграции в migrations/, которую ожидает код.
+const ExpectedSchemaVersion = 20
+
+// latestSchemaObjects — объекты, созданные последними миграциями (019, 020). По ним проверяется
+// схема, применённая через scripts/init_db_all.sql, где версии goose нет.
+// Обновляется вместе с ExpectedSchemaVersion.
+var latestSchemaObjects = []string{"refresh_token_uses", "idx_users_phone_ban_key"}
+
 func NewPool(ctx context.Context, connString string) (*pgxpool.Pool, error) {
-	return pgxpool.New(ctx, connString)
+	cfg, err := pgxpool.ParseConfig(connString)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ConnConfig.Tracer = queryTracer{}
+	return pgxpool.NewWithConfig(ctx, cfg)
+}
+
+// SchemaVersion возвращает последнюю применённую версию goose-миграций
+// или -1, если таблицы goose_db_version нет (схема создана скриптом).
+func SchemaVersion(ctx context.Context, pool *pgxpool.Pool) (int64, error) {
+	var exists bool
+	if err := pool.QueryRow(ctx, `SELECT to_regclass('goose_db_version') IS NOT NULL`).Scan(&exists); err != nil {
+		return 0, err
+	}
+	if !exists {
+		return -1, nil
+	}
+	var version int64
+	err := pool.QueryRow(ctx, `
+		SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version WHERE is_applied
+	`).Scan(&version)
+	return version, err
+}
+
+// MissingSchemaObjects возвращает объекты последних миграций, которых нет в базе.
+func MissingSchemaObjects(ctx context.Context, pool *pgxpool.Pool) ([]string, error) {
+	var missing []string
+	err := pool.QueryRow(ctx, `
+		SELECT COALESCE(array_agg(name), '{}') FROM unnest($1::text[]) AS name WHERE to_regclass(name) IS NULL
+	`, latestSchemaObjects).Scan(&missing)
+	return missing, err
 }
@@ -0,0 +1,35 @@
+package db
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type queryStartKey struct{}
+
+// queryTracer пишет SQL-запросы в лог на уровне debug. Контекст запроса несёт request_id,
+// поэтому запросы репозиториев связываются с HTTP-запросом или update бота.
+type queryTracer struct{}
+
+func (queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if !slog.Default().Enabled(ctx, slog.LevelDebug) {
+		return ctx
+	}
+	ctx = context.WithValue(ctx, queryStartKey{}, time.Now())
+	return ctx
+}
+
+func (queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(queryStartKey{}).(time.Time)
+	if !ok {
+		return
+	}
+	if data.Err != nil {
+		slog.DebugContext(ctx, "sql query failed", "error", data.Err, "duration_ms", time.Since(start).Milliseconds())
+		return
+	}
+	slog.DebugContext(ctx, "sql query", "command", data.CommandTag.String(), "duration_ms", time.Since(start).Milliseconds())
+}
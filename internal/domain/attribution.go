@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Attribution — источник пользователя или спина из start_param ссылки startapp.
+type Attribution struct {
+	StartParam         string
+	Campaign           string
+	ReferrerTelegramID int64
+}
+
+const (
+	referrerPrefix = "ref_"
+	campaignPrefix = "c_"
+	maxCampaignLen = 100
+)
+
+// ParseStartParam разбирает start_param: "ref_<telegram_id>" — реферальная ссылка,
+// "c_<кампания>" или любое другое значение — кампания.
+func ParseStartParam(startParam string) Attribution {
+	sp := strings.TrimSpace(startParam)
+	a := Attribution{StartParam: sp}
+	switch {
+	case sp == "":
+	case strings.HasPrefix(sp, referrerPrefix):
+		if id, err := strconv.ParseInt(strings.TrimPrefix(sp, referrerPrefix), 10, 64); err == nil && id > 0 {
+			a.ReferrerTelegramID = id
+		} else {
+			a.Campaign = sp
+		}
+	case strings.HasPrefix(sp, campaignPrefix):
+		a.Campaign = strings.TrimPrefix(sp, campaignPrefix)
+	default:
+		a.Campaign = sp
+	}
+	if len(a.Campaign) > maxCampaignLen {
+		a.Campaign = a.Campaign[:maxCampaignLen]
+	}
+	return a
+}
+
+// IsZero сообщает, что источник не задан.
+func (a Attribution) IsZero() bool {
+	return a.StartParam == ""
+}
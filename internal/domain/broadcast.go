@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// BroadcastAudience — кому отправляется рассылка.
+type BroadcastAudience string
+
+const (
+	AudienceAll        BroadcastAudience = "all"        // все, кто поделился номером
+	AudienceSpun       BroadcastAudience = "spun"       // крутили рулетку
+	AudienceNotSpun    BroadcastAudience = "not_spun"   // ещё не крутили
+	AudiencePrize      BroadcastAudience = "prize"      // выиграли приз PrizeID
+	AudienceUnredeemed BroadcastAudience = "unredeemed" // есть непогашенный и непросроченный ваучер
+)
+
+// ParseAudience разбирает аудиторию; для prize следующий аргумент — id приза.
+func ParseAudience(args []string) (BroadcastAudience, int, error) {
+	if len(args) == 0 {
+		return "", 0, fmt.Errorf("audience required")
+	}
+	switch a := BroadcastAudience(args[0]); a {
+	case AudienceAll, AudienceSpun, AudienceNotSpun, AudienceUnredeemed:
+		return a, 0, nil
+	case AudiencePrize:
+		if len(args) < 2 {
+			return "", 0, fmt.Errorf("prize id required")
+		}
+		id, err := strconv.Atoi(args[1])
+		if err != nil || id <= 0 {
+			return "", 0, fmt.Errorf("invalid prize id %q", args[1])
+		}
+		return a, id, nil
+	}
+	return "", 0, fmt.Errorf("unknown audience %q", args[0])
+}
+
+type BroadcastStatus string
+
+const (
+	BroadcastDraft     BroadcastStatus = "draft"
+	BroadcastRunning   BroadcastStatus = "running"
+	BroadcastDone      BroadcastStatus = "done"
+	BroadcastCancelled BroadcastStatus = "cancelled"
+	BroadcastFailed    BroadcastStatus = "failed"
+)
+
+type Broadcast struct {
+	ID          int64
+	Audience    BroadcastAudience
+	PrizeID     int
+	Text        string
+	PhotoFileID string
+	ButtonText  string
+	ButtonURL   string
+	Status      BroadcastStatus
+	CreatedBy   int64
+	Total       int
+	Sent        int
+	Failed      int
+	Blocked     int
+	CreatedAt   time.Time
+	StartedAt   *time.Time
+	FinishedAt  *time.Time
+}
+
+// Recipient — получатель рассылки.
+type Recipient struct {
+	UserID         int64
+	TelegramUserID int64
+}
@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// ExportFilter — фильтр выгрузки. Период применяется к дате спина (для пользователей без спинов — к дате регистрации).
+type ExportFilter struct {
+	From     time.Time
+	To       time.Time
+	Campaign string
+}
+
+// ExportRow — строка выгрузки: спин пользователя или пользователь без спинов (SpinID == 0).
+type ExportRow struct {
+	UserID         int64
+	TelegramUserID int64
+	FirstName      string
+	LastName       string
+	Username       string
+	Phone          string
+	RegisteredAt   time.Time
+	SpinID         int64
+	Prize          string
+	SpinAt         *time.Time
+	Status         SpinStatus
+	VoucherCode    string
+	RedeemedAt     *time.Time
+	StartParam     string
+	Campaign       string
+}
@@ -0,0 +1,68 @@
+package domain
+
+import "time"
+
+// FunnelEvent — шаг воронки, который записывается в funnel_events.
+type FunnelEvent string
+
+const (
+	FunnelEventStart      FunnelEvent = "start"
+	FunnelEventSubscribed FunnelEvent = "subscribed"
+	FunnelEventAppOpened  FunnelEvent = "app_opened"
+	// Пользователь заблокировал или разблокировал бота (my_chat_member). В воронку не входят.
+	FunnelEventBotBlocked   FunnelEvent = "bot_blocked"
+	FunnelEventBotUnblocked FunnelEvent = "bot_unblocked"
+)
+
+// Шаги воронки в отчёте. phone_shared и spun берутся из users и spins.
+const (
+	FunnelStepStart       = "start"
+	FunnelStepSubscribed  = "subscribed"
+	FunnelStepPhoneShared = "phone_shared"
+	FunnelStepAppOpened   = "app_opened"
+	FunnelStepSpun        = "spun"
+)
+
+// FunnelSteps — порядок шагов воронки.
+var FunnelSteps = []string{
+	FunnelStepStart,
+	FunnelStepSubscribed,
+	FunnelStepPhoneShared,
+	FunnelStepAppOpened,
+	FunnelStepSpun,
+}
+
+// DailyStats — уникальные пользователи на каждом шаге воронки за день и число спинов.
+type DailyStats struct {
+	Date  time.Time
+	Steps map[string]int
+	Spins int
+}
+
+// PrizeStats — фактическое распределение выигрышей по призу против заданного весами.
+type PrizeStats struct {
+	PrizeID        int
+	Name           string
+	Type           string
+	IsActive       bool
+	Weight         int
+	Spins          int
+	ConfiguredRate float64
+	ActualRate     float64
+}
+
+// Digest — сводка за период для админского чата.
+type Digest struct {
+	From         time.Time
+	To           time.Time
+	NewUsers     int
+	Spins        int
+	Redemptions  int
+	Unsubscribes int
+	SpinsByPrize []PrizeCount
+}
+
+type PrizeCount struct {
+	Name  string
+	Count int
+}
@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// PhoneBan — запрет на номер телефона: действует на все аккаунты с этим номером,
+// в том числе зарегистрированные после бана.
+type PhoneBan struct {
+	Phone     string
+	Reason    string
+	BannedBy  string
+	CreatedAt time.Time
+}
+
+// PhoneBanKey приводит номер к виду, по которому ищутся баны: только цифры, российский
+// номер с 8 в начале — с 7 (так его присылает Telegram). Пустая строка — номер не распознан.
+func PhoneBanKey(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	digits := b.String()
+	if len(digits) == 11 && digits[0] == '8' {
+		digits = "7" + digits[1:]
+	}
+	if len(digits) < 7 {
+		return ""
+	}
+	return digits
+}
@@ -0,0 +1,35 @@
+package domain
+
+import "time"
+
+// OutboxKind — адресат сообщения в очереди: админский чат или личный чат пользователя.
+type OutboxKind string
+
+const (
+	OutboxAdmin OutboxKind = "admin"
+	OutboxUser  OutboxKind = "user"
+)
+
+type OutboxStatus string
+
+const (
+	OutboxPending OutboxStatus = "pending"
+	OutboxSent    OutboxStatus = "sent"
+	OutboxFailed  OutboxStatus = "failed"
+)
+
+// OutboxMessage — сообщение бота, ожидающее доставки. Для OutboxAdmin ChatID не задаётся:
+// сообщение уходит получателю Destination из настроек маршрутизации (пусто — получателю по умолчанию).
+type OutboxMessage struct {
+	ID          int64
+	Kind        OutboxKind
+	ChatID      int64
+	Destination string
+	Event       string
+	Text        string
+	ParseMode   string
+	// ReplyMarkup — inline-клавиатура в JSON (формат Bot API), пусто — без кнопок.
+	ReplyMarkup []byte
+	Attempts    int
+	CreatedAt   time.Time
+}
@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// ParseDateBound разбирает границу периода: RFC 3339 или дату YYYY-MM-DD в поясе loc.
+// Для верхней границы (upper) дата означает конец дня, поэтому сдвигается на сутки вперёд.
+// Пустая строка — нулевое время (граница не задана).
+func ParseDateBound(v string, upper bool, loc *time.Location) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, v, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", v)
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
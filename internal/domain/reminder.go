@@ -0,0 +1,14 @@
+package domain
+
+import "time"
+
+// VoucherReminder — непогашенный ваучер, о котором пора напомнить владельцу.
+type VoucherReminder struct {
+	SpinID         int64
+	UserID         int64
+	TelegramUserID int64
+	PrizeName      string
+	VoucherCode    string
+	ExpiresAt      time.Time
+	DaysBefore     int
+}
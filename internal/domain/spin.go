@@ -2,16 +2,77 @@ package domain
 
 import "time"
 
+// SpinStatus — статус ваучера, выданного по спину.
+type SpinStatus string
+
+const (
+	SpinStatusIssued   SpinStatus = "issued"
+	SpinStatusClaimed  SpinStatus = "claimed"
+	SpinStatusRedeemed SpinStatus = "redeemed"
+	SpinStatusExpired  SpinStatus = "expired"
+)
+
+// Label — название статуса для людей: одно и то же в админском чате и в выгрузке.
+func (s SpinStatus) Label() string {
+	switch s {
+	case SpinStatusIssued:
+		return "выдан"
+	case SpinStatusClaimed:
+		return "созвонились"
+	case SpinStatusRedeemed:
+		return "погашен"
+	case SpinStatusExpired:
+		return "истёк"
+	}
+	return string(s)
+}
+
+// ParseSpinStatus возвращает статус и true, если s — известный статус.
+func ParseSpinStatus(s string) (SpinStatus, bool) {
+	switch st := SpinStatus(s); st {
+	case SpinStatusIssued, SpinStatusClaimed, SpinStatusRedeemed, SpinStatusExpired:
+		return st, true
+	}
+	return "", false
+}
+
 type Spin struct {
 	ID          int64
 	UserID      int64
 	PrizeID     int
 	ResultValue float64
-	IPHash      string
-	CreatedAt   time.Time
+	// IPHash — HMAC адреса клиента, IPHashVersion — номер ключа (internal/iphash), 0 — до введения ключей.
+	IPHash        string
+	IPHashVersion int
+	StartParam    string
+	Campaign      string
+	Status        SpinStatus
+	VoucherCode   string
+	// FraudScore и FraudReasons — оценка риска спина (internal/fraud), 0 — сигналов нет.
+	FraudScore   int
+	FraudReasons []string
+	ClaimedAt    *time.Time
+	RedeemedAt   *time.Time
+	ExpiresAt    *time.Time
+	CreatedAt    time.Time
 }
 
 type SpinWithPrize struct {
 	Spin
 	Prize *Prize
 }
+
+// SpinCursor — позиция в истории спинов (created_at, id), с которой продолжается выборка.
+type SpinCursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// SpinFilter — фильтр истории спинов пользователя. Нулевые поля не ограничивают выборку.
+type SpinFilter struct {
+	Status SpinStatus
+	From   time.Time
+	To     time.Time
+	After  *SpinCursor
+	Limit  int
+}
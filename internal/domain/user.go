@@ -3,12 +3,25 @@ package domain
 import "time"
 
 type User struct {
-	ID              int64
-	TelegramUserID  int64
-	Phone           string
-	FirstName       string
-	LastName        string
-	Username        string
-	CreatedAt       time.Time
-	UpdatedAt       time.Time
+	ID             int64
+	TelegramUserID int64
+	Phone          string
+	FirstName      string
+	LastName       string
+	Username       string
+	Attribution    Attribution
+	// IsPremium — Telegram Premium по последней initData (сигнал для internal/fraud).
+	IsPremium bool
+	// BlockedAt — когда менеджер заблокировал пользователя (nil — не заблокирован),
+	// BlockReason и BlockedBy — причина и кто заблокировал.
+	BlockedAt   *time.Time
+	BlockReason string
+	BlockedBy   string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// IsBlocked сообщает, что пользователю закрыт доступ к розыгрышу.
+func (u *User) IsBlocked() bool {
+	return u.BlockedAt != nil
 }
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WebhookEvent — событие, о котором сообщают исходящие webhook (в CRM).
+type WebhookEvent string
+
+const (
+	WebhookUserRegistered  WebhookEvent = "user.registered"
+	WebhookSpinCreated     WebhookEvent = "spin.created"
+	WebhookVoucherRedeemed WebhookEvent = "voucher.redeemed"
+)
+
+// WebhookEvents — все события в порядке для документации и проверки настроек.
+var WebhookEvents = []WebhookEvent{WebhookUserRegistered, WebhookSpinCreated, WebhookVoucherRedeemed}
+
+// ParseWebhookEvent возвращает событие и true, если s — известное событие.
+func ParseWebhookEvent(s string) (WebhookEvent, bool) {
+	for _, e := range WebhookEvents {
+		if string(e) == s {
+			return e, true
+		}
+	}
+	return "", false
+}
+
+type WebhookStatus string
+
+const (
+	WebhookPending   WebhookStatus = "pending"
+	WebhookDelivered WebhookStatus = "delivered"
+	WebhookFailed    WebhookStatus = "failed"
+)
+
+// WebhookDelivery — доставка одного события на один URL; запись остаётся в журнале после доставки.
+type WebhookDelivery struct {
+	ID      int64
+	EventID string
+	Event   WebhookEvent
+	URL     string
+	// Payload — тело запроса, собранное при постановке в очередь.
+	Payload      json.RawMessage
+	Status       WebhookStatus
+	Attempts     int
+	ResponseCode int
+	LastError    string
+	CreatedAt    time.Time
+	DeliveredAt  *time.Time
+}
@@ -0,0 +1,177 @@
+// Package export формирует выгрузку пользователей и спинов в CSV и XLSX для менеджеров.
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"era_sporta_bot_ruletka/internal/domain"
+
+	"github.com/xuri/excelize/v2"
+)
+
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+// ParseFormat возвращает формат по имени (csv, xlsx; пусто — csv).
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case "":
+		return FormatCSV, nil
+	case FormatCSV, FormatXLSX:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown export format %q", s)
+}
+
+// ContentType возвращает MIME-тип файла выгрузки.
+func (f Format) ContentType() string {
+	if f == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// FileName возвращает имя файла выгрузки на момент now.
+func (f Format) FileName(now time.Time) string {
+	return "era_sporta_export_" + now.Format("2006-01-02_1504") + "." + string(f)
+}
+
+const dateTimeLayout = "02.01.2006 15:04"
+
+var header = []string{
+	"Имя", "Телефон", "Telegram", "Telegram ID", "Дата регистрации",
+	"Приз", "Дата спина", "Статус", "Код ваучера", "Дата погашения", "Источник",
+}
+
+// record форматирует строку выгрузки. Даты переводятся в пояс loc.
+func record(r *domain.ExportRow, loc *time.Location) []string {
+	name := strings.TrimSpace(r.FirstName + " " + r.LastName)
+	username := ""
+	if r.Username != "" {
+		username = "@" + r.Username
+	}
+	source := r.Campaign
+	if source == "" {
+		source = r.StartParam
+	}
+	return []string{
+		name,
+		r.Phone,
+		username,
+		strconv.FormatInt(r.TelegramUserID, 10),
+		r.RegisteredAt.In(loc).Format(dateTimeLayout),
+		r.Prize,
+		formatTime(r.SpinAt, loc),
+		r.Status.Label(),
+		r.VoucherCode,
+		formatTime(r.RedeemedAt, loc),
+		source,
+	}
+}
+
+func formatTime(t *time.Time, loc *time.Location) string {
+	if t == nil {
+		return ""
+	}
+	return t.In(loc).Format(dateTimeLayout)
+}
+
+// Write пишет выгрузку в формате f.
+func Write(w io.Writer, f Format, rows []*domain.ExportRow, loc *time.Location) error {
+	if f == FormatXLSX {
+		return WriteXLSX(w, rows, loc)
+	}
+	return WriteCSV(w, rows, loc)
+}
+
+// WriteCSV пишет CSV с разделителем «;» и BOM — так файл сразу открывается в Excel с русской локалью.
+func WriteCSV(w io.Writer, rows []*domain.ExportRow, loc *time.Location) error {
+	if _, err := io.WriteString(w, "\uFEFF"); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	cw.Comma = ';'
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		rec := record(r, loc)
+		for i, v := range rec {
+			rec[i] = escapeFormula(v)
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// escapeFormula экранирует ячейку CSV, которую Excel принял бы за формулу (имя вида «=HYPERLINK(…)»
+// из Telegram), апострофом. В XLSX значения и так записываются строками.
+func escapeFormula(v string) string {
+	if v != "" && strings.ContainsRune("=+-@", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+// WriteXLSX пишет книгу с одним листом «Выгрузка».
+func WriteXLSX(w io.Writer, rows []*domain.ExportRow, loc *time.Location) error {
+	const sheet = "Выгрузка"
+	f := excelize.NewFile()
+	defer f.Close()
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		return err
+	}
+
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+	bold, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+	if err := sw.SetColWidth(1, len(header), 20); err != nil {
+		return err
+	}
+	if err := sw.SetPanes(&excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return err
+	}
+
+	cells := make([]any, len(header))
+	for i, h := range header {
+		cells[i] = excelize.Cell{StyleID: bold, Value: h}
+	}
+	if err := sw.SetRow("A1", cells); err != nil {
+		return err
+	}
+	for i, r := range rows {
+		rec := record(r, loc)
+		cells := make([]any, len(rec))
+		for j, v := range rec {
+			cells[j] = v
+		}
+		cell, err := excelize.CoordinatesToCellName(1, i+2)
+		if err != nil {
+			return err
+		}
+		if err := sw.SetRow(cell, cells); err != nil {
+			return err
+		}
+	}
+	if err := sw.Flush(); err != nil {
+		return err
+	}
+	return f.Write(w)
+}
@@ -0,0 +1,176 @@
+// Package fraud оценивает риск спина по сигналам: много аккаунтов с одного адреса, новый аккаунт,
+// номер из неожиданной страны, аккаунт Telegram без username и без Premium.
+package fraud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/iphash"
+)
+
+// Action — решение по спину.
+type Action string
+
+const (
+	ActionAllow Action = "allow"
+	ActionFlag  Action = "flag"
+	ActionBlock Action = "block"
+)
+
+// Rules — пороги и веса сигналов (FRAUD_RULES). Нулевой вес отключает сигнал,
+// нулевой BlockScore — спины не отклоняются, только помечаются.
+type Rules struct {
+	// SameIPUsers — сколько других аккаунтов крутили с того же ip_hash за SameIPWindowHours.
+	SameIPUsers       int `json:"same_ip_users"`
+	SameIPWindowHours int `json:"same_ip_window_hours"`
+	SameIPScore       int `json:"same_ip_score"`
+
+	// NewAccountMinutes — аккаунт считается новым, если пользователь создан (users.created_at)
+	// меньше стольких минут назад. Смена номера существующим пользователем возраст не сбрасывает.
+	NewAccountMinutes int `json:"new_account_minutes"`
+	NewAccountScore   int `json:"new_account_score"`
+
+	// PhonePrefixes — ожидаемые коды стран (цифры после +), пусто — не проверять.
+	PhonePrefixes     []string `json:"phone_prefixes"`
+	ForeignPhoneScore int      `json:"foreign_phone_score"`
+
+	NoUsernameScore int `json:"no_username_score"`
+	NoPremiumScore  int `json:"no_premium_score"`
+
+	// FlagScore — с какого риска спин помечается и уходит уведомление fraud_alert.
+	FlagScore int `json:"flag_score"`
+	// BlockScore — с какого риска спин отклоняется (0 — не отклонять).
+	BlockScore int `json:"block_score"`
+}
+
+// DefaultRules — правила по умолчанию: спины помечаются, но не отклоняются.
+func DefaultRules() Rules {
+	return Rules{
+		SameIPUsers:       3,
+		SameIPWindowHours: 24,
+		SameIPScore:       40,
+		NewAccountMinutes: 10,
+		NewAccountScore:   15,
+		PhonePrefixes:     []string{"7"},
+		ForeignPhoneScore: 30,
+		NoUsernameScore:   10,
+		NoPremiumScore:    5,
+		FlagScore:         40,
+	}
+}
+
+// ParseRules накладывает JSON из FRAUD_RULES на правила по умолчанию: указывать нужно только
+// изменяемые поля. Пустая строка — правила по умолчанию.
+func ParseRules(rulesJSON string) (Rules, error) {
+	r := DefaultRules()
+	if strings.TrimSpace(rulesJSON) != "" {
+		dec := json.NewDecoder(strings.NewReader(rulesJSON))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&r); err != nil {
+			return r, fmt.Errorf("FRAUD_RULES: %w", err)
+		}
+	}
+	if r.FlagScore <= 0 {
+		return r, errors.New("FRAUD_RULES: flag_score must be positive")
+	}
+	if r.BlockScore != 0 && r.BlockScore < r.FlagScore {
+		return r, errors.New("FRAUD_RULES: block_score must be 0 or not less than flag_score")
+	}
+	if r.SameIPScore > 0 && (r.SameIPUsers <= 0 || r.SameIPWindowHours <= 0) {
+		return r, errors.New("FRAUD_RULES: same_ip_users and same_ip_window_hours must be positive")
+	}
+	return r, nil
+}
+
+// IPStore считает аккаунты, крутившие рулетку с того же адреса: ipHashes — хэши адреса
+// по всем ключам (internal/iphash), действовавшим в окне.
+type IPStore interface {
+	CountOtherUsersByIPHash(ctx context.Context, ipHashes []string, userID int64, since time.Time) (int, error)
+}
+
+// Assessment — оценка спина: суммарный риск, сработавшие сигналы и решение.
+type Assessment struct {
+	Score   int
+	Reasons []string
+	Action  Action
+}
+
+// Flagged сообщает, что о спине нужно предупредить менеджеров.
+func (a Assessment) Flagged() bool {
+	return a.Action == ActionFlag || a.Action == ActionBlock
+}
+
+// Scorer оценивает спины по правилам.
+type Scorer struct {
+	rules  Rules
+	store  IPStore
+	hasher *iphash.Hasher
+}
+
+func NewScorer(rules Rules, store IPStore, hasher *iphash.Hasher) *Scorer {
+	return &Scorer{rules: rules, store: store, hasher: hasher}
+}
+
+// Assess оценивает спин пользователя с адреса clientIP. Ошибка хранилища возвращается вместе
+// с оценкой: сигнал по IP пропускается, остальные считаются, спин из-за сбоя не отклоняется.
+func (s *Scorer) Assess(ctx context.Context, user *domain.User, clientIP string, now time.Time) (Assessment, error) {
+	var a Assessment
+	add := func(score int, reason string) {
+		if score > 0 {
+			a.Score += score
+			a.Reasons = append(a.Reasons, reason)
+		}
+	}
+
+	var storeErr error
+	if s.rules.SameIPScore > 0 {
+		// Окно может захватить смену ключа: ищем хэши адреса по всем ключам за окно
+		since := now.Add(-time.Duration(s.rules.SameIPWindowHours) * time.Hour)
+		if ipHashes := s.hasher.Candidates(clientIP, since, now); len(ipHashes) > 0 {
+			n, err := s.store.CountOtherUsersByIPHash(ctx, ipHashes, user.ID, since)
+			if err != nil {
+				storeErr = fmt.Errorf("count users by ip hash: %w", err)
+			} else if n >= s.rules.SameIPUsers {
+				add(s.rules.SameIPScore, fmt.Sprintf("с этого IP крутили ещё %d акк. за %d ч", n, s.rules.SameIPWindowHours))
+			}
+		}
+	}
+	if s.rules.NewAccountMinutes > 0 && now.Sub(user.CreatedAt) < time.Duration(s.rules.NewAccountMinutes)*time.Minute {
+		add(s.rules.NewAccountScore, fmt.Sprintf("аккаунт создан меньше %d мин назад", s.rules.NewAccountMinutes))
+	}
+	if len(s.rules.PhonePrefixes) > 0 && user.Phone != "" && !hasPhonePrefix(user.Phone, s.rules.PhonePrefixes) {
+		add(s.rules.ForeignPhoneScore, "номер с неожиданным кодом страны")
+	}
+	if user.Username == "" {
+		add(s.rules.NoUsernameScore, "нет username в Telegram")
+	}
+	if !user.IsPremium {
+		add(s.rules.NoPremiumScore, "нет Telegram Premium")
+	}
+
+	switch {
+	case s.rules.BlockScore > 0 && a.Score >= s.rules.BlockScore:
+		a.Action = ActionBlock
+	case a.Score >= s.rules.FlagScore:
+		a.Action = ActionFlag
+	default:
+		a.Action = ActionAllow
+	}
+	return a, storeErr
+}
+
+func hasPhonePrefix(phone string, prefixes []string) bool {
+	digits := strings.TrimPrefix(phone, "+")
+	for _, p := range prefixes {
+		if strings.HasPrefix(digits, strings.TrimPrefix(p, "+")) {
+			return true
+		}
+	}
+	return false
+}
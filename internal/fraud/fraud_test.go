@@ -0,0 +1,227 @@
+package fraud
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/iphash"
+)
+
+// fakeIPStore возвращает заданное число других аккаунтов и запоминает запрос.
+type fakeIPStore struct {
+	others int
+	err    error
+
+	calls    int
+	ipHashes []string
+	since    time.Time
+}
+
+func (s *fakeIPStore) CountOtherUsersByIPHash(_ context.Context, ipHashes []string, _ int64, since time.Time) (int, error) {
+	s.calls++
+	s.ipHashes = ipHashes
+	s.since = since
+	return s.others, s.err
+}
+
+var testNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// onlyRules — правила, где работает один сигнал с весом 10 и порогом пометки 10.
+func onlyRules(set func(r *Rules)) Rules {
+	r := Rules{FlagScore: 10}
+	set(&r)
+	return r
+}
+
+// cleanUser не срабатывает ни на один сигнал.
+func cleanUser() *domain.User {
+	return &domain.User{
+		ID:        1,
+		Phone:     "+79001234567",
+		Username:  "anna",
+		IsPremium: true,
+		CreatedAt: testNow.Add(-30 * 24 * time.Hour),
+	}
+}
+
+func newTestScorer(t *testing.T, rules Rules, store IPStore) *Scorer {
+	t.Helper()
+	hasher, err := iphash.New("test-ip-secret", "", 24*time.Hour)
+	if err != nil {
+		t.Fatalf("iphash.New: %v", err)
+	}
+	return NewScorer(rules, store, hasher)
+}
+
+// TestAssessSignals проверяет каждый сигнал по отдельности: срабатывание и границу.
+func TestAssessSignals(t *testing.T) {
+	sameIP := func(r *Rules) { r.SameIPUsers, r.SameIPWindowHours, r.SameIPScore = 3, 24, 10 }
+	newAccount := func(r *Rules) { r.NewAccountMinutes, r.NewAccountScore = 10, 10 }
+	foreign := func(r *Rules) { r.PhonePrefixes, r.ForeignPhoneScore = []string{"7", "+375"}, 10 }
+	noUsername := func(r *Rules) { r.NoUsernameScore = 10 }
+	noPremium := func(r *Rules) { r.NoPremiumScore = 10 }
+
+	cases := []struct {
+		name    string
+		rules   Rules
+		others  int
+		ip      string
+		user    func(u *domain.User)
+		flagged bool
+	}{
+		{name: "same ip at threshold", rules: onlyRules(sameIP), others: 3, ip: "203.0.113.7", flagged: true},
+		{name: "same ip below threshold", rules: onlyRules(sameIP), others: 2, ip: "203.0.113.7"},
+		{name: "same ip without address", rules: onlyRules(sameIP), others: 5, ip: ""},
+
+		{name: "new account", rules: onlyRules(newAccount), user: func(u *domain.User) { u.CreatedAt = testNow.Add(-5 * time.Minute) }, flagged: true},
+		{name: "account at age limit", rules: onlyRules(newAccount), user: func(u *domain.User) { u.CreatedAt = testNow.Add(-10 * time.Minute) }},
+
+		{name: "foreign phone", rules: onlyRules(foreign), user: func(u *domain.User) { u.Phone = "+4915112345678" }, flagged: true},
+		{name: "expected second prefix", rules: onlyRules(foreign), user: func(u *domain.User) { u.Phone = "+375291234567" }},
+		{name: "expected phone without plus", rules: onlyRules(foreign), user: func(u *domain.User) { u.Phone = "79001234567" }},
+
+		{name: "no username", rules: onlyRules(noUsername), user: func(u *domain.User) { u.Username = "" }, flagged: true},
+		{name: "no premium", rules: onlyRules(noPremium), user: func(u *domain.User) { u.IsPremium = false }, flagged: true},
+
+		{name: "zero weight disables signal", rules: Rules{FlagScore: 10, NewAccountMinutes: 10},
+			user: func(u *domain.User) { u.CreatedAt = testNow }},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			user := cleanUser()
+			if tc.user != nil {
+				tc.user(user)
+			}
+			a, err := newTestScorer(t, tc.rules, &fakeIPStore{others: tc.others}).Assess(context.Background(), user, tc.ip, testNow)
+			if err != nil {
+				t.Fatalf("Assess: %v", err)
+			}
+			if a.Flagged() != tc.flagged {
+				t.Fatalf("Flagged = %v, want %v (assessment %+v)", a.Flagged(), tc.flagged, a)
+			}
+			if tc.flagged && (a.Score != 10 || len(a.Reasons) != 1 || a.Action != ActionFlag) {
+				t.Fatalf("assessment = %+v, want one reason with score 10", a)
+			}
+			if !tc.flagged && (a.Score != 0 || len(a.Reasons) != 0 || a.Action != ActionAllow) {
+				t.Fatalf("assessment = %+v, want clean", a)
+			}
+		})
+	}
+}
+
+// TestAssessSameIPQuery: хранилище получает хэши адреса по всем ключам окна и начало окна.
+func TestAssessSameIPQuery(t *testing.T) {
+	store := &fakeIPStore{}
+	rules := onlyRules(func(r *Rules) { r.SameIPUsers, r.SameIPWindowHours, r.SameIPScore = 1, 48, 10 })
+	s := newTestScorer(t, rules, store)
+	if _, err := s.Assess(context.Background(), cleanUser(), "203.0.113.7:443", testNow); err != nil {
+		t.Fatalf("Assess: %v", err)
+	}
+	if store.calls != 1 {
+		t.Fatalf("store calls = %d, want 1", store.calls)
+	}
+	if want := testNow.Add(-48 * time.Hour); !store.since.Equal(want) {
+		t.Fatalf("since = %v, want %v", store.since, want)
+	}
+	// Окно 48 ч при суточной ротации захватывает три ключа
+	if want := s.hasher.Candidates("203.0.113.7", testNow.Add(-48*time.Hour), testNow); len(store.ipHashes) != 3 || strings.Join(store.ipHashes, ",") != strings.Join(want, ",") {
+		t.Fatalf("ipHashes = %v, want %v", store.ipHashes, want)
+	}
+}
+
+// TestAssessStoreError: сбой хранилища пропускает сигнал по IP, но остальные сигналы считаются.
+func TestAssessStoreError(t *testing.T) {
+	rules := Rules{FlagScore: 10, SameIPUsers: 1, SameIPWindowHours: 24, SameIPScore: 50, NoUsernameScore: 10}
+	user := cleanUser()
+	user.Username = ""
+	a, err := newTestScorer(t, rules, &fakeIPStore{others: 10, err: errors.New("db down")}).Assess(context.Background(), user, "203.0.113.7", testNow)
+	if err == nil {
+		t.Fatal("store error was not returned")
+	}
+	if a.Score != 10 || a.Action != ActionFlag {
+		t.Fatalf("assessment = %+v, want only the username signal", a)
+	}
+}
+
+// TestAssessActions: сигналы суммируются, BlockScore отклоняет спин, нулевой — только помечает.
+func TestAssessActions(t *testing.T) {
+	user := cleanUser()
+	user.Username = ""
+	user.IsPremium = false
+	user.CreatedAt = testNow
+
+	cases := []struct {
+		name       string
+		blockScore int
+		want       Action
+	}{
+		{name: "flag only", blockScore: 0, want: ActionFlag},
+		{name: "block", blockScore: 30, want: ActionBlock},
+		{name: "block threshold not reached", blockScore: 31, want: ActionFlag},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rules := Rules{FlagScore: 20, BlockScore: tc.blockScore, NewAccountMinutes: 10, NewAccountScore: 10, NoUsernameScore: 10, NoPremiumScore: 10}
+			a, err := newTestScorer(t, rules, &fakeIPStore{}).Assess(context.Background(), user, "", testNow)
+			if err != nil {
+				t.Fatalf("Assess: %v", err)
+			}
+			if a.Score != 30 || len(a.Reasons) != 3 || a.Action != tc.want {
+				t.Fatalf("assessment = %+v, want score 30 and %s", a, tc.want)
+			}
+		})
+	}
+}
+
+// TestParseRules: поля накладываются на умолчания, ошибки конфигурации отклоняются.
+func TestParseRules(t *testing.T) {
+	r, err := ParseRules("")
+	if err != nil {
+		t.Fatalf("empty: %v", err)
+	}
+	if r.FlagScore != DefaultRules().FlagScore || len(r.PhonePrefixes) != 1 {
+		t.Fatalf("empty rules = %+v, want defaults", r)
+	}
+
+	r, err = ParseRules(`{"same_ip_users":5,"phone_prefixes":["7","375"],"no_premium_score":0,"block_score":80}`)
+	if err != nil {
+		t.Fatalf("override: %v", err)
+	}
+	if r.SameIPUsers != 5 || len(r.PhonePrefixes) != 2 || r.NoPremiumScore != 0 || r.BlockScore != 80 {
+		t.Fatalf("override rules = %+v", r)
+	}
+	if r.SameIPScore != DefaultRules().SameIPScore || r.NoUsernameScore != DefaultRules().NoUsernameScore {
+		t.Fatalf("unset fields lost defaults: %+v", r)
+	}
+
+	malformed := []struct {
+		name string
+		json string
+	}{
+		{name: "not json", json: `same_ip_users=5`},
+		{name: "truncated", json: `{"same_ip_users":5`},
+		{name: "unknown field", json: `{"same_ip_user":5}`},
+		{name: "wrong type", json: `{"same_ip_users":"5"}`},
+		{name: "prefixes not a list", json: `{"phone_prefixes":"7"}`},
+		{name: "zero flag score", json: `{"flag_score":0}`},
+		{name: "block below flag", json: `{"flag_score":40,"block_score":30}`},
+		{name: "same ip without users", json: `{"same_ip_users":0}`},
+		{name: "same ip without window", json: `{"same_ip_window_hours":0}`},
+	}
+	for _, tc := range malformed {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ParseRules(tc.json); err == nil || !strings.HasPrefix(err.Error(), "FRAUD_RULES") {
+				t.Fatalf("ParseRules(%s) err = %v, want FRAUD_RULES error", tc.json, err)
+			}
+		})
+	}
+
+	// Без сигнала по IP его пороги не нужны
+	if _, err := ParseRules(`{"same_ip_score":0,"same_ip_users":0}`); err != nil {
+		t.Fatalf("disabled same ip: %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package health
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"era_sporta_bot_ruletka/internal/db"
+	"era_sporta_bot_ruletka/internal/telegram"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DBCheck пингует PostgreSQL.
+func DBCheck(pool *pgxpool.Pool) Check {
+	return func(ctx context.Context) (string, error) {
+		return "", pool.Ping(ctx)
+	}
+}
+
+// MigrationCheck сверяет применённую версию схемы с ожидаемой кодом.
+func MigrationCheck(pool *pgxpool.Pool) Check {
+	return func(ctx context.Context) (string, error) {
+		version, err := db.SchemaVersion(ctx, pool)
+		if err != nil {
+			return "", err
+		}
+		if version < 0 {
+			// Схема применена через scripts/init_db_all.sql — версии нет, проверяем объекты последних миграций
+			missing, err := db.MissingSchemaObjects(ctx, pool)
+			if err != nil {
+				return "unknown", err
+			}
+			if len(missing) > 0 {
+				return "unknown", fmt.Errorf("schema version unknown and %s missing, required version %d",
+					strings.Join(missing, ", "), db.ExpectedSchemaVersion)
+			}
+			return "script", nil
+		}
+		detail := strconv.FormatInt(version, 10)
+		if version < db.ExpectedSchemaVersion {
+			return detail, fmt.Errorf("schema version %d is older than required %d", version, db.ExpectedSchemaVersion)
+		}
+		return detail, nil
+	}
+}
+
+// TelegramCheck проверяет токен бота через закешированный getMe.
+func TelegramCheck(me *telegram.MeChecker) Check {
+	return func(ctx context.Context) (string, error) {
+		username, err := me.Check(ctx)
+		if err != nil {
+			return "", err
+		}
+		return "@" + username, nil
+	}
+}
+
+// RedisCheck отправляет PING на Redis из REDIS_URL (redis://[:password@]host:port/db).
+func RedisCheck(redisURL string) Check {
+	return func(ctx context.Context) (string, error) {
+		u, err := url.Parse(redisURL)
+		if err != nil {
+			return "", fmt.Errorf("parse redis url: %w", err)
+		}
+		addr := u.Host
+		if u.Port() == "" {
+			addr = net.JoinHostPort(u.Hostname(), "6379")
+		}
+
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+		if deadline, ok := ctx.Deadline(); ok {
+			_ = conn.SetDeadline(deadline)
+		}
+
+		r := bufio.NewReader(conn)
+		if password, ok := u.User.Password(); ok {
+			if err := redisCommand(conn, r, "AUTH", password); err != nil {
+				return "", err
+			}
+		}
+		if err := redisCommand(conn, r, "PING"); err != nil {
+			return "", err
+		}
+		return "", nil
+	}
+}
+
+func redisCommand(conn net.Conn, r *bufio.Reader, args ...string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if _, err := conn.Write([]byte(b.String())); err != nil {
+		return err
+	}
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(line, "-") {
+		return errors.New(strings.TrimSpace(line[1:]))
+	}
+	return nil
+}
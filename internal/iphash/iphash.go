@@ -0,0 +1,112 @@
+// Package iphash хэширует IP-адреса клиентов для хранения: HMAC-SHA256 с секретом из конфига
+// и ключом, который меняется каждые rotation. Номер ключа (версия) хранится рядом с хэшем:
+// за пределами одного периода хэши одного адреса не связываются, а без секрета адрес
+// не восстановить перебором.
+package iphash
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// IPv6PrefixBits — IPv6-адреса группируются по /64: провайдеры выдают абоненту целую подсеть,
+// и адреса внутри неё меняются (privacy extensions), поэтому хэшировать полный адрес бессмысленно.
+const IPv6PrefixBits = 64
+
+// MinRotation — минимальный период смены ключа: IP_HASH_ROTATION_DAYS задаётся в сутках.
+const MinRotation = 24 * time.Hour
+
+// Hasher вычисляет хэши адресов по ключам, выведенным из секрета.
+type Hasher struct {
+	secret   []byte
+	rotation time.Duration
+}
+
+// New создаёт хэшер. Если secret пуст, он выводится из токена бота, как ключ сессий.
+// rotation — период смены ключа (IP_HASH_ROTATION_DAYS), не меньше суток.
+func New(secret, botToken string, rotation time.Duration) (*Hasher, error) {
+	if rotation < MinRotation {
+		return nil, errors.New("IP_HASH_ROTATION_DAYS must be at least 1")
+	}
+	key := []byte(secret)
+	if len(key) == 0 {
+		mac := hmac.New(sha256.New, []byte("IPHashKey"))
+		mac.Write([]byte(botToken))
+		key = mac.Sum(nil)
+	}
+	return &Hasher{secret: key, rotation: rotation}, nil
+}
+
+// Hash возвращает хэш адреса ip и версию ключа на момент at. Для пустого
+// или некорректного адреса — пустая строка и 0.
+func (h *Hasher) Hash(ip string, at time.Time) (string, int) {
+	normalized, ok := Normalize(ip)
+	if !ok {
+		return "", 0
+	}
+	version := h.Version(at)
+	return h.sum(normalized, version), version
+}
+
+// Candidates возвращает хэши адреса ip по всем ключам, действовавшим с since по until, —
+// чтобы искать тот же адрес за окно, через которое прошла смена ключа.
+func (h *Hasher) Candidates(ip string, since, until time.Time) []string {
+	normalized, ok := Normalize(ip)
+	if !ok {
+		return nil
+	}
+	var out []string
+	for v := h.Version(since); v <= h.Version(until); v++ {
+		out = append(out, h.sum(normalized, v))
+	}
+	return out
+}
+
+// Version — номер ключа, действующего в момент at: номер периода rotation с начала эпохи Unix.
+// Версия 0 зарезервирована за хэшами, посчитанными до введения ключей.
+func (h *Hasher) Version(at time.Time) int {
+	return int(at.Unix()/int64(h.rotation/time.Second)) + 1
+}
+
+func (h *Hasher) sum(normalized string, version int) string {
+	var v [8]byte
+	binary.BigEndian.PutUint64(v[:], uint64(version))
+	keyMac := hmac.New(sha256.New, h.secret)
+	keyMac.Write(v[:])
+	mac := hmac.New(sha256.New, keyMac.Sum(nil))
+	mac.Write([]byte(normalized))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Normalize приводит адрес к виду, который хэшируется: убирает порт и зону, IPv4-mapped IPv6
+// переводит в IPv4, IPv6 заменяет его подсетью /64 ("2001:db8:1:2::/64").
+func Normalize(ip string) (string, bool) {
+	ip = strings.TrimSpace(ip)
+	if ip == "" {
+		return "", false
+	}
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	ip = strings.TrimSuffix(strings.TrimPrefix(ip, "["), "]")
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return "", false
+	}
+	addr = addr.WithZone("").Unmap()
+	if addr.Is4() {
+		return addr.String(), true
+	}
+	prefix, err := addr.Prefix(IPv6PrefixBits)
+	if err != nil {
+		return "", false
+	}
+	return prefix.String(), true
+}
@@ -0,0 +1,148 @@
+package iphash
+
+import (
+	"testing"
+	"time"
+)
+
+const day = 24 * time.Hour
+
+// boundary — начало периода ключа при суточной ротации (периоды считаются от эпохи Unix в UTC).
+var boundary = time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func newTestHasher(t *testing.T) *Hasher {
+	t.Helper()
+	h, err := New("test-ip-secret", "", day)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return h
+}
+
+// TestNormalize: порт, скобки и зона убираются, IPv4-mapped становится IPv4, IPv6 — подсетью /64.
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{in: "203.0.113.7", want: "203.0.113.7", ok: true},
+		{in: " 203.0.113.7 ", want: "203.0.113.7", ok: true},
+		{in: "203.0.113.7:54321", want: "203.0.113.7", ok: true},
+		{in: "2001:db8:1:2:aaaa:bbbb:cccc:dddd", want: "2001:db8:1:2::/64", ok: true},
+		{in: "[2001:db8:1:2::1]:443", want: "2001:db8:1:2::/64", ok: true},
+		{in: "[2001:db8:1:2::1]", want: "2001:db8:1:2::/64", ok: true},
+		{in: "fe80::1%eth0", want: "fe80::/64", ok: true},
+		{in: "::ffff:203.0.113.7", want: "203.0.113.7", ok: true},
+		{in: "[::ffff:203.0.113.7]:8080", want: "203.0.113.7", ok: true},
+		{in: ""},
+		{in: "not-an-ip"},
+		{in: "203.0.113.300"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.in, func(t *testing.T) {
+			got, ok := Normalize(tc.in)
+			if got != tc.want || ok != tc.ok {
+				t.Fatalf("Normalize(%q) = %q, %v, want %q, %v", tc.in, got, ok, tc.want, tc.ok)
+			}
+		})
+	}
+}
+
+// TestHashGrouping: адреса одной подсети /64 и один адрес в разных записях дают один хэш.
+func TestHashGrouping(t *testing.T) {
+	h := newTestHasher(t)
+	at := boundary.Add(time.Hour)
+
+	same := [][2]string{
+		{"2001:db8:1:2::1", "[2001:db8:1:2:ffff:ffff:ffff:ffff]:443"},
+		{"203.0.113.7", "::ffff:203.0.113.7"},
+		{"203.0.113.7:1", "203.0.113.7:2"},
+	}
+	for _, pair := range same {
+		a, _ := h.Hash(pair[0], at)
+		b, _ := h.Hash(pair[1], at)
+		if a == "" || a != b {
+			t.Fatalf("Hash(%q) = %q, Hash(%q) = %q, want equal", pair[0], a, pair[1], b)
+		}
+	}
+
+	a, _ := h.Hash("2001:db8:1:2::1", at)
+	b, _ := h.Hash("2001:db8:1:3::1", at)
+	if a == b {
+		t.Fatal("different /64 subnets share a hash")
+	}
+
+	if hash, version := h.Hash("garbage", at); hash != "" || version != 0 {
+		t.Fatalf("Hash(garbage) = %q, %d, want empty", hash, version)
+	}
+}
+
+// TestVersionRotation: версия меняется ровно на границе периода, и хэш вместе с ней.
+func TestVersionRotation(t *testing.T) {
+	h := newTestHasher(t)
+	before := boundary.Add(-time.Nanosecond)
+
+	if got, want := h.Version(boundary), h.Version(before)+1; got != want {
+		t.Fatalf("Version(boundary) = %d, want %d", got, want)
+	}
+	if h.Version(boundary) != h.Version(boundary.Add(day-time.Second)) {
+		t.Fatal("version changed inside one period")
+	}
+	if want := int(boundary.Unix()/int64(day/time.Second)) + 1; h.Version(boundary) != want {
+		t.Fatalf("Version(boundary) = %d, want %d", h.Version(boundary), want)
+	}
+
+	oldHash, oldVersion := h.Hash("203.0.113.7", before)
+	newHash, newVersion := h.Hash("203.0.113.7", boundary)
+	if oldVersion == newVersion || oldHash == newHash {
+		t.Fatalf("hash did not rotate: %s/%d and %s/%d", oldHash, oldVersion, newHash, newVersion)
+	}
+}
+
+// TestCandidates: окно через границу периода содержит хэши обоих ключей, внутри периода — один.
+func TestCandidates(t *testing.T) {
+	h := newTestHasher(t)
+	oldHash, _ := h.Hash("203.0.113.7", boundary.Add(-time.Hour))
+	newHash, _ := h.Hash("203.0.113.7", boundary.Add(time.Hour))
+
+	got := h.Candidates("203.0.113.7:443", boundary.Add(-time.Hour), boundary.Add(time.Hour))
+	if len(got) != 2 || got[0] != oldHash || got[1] != newHash {
+		t.Fatalf("Candidates across boundary = %v, want [%s %s]", got, oldHash, newHash)
+	}
+
+	got = h.Candidates("203.0.113.7", boundary, boundary.Add(day-time.Second))
+	if len(got) != 1 || got[0] != newHash {
+		t.Fatalf("Candidates within period = %v, want [%s]", got, newHash)
+	}
+
+	if got := h.Candidates("3 дня", boundary.Add(-3*day), boundary); got != nil {
+		t.Fatalf("Candidates(invalid ip) = %v, want nil", got)
+	}
+}
+
+// TestNewKeys: период короче суток отклоняется; пустой секрет выводится из токена бота.
+func TestNewKeys(t *testing.T) {
+	if _, err := New("secret", "", day-time.Second); err == nil {
+		t.Fatal("rotation shorter than a day accepted")
+	}
+
+	at := boundary.Add(time.Hour)
+	hashFor := func(secret, botToken string) string {
+		h, err := New(secret, botToken, day)
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		hash, _ := h.Hash("203.0.113.7", at)
+		return hash
+	}
+	if hashFor("", "123456:bot-a") != hashFor("", "123456:bot-a") {
+		t.Fatal("derived key is not stable")
+	}
+	if hashFor("", "123456:bot-a") == hashFor("", "123456:bot-b") {
+		t.Fatal("different bot tokens share a key")
+	}
+	if hashFor("secret-a", "123456:bot-a") == hashFor("secret-b", "123456:bot-a") {
+		t.Fatal("different secrets share a key")
+	}
+}
@@ -0,0 +1,102 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Стандартные имена полей, общие для API и бота.
+const (
+	KeyRequestID      = "request_id"
+	KeyTelegramUserID = "telegram_user_id"
+	KeyUserID         = "user_id"
+	KeySpinID         = "spin_id"
+	KeyUpdateID       = "update_id"
+	KeyComponent      = "component"
+)
+
+// New создаёт логгер, который дописывает к записи поля из context.Context.
+// format: "json" (по умолчанию) или "text"; level: debug, info, warn, error.
+func New(w io.Writer, level, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: parseLevel(level)}
+	var h slog.Handler
+	if strings.EqualFold(format, "text") {
+		h = slog.NewTextHandler(w, opts)
+	} else {
+		h = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(&contextHandler{Handler: h})
+}
+
+// Setup создаёт логгер на stdout и делает его логгером по умолчанию (slog и log).
+func Setup(level, format string) *slog.Logger {
+	l := New(os.Stdout, level, format)
+	slog.SetDefault(l)
+	return l
+}
+
+func parseLevel(level string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+type ctxAttrsKey struct{}
+
+// WithAttrs возвращает контекст, все записи лога с которым получат attrs.
+func WithAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	if len(attrs) == 0 {
+		return ctx
+	}
+	prev, _ := ctx.Value(ctxAttrsKey{}).([]slog.Attr)
+	merged := make([]slog.Attr, 0, len(prev)+len(attrs))
+	merged = append(merged, prev...)
+	merged = append(merged, attrs...)
+	return context.WithValue(ctx, ctxAttrsKey{}, merged)
+}
+
+type requestIDKey struct{}
+
+// WithRequestID сохраняет request ID в контексте и добавляет его в поля лога.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	ctx = context.WithValue(ctx, requestIDKey{}, id)
+	return WithAttrs(ctx, slog.String(KeyRequestID, id))
+}
+
+// RequestID возвращает request ID из контекста или пустую строку.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler дописывает поля из контекста к каждой записи.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if attrs, ok := ctx.Value(ctxAttrsKey{}).([]slog.Attr); ok {
+			r.AddAttrs(attrs...)
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,145 @@
+package metrics
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "era_sporta"
+
+// Spin outcomes used as the "outcome" label of SpinsTotal.
+const (
+	SpinOutcomeSuccess       = "success"
+	SpinOutcomeLimitExceeded = "limit_exceeded"
+	SpinOutcomeRejected      = "rejected"
+	SpinOutcomeRateLimited   = "rate_limited"
+	SpinOutcomeError         = "error"
+)
+
+var (
+	// HTTPRequestDuration — латентность HTTP-запросов по маршрутам Gin.
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// SpinsTotal — количество спинов по призу и результату.
+	SpinsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "roulette",
+		Name:      "spins_total",
+		Help:      "Roulette spins by prize and outcome.",
+	}, []string{"prize", "outcome"})
+
+	// InitDataValidationFailures — отклонённые initData по причине.
+	InitDataValidationFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "auth",
+		Name:      "init_data_validation_failures_total",
+		Help:      "Rejected Telegram initData by reason.",
+	}, []string{"reason"})
+
+	// NotifierDeliveries — доставка уведомлений в Telegram.
+	NotifierDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "notifier",
+		Name:      "deliveries_total",
+		Help:      "Notification deliveries by result.",
+	}, []string{"result"})
+
+	// BroadcastDeliveries — доставка сообщений рассылок по результату (sent, failed, blocked).
+	BroadcastDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "broadcast",
+		Name:      "deliveries_total",
+		Help:      "Broadcast message deliveries by result.",
+	}, []string{"result"})
+
+	// VoucherReminders — напоминания о сгорающих ваучерах по результату (sent, failed, blocked).
+	VoucherReminders = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "reminder",
+		Name:      "deliveries_total",
+		Help:      "Voucher expiry reminder deliveries by result.",
+	}, []string{"result"})
+
+	// OutboxDeliveries — доставка сообщений из очереди уведомлений по адресату (admin, user)
+	// и результату (sent, retry, failed, blocked).
+	OutboxDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "deliveries_total",
+		Help:      "Notification outbox deliveries by kind and result.",
+	}, []string{"kind", "result"})
+
+	// WebhookDeliveries — исходящие webhook по событию и результату (delivered, retry, failed).
+	WebhookDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "webhook",
+		Name:      "deliveries_total",
+		Help:      "Outgoing webhook deliveries by event and result.",
+	}, []string{"event", "result"})
+
+	// FraudAssessments — оценки риска спинов по решению (allow, flag, block).
+	FraudAssessments = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "fraud",
+		Name:      "assessments_total",
+		Help:      "Spin fraud assessments by action.",
+	}, []string{"action"})
+
+	// BotUpdateDuration — время обработки update ботом.
+	BotUpdateDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "bot",
+		Name:      "update_duration_seconds",
+		Help:      "Time spent processing a Telegram update.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"type"})
+)
+
+// ObserveSpin увеличивает счётчик спинов. Для неуспешных спинов prize пустой.
+func ObserveSpin(prize, outcome string) {
+	SpinsTotal.WithLabelValues(prize, outcome).Inc()
+}
+
+// ObserveInitDataFailure увеличивает счётчик отклонённых initData.
+func ObserveInitDataFailure(reason string) {
+	InitDataValidationFailures.WithLabelValues(reason).Inc()
+}
+
+// ObserveNotifierDelivery фиксирует результат доставки уведомления.
+func ObserveNotifierDelivery(ok bool) {
+	result := "success"
+	if !ok {
+		result = "failure"
+	}
+	NotifierDeliveries.WithLabelValues(result).Inc()
+}
+
+// Handler возвращает HTTP-обработчик /metrics.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Serve поднимает служебный HTTP-сервер с /metrics на addr — отдельно от публичного порта API,
+// чтобы метрики не были видны снаружи. Останавливается через Shutdown возвращённого сервера.
+func Serve(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	srv := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		slog.Info("metrics listening", "addr", addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("metrics server failed", "error", err)
+		}
+	}()
+	return srv
+}
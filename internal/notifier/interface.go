@@ -8,5 +8,15 @@ import (
 
 // AdminNotifier notifies admin about spin results
 type AdminNotifier interface {
-	NotifySpin(ctx context.Context, user *domain.User, prizeName string)
+	NotifySpin(ctx context.Context, user *domain.User, spin *domain.SpinWithPrize)
+}
+
+// UserNotifier sends the spin result to the user's bot chat
+type UserNotifier interface {
+	NotifySpinResult(ctx context.Context, user *domain.User, spin *domain.SpinWithPrize)
+}
+
+// FraudNotifier warns managers about a suspicious spin. spin is nil when the spin was rejected.
+type FraudNotifier interface {
+	NotifyFraud(ctx context.Context, user *domain.User, spin *domain.SpinWithPrize, score int, reasons []string)
 }
@@ -0,0 +1,151 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"era_sporta_bot_ruletka/internal/domain"
+)
+
+// DefaultDestination — получатель уведомлений, для которых не подошло ни одно правило,
+// и служебных сообщений (сводки, итоги рассылок). По умолчанию это ADMIN_TELEGRAM_CHAT_ID.
+const DefaultDestination = "admin"
+
+// Destination — куда доставлять уведомления: чат Telegram (ChatID, опционально тема форума ThreadID)
+// или внешний webhook (Webhook — URL, на который уходит POST с JSON).
+type Destination struct {
+	Name     string `json:"-"`
+	ChatID   int64  `json:"chat_id"`
+	ThreadID int    `json:"thread_id"`
+	Webhook  string `json:"webhook"`
+}
+
+// IsWebhook сообщает, что уведомления уходят на webhook, а не в Telegram.
+func (d Destination) IsWebhook() bool {
+	return d.Webhook != ""
+}
+
+// Route — правило маршрутизации: уведомления события Event (с призом из PrizeTypes/PrizeIDs,
+// если они заданы) уходят получателям To.
+type Route struct {
+	Event      Event    `json:"event"`
+	PrizeTypes []string `json:"prize_types"`
+	PrizeIDs   []int    `json:"prize_ids"`
+	To         []string `json:"to"`
+}
+
+func (r Route) matches(e Event, prize *domain.Prize) bool {
+	if r.Event != e {
+		return false
+	}
+	if len(r.PrizeTypes) == 0 && len(r.PrizeIDs) == 0 {
+		return true
+	}
+	if prize == nil {
+		return false
+	}
+	for _, t := range r.PrizeTypes {
+		if strings.EqualFold(t, prize.Type) {
+			return true
+		}
+	}
+	for _, id := range r.PrizeIDs {
+		if id == prize.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// Router выбирает получателей уведомления по событию и призу.
+type Router struct {
+	destinations map[string]Destination
+	routes       []Route
+}
+
+// ParseRouter собирает маршрутизацию из настроек: adminChatID — получатель "admin" по умолчанию,
+// destinationsJSON — объект {"имя": {"chat_id": ..., "thread_id": ...} | {"webhook": "https://..."}}
+// (NOTIFY_DESTINATIONS), routesJSON — массив правил Route (NOTIFY_ROUTES). Пустые строки допустимы.
+func ParseRouter(adminChatID int64, destinationsJSON, routesJSON string) (*Router, error) {
+	r := &Router{destinations: make(map[string]Destination)}
+	if adminChatID != 0 {
+		r.destinations[DefaultDestination] = Destination{Name: DefaultDestination, ChatID: adminChatID}
+	}
+	if strings.TrimSpace(destinationsJSON) != "" {
+		var dests map[string]Destination
+		if err := json.Unmarshal([]byte(destinationsJSON), &dests); err != nil {
+			return nil, fmt.Errorf("NOTIFY_DESTINATIONS: %w", err)
+		}
+		for name, d := range dests {
+			if (d.ChatID == 0) == (d.Webhook == "") {
+				return nil, fmt.Errorf("NOTIFY_DESTINATIONS: %q needs exactly one of chat_id or webhook", name)
+			}
+			if d.Webhook != "" && !strings.HasPrefix(d.Webhook, "https://") && !strings.HasPrefix(d.Webhook, "http://") {
+				return nil, fmt.Errorf("NOTIFY_DESTINATIONS: %q webhook must be an http(s) URL", name)
+			}
+			d.Name = name
+			r.destinations[name] = d
+		}
+	}
+	if strings.TrimSpace(routesJSON) != "" {
+		if err := json.Unmarshal([]byte(routesJSON), &r.routes); err != nil {
+			return nil, fmt.Errorf("NOTIFY_ROUTES: %w", err)
+		}
+		for i, rt := range r.routes {
+			if _, ok := ParseEvent(string(rt.Event)); !ok {
+				return nil, fmt.Errorf("NOTIFY_ROUTES[%d]: unknown event %q", i, rt.Event)
+			}
+			if len(rt.To) == 0 {
+				return nil, fmt.Errorf("NOTIFY_ROUTES[%d]: empty \"to\"", i)
+			}
+			for _, name := range rt.To {
+				if _, ok := r.destinations[name]; !ok {
+					return nil, fmt.Errorf("NOTIFY_ROUTES[%d]: unknown destination %q", i, name)
+				}
+			}
+		}
+	}
+	return r, nil
+}
+
+// Resolve возвращает имена получателей события: объединение всех подошедших правил,
+// а если ни одно не подошло — получателя по умолчанию (если он настроен).
+func (r *Router) Resolve(e Event, prize *domain.Prize) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, rt := range r.routes {
+		if !rt.matches(e, prize) {
+			continue
+		}
+		for _, name := range rt.To {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		if _, ok := r.destinations[DefaultDestination]; ok {
+			names = append(names, DefaultDestination)
+		}
+	}
+	return names
+}
+
+// Destination возвращает получателя по имени.
+func (r *Router) Destination(name string) (Destination, bool) {
+	d, ok := r.destinations[name]
+	return d, ok
+}
+
+// TelegramChats возвращает id всех чатов Telegram среди получателей.
+func (r *Router) TelegramChats() []int64 {
+	var ids []int64
+	for _, d := range r.destinations {
+		if d.ChatID != 0 {
+			ids = append(ids, d.ChatID)
+		}
+	}
+	return ids
+}
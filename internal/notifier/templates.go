@@ -0,0 +1,157 @@
+package notifier
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+// Event — именованное событие, для которого есть шаблон уведомления.
+type Event string
+
+const (
+	EventNewUser    Event = "new_user"
+	EventSpin       Event = "spin"
+	EventRedemption Event = "redemption"
+	EventFraudAlert Event = "fraud_alert"
+)
+
+// Events — все события с шаблонами, в порядке показа менеджерам.
+var Events = []Event{EventNewUser, EventSpin, EventRedemption, EventFraudAlert}
+
+// ParseEvent возвращает событие и true, если s — известное событие.
+func ParseEvent(s string) (Event, bool) {
+	for _, e := range Events {
+		if string(e) == s {
+			return e, true
+		}
+	}
+	return "", false
+}
+
+// Шаблоны по умолчанию. Сообщения отправляются с parse mode HTML; строковые поля данных
+// уже экранированы, а UserLink — готовая HTML-ссылка на профиль.
+var defaultTemplates = map[Event]string{
+	EventNewUser: `Новый пользователь:
+Номер - {{.Phone}}
+Имя - {{.Name}}
+Id - {{.TelegramID}}`,
+
+	EventSpin: `🎰 Новый спин!
+👤 {{.UserLink}}
+📞 {{.Phone}}
+🎁 Что выиграл: {{.Prize}}
+{{if .Voucher}}🎟 Ваучер: <code>{{.Voucher}}</code>{{if .ExpiresAt}} (до {{.ExpiresAt}}){{end}}
+{{end}}📍 Источник: {{.Source}}
+🕒 Время: {{.Time}}
+Статус: {{.Status}}{{if .Blocked}}
+⛔ Пользователь заблокирован{{end}}`,
+
+	EventRedemption: `🎟 Ваучер <code>{{.Voucher}}</code> погашен
+👤 {{.UserLink}}, {{.Phone}}
+🎁 {{.Prize}}
+Отметил: {{.Admin}}
+🕒 {{.Time}}`,
+
+	EventFraudAlert: `🚨 {{if .Rejected}}Спин отклонён{{else}}Подозрительный спин{{end}} — риск {{.Score}}
+👤 {{.UserLink}}, {{.Phone}}
+{{if .Prize}}🎁 {{.Prize}}
+{{end}}{{range .Reasons}}• {{.}}
+{{end}}🕒 {{.Time}}`,
+}
+
+var parsedDefaults = func() map[Event]*template.Template {
+	m := make(map[Event]*template.Template, len(defaultTemplates))
+	for e, body := range defaultTemplates {
+		m[e] = template.Must(template.New(string(e)).Option("missingkey=error").Parse(body))
+	}
+	return m
+}()
+
+// DefaultTemplate возвращает текст шаблона события по умолчанию.
+func DefaultTemplate(e Event) string {
+	return defaultTemplates[e]
+}
+
+// Default возвращает разобранный шаблон события по умолчанию.
+func Default(e Event) *template.Template {
+	return parsedDefaults[e]
+}
+
+// NewUserData — данные шаблона new_user.
+type NewUserData struct {
+	Name       string
+	Username   string
+	Phone      string
+	TelegramID int64
+	UserLink   string
+	Time       string
+}
+
+// SpinData — данные шаблона spin.
+type SpinData struct {
+	Name       string
+	Username   string
+	Phone      string
+	TelegramID int64
+	UserLink   string
+	Prize      string
+	Voucher    string
+	ExpiresAt  string
+	Source     string
+	Status     string
+	Blocked    bool
+	Time       string
+}
+
+// RedemptionData — данные шаблона redemption.
+type RedemptionData struct {
+	Name     string
+	Phone    string
+	UserLink string
+	Prize    string
+	Voucher  string
+	Admin    string
+	Time     string
+}
+
+// FraudAlertData — данные шаблона fraud_alert. Rejected — спин отклонён, тогда Prize пустой.
+type FraudAlertData struct {
+	Name     string
+	Phone    string
+	UserLink string
+	Prize    string
+	Score    int
+	Reasons  []string
+	Rejected bool
+	Time     string
+}
+
+// sampleData — пример данных для проверки шаблона перед сохранением.
+var sampleData = map[Event]any{
+	EventNewUser: NewUserData{Name: "Иван Петров", Username: "ivan", Phone: "+79990000000", TelegramID: 1,
+		UserLink: `<a href="https://t.me/ivan">Иван Петров</a>`, Time: "01.01.2026 12:00"},
+	EventSpin: SpinData{Name: "Иван Петров", Username: "ivan", Phone: "+79990000000", TelegramID: 1,
+		UserLink: `<a href="https://t.me/ivan">Иван Петров</a>`, Prize: "Скидка 10%", Voucher: "ES-ABCD2345",
+		ExpiresAt: "31.01.2026", Source: "напрямую", Status: "выдан", Time: "01.01.2026 12:00"},
+	EventRedemption: RedemptionData{Name: "Иван Петров", Phone: "+79990000000", UserLink: `<a href="https://t.me/ivan">Иван Петров</a>`,
+		Prize: "Скидка 10%", Voucher: "ES-ABCD2345", Admin: "@manager", Time: "01.01.2026 12:00"},
+	EventFraudAlert: FraudAlertData{Name: "Иван Петров", Phone: "+79990000000", UserLink: `<a href="https://t.me/ivan">Иван Петров</a>`,
+		Prize: "Скидка 10%", Score: 80, Reasons: []string{"много аккаунтов с одного IP"}, Time: "01.01.2026 12:00"},
+}
+
+// ParseTemplate разбирает шаблон события и проверяет, что он выполняется на примере данных.
+func ParseTemplate(e Event, body string) (*template.Template, error) {
+	t, err := template.New(string(e)).Option("missingkey=error").Parse(body)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, sampleData[e]); err != nil {
+		return nil, err
+	}
+	if buf.Len() == 0 {
+		return nil, fmt.Errorf("template %s renders an empty message", e)
+	}
+	return t, nil
+}
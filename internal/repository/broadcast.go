@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"era_sporta_bot_ruletka/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type BroadcastRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewBroadcastRepository(pool *pgxpool.Pool) *BroadcastRepository {
+	return &BroadcastRepository{pool: pool}
+}
+
+const broadcastColumns = `
+	id, audience, COALESCE(prize_id, 0), text, COALESCE(photo_file_id, ''),
+	COALESCE(button_text, ''), COALESCE(button_url, ''), status, created_by,
+	total, sent, failed, blocked, created_at, started_at, finished_at`
+
+func scanBroadcast(row pgx.Row) (*domain.Broadcast, error) {
+	var b domain.Broadcast
+	err := row.Scan(
+		&b.ID, &b.Audience, &b.PrizeID, &b.Text, &b.PhotoFileID,
+		&b.ButtonText, &b.ButtonURL, &b.Status, &b.CreatedBy,
+		&b.Total, &b.Sent, &b.Failed, &b.Blocked, &b.CreatedAt, &b.StartedAt, &b.FinishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *BroadcastRepository) Create(ctx context.Context, b *domain.Broadcast) error {
+	return r.pool.QueryRow(ctx, `
+		INSERT INTO broadcasts (audience, prize_id, text, photo_file_id, button_text, button_url, status, created_by, total, created_at)
+		VALUES ($1, NULLIF($2, 0), $3, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), 'draft', $7, $8, NOW())
+		RETURNING id, status, created_at
+	`, string(b.Audience), b.PrizeID, b.Text, b.PhotoFileID, b.ButtonText, b.ButtonURL, b.CreatedBy, b.Total).
+		Scan(&b.ID, &b.Status, &b.CreatedAt)
+}
+
+func (r *BroadcastRepository) GetByID(ctx context.Context, id int64) (*domain.Broadcast, error) {
+	return scanBroadcast(r.pool.QueryRow(ctx, `SELECT `+broadcastColumns+` FROM broadcasts WHERE id = $1`, id))
+}
+
+// ListRecent возвращает последние рассылки, от новых к старым.
+func (r *BroadcastRepository) ListRecent(ctx context.Context, limit int) ([]*domain.Broadcast, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+broadcastColumns+` FROM broadcasts ORDER BY id DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*domain.Broadcast
+	for rows.Next() {
+		b, err := scanBroadcast(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, b)
+	}
+	return result, rows.Err()
+}
+
+// Start переводит черновик в running. Возвращает false, если рассылка уже не черновик.
+func (r *BroadcastRepository) Start(ctx context.Context, id int64, total int) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE broadcasts SET status = 'running', total = $2, started_at = NOW()
+		WHERE id = $1 AND status = 'draft'
+	`, id, total)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// Cancel отменяет черновик или идущую рассылку. Возвращает false, если она уже завершена.
+func (r *BroadcastRepository) Cancel(ctx context.Context, id int64) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE broadcasts SET status = 'cancelled', finished_at = NOW()
+		WHERE id = $1 AND status IN ('draft', 'running')
+	`, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// UpdateProgress сохраняет счётчики доставки.
+func (r *BroadcastRepository) UpdateProgress(ctx context.Context, b *domain.Broadcast) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE broadcasts SET sent = $2, failed = $3, blocked = $4 WHERE id = $1
+	`, b.ID, b.Sent, b.Failed, b.Blocked)
+	return err
+}
+
+// Finish сохраняет итоговые счётчики и статус, если рассылку не отменили.
+func (r *BroadcastRepository) Finish(ctx context.Context, b *domain.Broadcast) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE broadcasts SET sent = $2, failed = $3, blocked = $4, finished_at = NOW(),
+			status = CASE WHEN status = 'running' THEN $5 ELSE status END
+		WHERE id = $1
+	`, b.ID, b.Sent, b.Failed, b.Blocked, string(b.Status))
+	return err
+}
+
+// FailRunning помечает рассылки, прерванные перезапуском процесса.
+func (r *BroadcastRepository) FailRunning(ctx context.Context) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE broadcasts SET status = 'failed', finished_at = NOW() WHERE status = 'running'
+	`)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// Recipients возвращает получателей аудитории, не заблокировавших бота и не заблокированных менеджерами.
+func (r *BroadcastRepository) Recipients(ctx context.Context, audience domain.BroadcastAudience, prizeID int) ([]domain.Recipient, error) {
+	var cond string
+	var args []any
+	switch audience {
+	case domain.AudienceAll:
+	case domain.AudienceSpun:
+		cond = `AND EXISTS (SELECT 1 FROM spins s WHERE s.user_id = u.id)`
+	case domain.AudienceNotSpun:
+		cond = `AND NOT EXISTS (SELECT 1 FROM spins s WHERE s.user_id = u.id)`
+	case domain.AudiencePrize:
+		cond = `AND EXISTS (SELECT 1 FROM spins s WHERE s.user_id = u.id AND s.prize_id = $1)`
+		args = append(args, prizeID)
+	case domain.AudienceUnredeemed:
+		cond = `AND EXISTS (SELECT 1 FROM spins s WHERE s.user_id = u.id
+			AND s.status IN ('issued', 'claimed') AND (s.expires_at IS NULL OR s.expires_at > NOW()))`
+	default:
+		return nil, fmt.Errorf("unknown audience %q", audience)
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT u.id, u.telegram_user_id
+		FROM users u
+		WHERE u.bot_blocked_at IS NULL AND u.blocked_at IS NULL `+cond+`
+		ORDER BY u.id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []domain.Recipient
+	for rows.Next() {
+		var rc domain.Recipient
+		if err := rows.Scan(&rc.UserID, &rc.TelegramUserID); err != nil {
+			return nil, err
+		}
+		result = append(result, rc)
+	}
+	return result, rows.Err()
+}
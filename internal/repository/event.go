@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+
+	"era_sporta_bot_ruletka/internal/domain"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type EventRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewEventRepository(pool *pgxpool.Pool) *EventRepository {
+	return &EventRepository{pool: pool}
+}
+
+func (r *EventRepository) Record(ctx context.Context, telegramUserID int64, event domain.FunnelEvent, startParam string) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO funnel_events (telegram_user_id, event, start_param, created_at)
+		VALUES ($1, $2, NULLIF($3, ''), NOW())
+	`, telegramUserID, string(event), startParam)
+	return err
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"era_sporta_bot_ruletka/internal/domain"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ExportRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewExportRepository(pool *pgxpool.Pool) *ExportRepository {
+	return &ExportRepository{pool: pool}
+}
+
+// Rows возвращает пользователей со спинами (строка на спин) и без них (одна строка), от новых к старым.
+// Кампания спина приоритетнее кампании пользователя.
+func (r *ExportRepository) Rows(ctx context.Context, f domain.ExportFilter) ([]*domain.ExportRow, error) {
+	var conds []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if !f.From.IsZero() {
+		add("COALESCE(s.created_at, u.created_at) >= $%d", f.From)
+	}
+	if !f.To.IsZero() {
+		add("COALESCE(s.created_at, u.created_at) < $%d", f.To)
+	}
+	if f.Campaign != "" {
+		add("COALESCE(s.campaign, u.campaign) = $%d", f.Campaign)
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = "WHERE " + strings.Join(conds, " AND ")
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT u.id, u.telegram_user_id, COALESCE(u.first_name, ''), COALESCE(u.last_name, ''),
+		       COALESCE(u.username, ''), u.phone, u.created_at,
+		       COALESCE(s.id, 0), COALESCE(p.name, ''), s.created_at,
+		       COALESCE(`+spinStatusExpr+`, ''), COALESCE(s.voucher_code, ''), s.redeemed_at,
+		       COALESCE(s.start_param, u.start_param, ''), COALESCE(s.campaign, u.campaign, '')
+		FROM users u
+		LEFT JOIN spins s ON s.user_id = u.id
+		LEFT JOIN prizes p ON p.id = s.prize_id
+		`+where+`
+		ORDER BY COALESCE(s.created_at, u.created_at) DESC, s.id DESC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*domain.ExportRow
+	for rows.Next() {
+		var e domain.ExportRow
+		err := rows.Scan(
+			&e.UserID, &e.TelegramUserID, &e.FirstName, &e.LastName,
+			&e.Username, &e.Phone, &e.RegisteredAt,
+			&e.SpinID, &e.Prize, &e.SpinAt,
+			&e.Status, &e.VoucherCode, &e.RedeemedAt,
+			&e.StartParam, &e.Campaign,
+		)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, &e)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"era_sporta_bot_ruletka/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// phoneDigits — номер пользователя в виде ключа phone_bans для SQL: только цифры, 8XXXXXXXXXX
+// как 7XXXXXXXXXX, как в domain.PhoneBanKey. Выражение совпадает с индексом idx_users_phone_ban_key.
+const phoneDigits = `regexp_replace(regexp_replace(phone, '\D', '', 'g'), '^8(\d{10})$', '7\1')`
+
+// ModerationRepository хранит блокировки пользователей и баны номеров.
+// Инвариант: все аккаунты с забаненным номером заблокированы.
+type ModerationRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewModerationRepository(pool *pgxpool.Pool) *ModerationRepository {
+	return &ModerationRepository{pool: pool}
+}
+
+// BlockUser блокирует пользователя, банит его номер и блокирует другие аккаунты с этим номером.
+// Уже заблокированному обновляются причина и автор. Возвращает pgx.ErrNoRows, если пользователя нет.
+func (r *ModerationRepository) BlockUser(ctx context.Context, telegramUserID int64, reason, actor string) (*domain.User, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	user, err := scanUser(tx.QueryRow(ctx, `
+		UPDATE users SET blocked_at = COALESCE(blocked_at, NOW()), block_reason = NULLIF($2, ''),
+			blocked_by = NULLIF($3, ''), updated_at = NOW()
+		WHERE telegram_user_id = $1
+		RETURNING `+userColumns, telegramUserID, reason, actor))
+	if err != nil {
+		return nil, err
+	}
+	if key := domain.PhoneBanKey(user.Phone); key != "" {
+		if err := banPhone(ctx, tx, key, reason, actor); err != nil {
+			return nil, err
+		}
+		if _, err := blockByPhone(ctx, tx, key, reason, actor); err != nil {
+			return nil, err
+		}
+	}
+	return user, tx.Commit(ctx)
+}
+
+// UnblockUser снимает блокировку с пользователя, бан с его номера и блокировку с других
+// аккаунтов с этим номером. Возвращает pgx.ErrNoRows, если пользователя нет.
+func (r *ModerationRepository) UnblockUser(ctx context.Context, telegramUserID int64) (*domain.User, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	user, err := scanUser(tx.QueryRow(ctx, `
+		UPDATE users SET blocked_at = NULL, block_reason = NULL, blocked_by = NULL, updated_at = NOW()
+		WHERE telegram_user_id = $1
+		RETURNING `+userColumns, telegramUserID))
+	if err != nil {
+		return nil, err
+	}
+	if key := domain.PhoneBanKey(user.Phone); key != "" {
+		if _, err := unbanPhone(ctx, tx, key); err != nil {
+			return nil, err
+		}
+	}
+	return user, tx.Commit(ctx)
+}
+
+// BanPhone банит номер (ключ domain.PhoneBanKey) и блокирует все аккаунты с ним.
+// Возвращает, сколько аккаунтов заблокировано.
+func (r *ModerationRepository) BanPhone(ctx context.Context, phone, reason, actor string) (int, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := banPhone(ctx, tx, phone, reason, actor); err != nil {
+		return 0, err
+	}
+	blocked, err := blockByPhone(ctx, tx, phone, reason, actor)
+	if err != nil {
+		return 0, err
+	}
+	return blocked, tx.Commit(ctx)
+}
+
+// UnbanPhone снимает бан с номера и блокировку со всех аккаунтов с ним.
+// Возвращает false, если номер не был забанен.
+func (r *ModerationRepository) UnbanPhone(ctx context.Context, phone string) (bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	found, err := unbanPhone(ctx, tx, phone)
+	if err != nil {
+		return false, err
+	}
+	return found, tx.Commit(ctx)
+}
+
+// ListBlockedUsers возвращает заблокированных пользователей, недавно заблокированных первыми.
+func (r *ModerationRepository) ListBlockedUsers(ctx context.Context, limit int) ([]*domain.User, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+userColumns+` FROM users
+		WHERE blocked_at IS NOT NULL
+		ORDER BY blocked_at DESC, id DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*domain.User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+// ListPhoneBans возвращает баны номеров, новые первыми.
+func (r *ModerationRepository) ListPhoneBans(ctx context.Context, limit int) ([]domain.PhoneBan, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT phone, COALESCE(reason, ''), COALESCE(banned_by, ''), created_at FROM phone_bans
+		ORDER BY created_at DESC, phone
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []domain.PhoneBan
+	for rows.Next() {
+		var b domain.PhoneBan
+		if err := rows.Scan(&b.Phone, &b.Reason, &b.BannedBy, &b.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+func banPhone(ctx context.Context, tx pgx.Tx, phone, reason, actor string) error {
+	if phone == "" {
+		return errors.New("empty phone ban key")
+	}
+	_, err := tx.Exec(ctx, `
+		INSERT INTO phone_bans (phone, reason, banned_by) VALUES ($1, NULLIF($2, ''), NULLIF($3, ''))
+		ON CONFLICT (phone) DO UPDATE SET reason = EXCLUDED.reason, banned_by = EXCLUDED.banned_by
+	`, phone, reason, actor)
+	if err != nil {
+		return fmt.Errorf("ban phone: %w", err)
+	}
+	return nil
+}
+
+func unbanPhone(ctx context.Context, tx pgx.Tx, phone string) (bool, error) {
+	tag, err := tx.Exec(ctx, `DELETE FROM phone_bans WHERE phone = $1`, phone)
+	if err != nil {
+		return false, fmt.Errorf("unban phone: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE users SET blocked_at = NULL, block_reason = NULL, blocked_by = NULL, updated_at = NOW()
+		WHERE `+phoneDigits+` = $1 AND blocked_at IS NOT NULL
+	`, phone); err != nil {
+		return false, fmt.Errorf("unblock users by phone: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func blockByPhone(ctx context.Context, tx pgx.Tx, phone, reason, actor string) (int, error) {
+	tag, err := tx.Exec(ctx, `
+		UPDATE users SET blocked_at = NOW(), block_reason = NULLIF($2, ''), blocked_by = NULLIF($3, ''), updated_at = NOW()
+		WHERE `+phoneDigits+` = $1 AND blocked_at IS NULL
+	`, phone, reason, actor)
+	if err != nil {
+		return 0, fmt.Errorf("block users by phone: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"era_sporta_bot_ruletka/internal/domain"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type OutboxRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewOutboxRepository(pool *pgxpool.Pool) *OutboxRepository {
+	return &OutboxRepository{pool: pool}
+}
+
+func (r *OutboxRepository) Enqueue(ctx context.Context, m *domain.OutboxMessage) error {
+	return r.pool.QueryRow(ctx, `
+		INSERT INTO notification_outbox (kind, chat_id, destination, event, text, parse_mode, reply_markup)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, NULLIF($6, ''), $7)
+		RETURNING id, created_at
+	`, string(m.Kind), m.ChatID, m.Destination, m.Event, m.Text, m.ParseMode, m.ReplyMarkup).Scan(&m.ID, &m.CreatedAt)
+}
+
+// Claim забирает до limit сообщений, готовых к отправке, и откладывает их на lease,
+// чтобы параллельный обработчик не взял те же сообщения. Счётчик попыток увеличивается сразу.
+func (r *OutboxRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxMessage, error) {
+	rows, err := r.pool.Query(ctx, `
+		UPDATE notification_outbox
+		SET attempts = attempts + 1, next_attempt_at = NOW() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id FROM notification_outbox
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, kind, chat_id, COALESCE(destination, ''), COALESCE(event, ''), text,
+			COALESCE(parse_mode, ''), reply_markup, attempts, created_at
+	`, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []domain.OutboxMessage
+	for rows.Next() {
+		var m domain.OutboxMessage
+		if err := rows.Scan(&m.ID, &m.Kind, &m.ChatID, &m.Destination, &m.Event, &m.Text, &m.ParseMode, &m.ReplyMarkup, &m.Attempts, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, m)
+	}
+	return result, rows.Err()
+}
+
+func (r *OutboxRepository) MarkSent(ctx context.Context, id int64) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE notification_outbox SET status = 'sent', sent_at = NOW(), last_error = NULL
+		WHERE id = $1
+	`, id)
+	return err
+}
+
+// Retry возвращает сообщение в очередь с отправкой не раньше at.
+func (r *OutboxRepository) Retry(ctx context.Context, id int64, at time.Time, lastError string) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE notification_outbox SET next_attempt_at = $2, last_error = $3
+		WHERE id = $1
+	`, id, at, lastError)
+	return err
+}
+
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id int64, lastError string) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE notification_outbox SET status = 'failed', last_error = $2
+		WHERE id = $1
+	`, id, lastError)
+	return err
+}
+
+// DeleteSentBefore удаляет доставленные сообщения старше before.
+func (r *OutboxRepository) DeleteSentBefore(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM notification_outbox WHERE status = 'sent' AND sent_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+
+	"era_sporta_bot_ruletka/internal/domain"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ReminderRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewReminderRepository(pool *pgxpool.Pool) *ReminderRepository {
+	return &ReminderRepository{pool: pool}
+}
+
+// Due возвращает непогашенные ваучеры, которые истекают в ближайшие daysBefore дней и
+// по которым ещё не отправляли напоминание с этим или более поздним порогом.
+// Пользователи, отказавшиеся от напоминаний, заблокировавшие бота или заблокированные менеджерами, пропускаются.
+func (r *ReminderRepository) Due(ctx context.Context, daysBefore, limit int) ([]domain.VoucherReminder, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT s.id, u.id, u.telegram_user_id, p.name, s.voucher_code, s.expires_at
+		FROM spins s
+		JOIN users u ON u.id = s.user_id
+		JOIN prizes p ON p.id = s.prize_id
+		WHERE s.status IN ('issued', 'claimed')
+			AND s.voucher_code IS NOT NULL
+			AND s.expires_at > NOW()
+			AND s.expires_at <= NOW() + make_interval(days => $1)
+			AND u.reminders_opt_out_at IS NULL
+			AND u.bot_blocked_at IS NULL
+			AND u.blocked_at IS NULL
+			AND NOT EXISTS (
+				SELECT 1 FROM voucher_reminders vr
+				WHERE vr.spin_id = s.id AND vr.days_before <= $1
+			)
+		ORDER BY s.expires_at
+		LIMIT $2
+	`, daysBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []domain.VoucherReminder
+	for rows.Next() {
+		rm := domain.VoucherReminder{DaysBefore: daysBefore}
+		if err := rows.Scan(&rm.SpinID, &rm.UserID, &rm.TelegramUserID, &rm.PrizeName, &rm.VoucherCode, &rm.ExpiresAt); err != nil {
+			return nil, err
+		}
+		result = append(result, rm)
+	}
+	return result, rows.Err()
+}
+
+// Record сохраняет факт отправки напоминания.
+func (r *ReminderRepository) Record(ctx context.Context, rm domain.VoucherReminder) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO voucher_reminders (spin_id, user_id, days_before)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (spin_id, days_before) DO NOTHING
+	`, rm.SpinID, rm.UserID, rm.DaysBefore)
+	return err
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SessionRepository хранит использованные refresh-токены Mini App.
+type SessionRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewSessionRepository(pool *pgxpool.Pool) *SessionRepository {
+	return &SessionRepository{pool: pool}
+}
+
+// UseRefreshToken помечает refresh-токен jti использованным. Возвращает false, если он уже
+// был использован. Заодно удаляет записи об истёкших токенах — их и так не примут.
+func (r *SessionRepository) UseRefreshToken(ctx context.Context, jti string, telegramUserID int64, expiresAt time.Time) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		WITH purge AS (
+			DELETE FROM refresh_token_uses WHERE expires_at < NOW()
+		)
+		INSERT INTO refresh_token_uses (jti, telegram_user_id, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (jti) DO NOTHING
+	`, jti, telegramUserID, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
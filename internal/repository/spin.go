@@ -2,6 +2,10 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"era_sporta_bot_ruletka/internal/domain"
 
@@ -18,10 +22,23 @@ func NewSpinRepository(pool *pgxpool.Pool) *SpinRepository {
 
 func (r *SpinRepository) Create(ctx context.Context, s *domain.Spin) error {
 	return r.pool.QueryRow(ctx, `
-		INSERT INTO spins (user_id, prize_id, result_value, ip_hash, created_at)
-		VALUES ($1, $2, $3, $4, NOW())
-		RETURNING id, created_at
-	`, s.UserID, s.PrizeID, s.ResultValue, s.IPHash).Scan(&s.ID, &s.CreatedAt)
+		INSERT INTO spins (user_id, prize_id, result_value, ip_hash, ip_hash_version, start_param, campaign, voucher_code,
+			expires_at, fraud_score, fraud_reasons, created_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''), $9, $10, $11, NOW())
+		RETURNING id, status, created_at
+	`, s.UserID, s.PrizeID, s.ResultValue, s.IPHash, s.IPHashVersion, s.StartParam, s.Campaign, s.VoucherCode,
+		s.ExpiresAt, s.FraudScore, s.FraudReasons).Scan(&s.ID, &s.Status, &s.CreatedAt)
+}
+
+// CountOtherUsersByIPHash считает других пользователей, крутивших рулетку с любым из ipHashes
+// (хэши одного адреса по разным ключам) начиная с since.
+func (r *SpinRepository) CountOtherUsersByIPHash(ctx context.Context, ipHashes []string, userID int64, since time.Time) (int, error) {
+	var count int
+	err := r.pool.QueryRow(ctx, `
+		SELECT COUNT(DISTINCT user_id) FROM spins
+		WHERE ip_hash = ANY($1) AND user_id <> $2 AND created_at >= $3
+	`, ipHashes, userID, since).Scan(&count)
+	return count, err
 }
 
 func (r *SpinRepository) CountByUserID(ctx context.Context, userID int64) (int, error) {
@@ -30,19 +47,52 @@ func (r *SpinRepository) CountByUserID(ctx context.Context, userID int64) (int,
 	return count, err
 }
 
-func (r *SpinRepository) ListByUserID(ctx context.Context, userID int64, limit int) ([]*domain.SpinWithPrize, error) {
+// spinStatusExpr — фактический статус спина: выданный или полученный ваучер
+// с истёкшим expires_at считается просроченным.
+const spinStatusExpr = `CASE WHEN s.status IN ('issued', 'claimed') AND s.expires_at <= NOW() THEN 'expired' ELSE s.status END`
+
+// spinFilterWhere собирает WHERE для истории спинов пользователя. Курсор учитывается, только если withCursor.
+func spinFilterWhere(userID int64, f domain.SpinFilter, withCursor bool) (string, []any) {
+	conds := []string{"s.user_id = $1"}
+	args := []any{userID}
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if f.Status != "" {
+		add(spinStatusExpr+" = $%d", string(f.Status))
+	}
+	if !f.From.IsZero() {
+		add("s.created_at >= $%d", f.From)
+	}
+	if !f.To.IsZero() {
+		add("s.created_at < $%d", f.To)
+	}
+	if withCursor && f.After != nil {
+		args = append(args, f.After.CreatedAt, f.After.ID)
+		conds = append(conds, fmt.Sprintf("(s.created_at, s.id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// ListByUserID возвращает страницу истории спинов пользователя, от новых к старым.
+func (r *SpinRepository) ListByUserID(ctx context.Context, userID int64, f domain.SpinFilter) ([]*domain.SpinWithPrize, error) {
+	limit := f.Limit
 	if limit <= 0 {
 		limit = 10
 	}
+	where, args := spinFilterWhere(userID, f, true)
+	args = append(args, limit)
 	rows, err := r.pool.Query(ctx, `
-		SELECT s.id, s.user_id, s.prize_id, s.result_value, s.ip_hash, s.created_at,
+		SELECT s.id, s.user_id, s.prize_id, s.result_value, COALESCE(s.ip_hash, ''),
+		       COALESCE(s.start_param, ''), COALESCE(s.campaign, ''),
+		       `+spinStatusExpr+`, COALESCE(s.voucher_code, ''), s.claimed_at, s.redeemed_at, s.expires_at, s.created_at,
 		       p.id, p.name, p.type, p.value, p.probability_weight, p.is_active, p.created_at
 		FROM spins s
 		JOIN prizes p ON p.id = s.prize_id
-		WHERE s.user_id = $1
-		ORDER BY s.created_at DESC
-		LIMIT $2
-	`, userID, limit)
+		WHERE `+where+`
+		ORDER BY s.created_at DESC, s.id DESC
+		LIMIT $`+strconv.Itoa(len(args)), args...)
 	if err != nil {
 		return nil, err
 	}
@@ -53,7 +103,9 @@ func (r *SpinRepository) ListByUserID(ctx context.Context, userID int64, limit i
 		var swp domain.SpinWithPrize
 		swp.Prize = &domain.Prize{}
 		err := rows.Scan(
-			&swp.ID, &swp.UserID, &swp.PrizeID, &swp.ResultValue, &swp.IPHash, &swp.CreatedAt,
+			&swp.ID, &swp.UserID, &swp.PrizeID, &swp.ResultValue, &swp.IPHash,
+			&swp.StartParam, &swp.Campaign,
+			&swp.Status, &swp.VoucherCode, &swp.ClaimedAt, &swp.RedeemedAt, &swp.ExpiresAt, &swp.CreatedAt,
 			&swp.Prize.ID, &swp.Prize.Name, &swp.Prize.Type, &swp.Prize.Value, &swp.Prize.ProbabilityWeight, &swp.Prize.IsActive, &swp.Prize.CreatedAt,
 		)
 		if err != nil {
@@ -63,3 +115,60 @@ func (r *SpinRepository) ListByUserID(ctx context.Context, userID int64, limit i
 	}
 	return result, rows.Err()
 }
+
+// CountByUserIDFiltered считает спины пользователя по фильтру без учёта курсора и лимита.
+func (r *SpinRepository) CountByUserIDFiltered(ctx context.Context, userID int64, f domain.SpinFilter) (int, error) {
+	where, args := spinFilterWhere(userID, f, false)
+	var count int
+	err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM spins s WHERE `+where, args...).Scan(&count)
+	return count, err
+}
+
+// GetByID возвращает спин с призом и фактическим статусом ваучера.
+func (r *SpinRepository) GetByID(ctx context.Context, id int64) (*domain.SpinWithPrize, error) {
+	var swp domain.SpinWithPrize
+	swp.Prize = &domain.Prize{}
+	err := r.pool.QueryRow(ctx, `
+		SELECT s.id, s.user_id, s.prize_id, s.result_value, COALESCE(s.ip_hash, ''),
+		       COALESCE(s.start_param, ''), COALESCE(s.campaign, ''),
+		       `+spinStatusExpr+`, COALESCE(s.voucher_code, ''), s.fraud_score, COALESCE(s.fraud_reasons, '{}'),
+		       s.claimed_at, s.redeemed_at, s.expires_at, s.created_at,
+		       p.id, p.name, p.type, p.value, p.probability_weight, p.is_active, p.created_at
+		FROM spins s
+		JOIN prizes p ON p.id = s.prize_id
+		WHERE s.id = $1
+	`, id).Scan(
+		&swp.ID, &swp.UserID, &swp.PrizeID, &swp.ResultValue, &swp.IPHash,
+		&swp.StartParam, &swp.Campaign,
+		&swp.Status, &swp.VoucherCode, &swp.FraudScore, &swp.FraudReasons, &swp.ClaimedAt, &swp.RedeemedAt, &swp.ExpiresAt, &swp.CreatedAt,
+		&swp.Prize.ID, &swp.Prize.Name, &swp.Prize.Type, &swp.Prize.Value, &swp.Prize.ProbabilityWeight, &swp.Prize.IsActive, &swp.Prize.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &swp, nil
+}
+
+// MarkClaimed переводит действующий ваучер из issued в claimed (менеджер связался с победителем).
+func (r *SpinRepository) MarkClaimed(ctx context.Context, id int64) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE spins SET status = 'claimed', claimed_at = NOW()
+		WHERE id = $1 AND status = 'issued' AND (expires_at IS NULL OR expires_at > NOW())
+	`, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// MarkRedeemed отмечает действующий ваучер погашенным.
+func (r *SpinRepository) MarkRedeemed(ctx context.Context, id int64) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE spins SET status = 'redeemed', redeemed_at = NOW(), claimed_at = COALESCE(claimed_at, NOW())
+		WHERE id = $1 AND status IN ('issued', 'claimed') AND (expires_at IS NULL OR expires_at > NOW())
+	`, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"era_sporta_bot_ruletka/internal/domain"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// StatsRepository считает агрегаты для админской аналитики по funnel_events, users и spins.
+type StatsRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewStatsRepository(pool *pgxpool.Pool) *StatsRepository {
+	return &StatsRepository{pool: pool}
+}
+
+// funnelStepsSQL — (telegram_user_id, step, created_at) для всех шагов воронки в [$1, $2).
+const funnelStepsSQL = `
+	SELECT telegram_user_id, event AS step, created_at
+	FROM funnel_events
+	WHERE event IN ('start', 'subscribed', 'app_opened') AND created_at >= $1 AND created_at < $2
+	UNION ALL
+	SELECT telegram_user_id, 'phone_shared', created_at
+	FROM users
+	WHERE created_at >= $1 AND created_at < $2
+	UNION ALL
+	SELECT u.telegram_user_id, 'spun', s.created_at
+	FROM spins s
+	JOIN users u ON u.id = s.user_id
+	WHERE s.created_at >= $1 AND s.created_at < $2`
+
+// FunnelTotals возвращает число уникальных пользователей на каждом шаге и всего за период.
+func (r *StatsRepository) FunnelTotals(ctx context.Context, from, to time.Time) (map[string]int, int, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT COALESCE(step, ''), COUNT(DISTINCT telegram_user_id)
+		FROM (`+funnelStepsSQL+`) f
+		GROUP BY GROUPING SETS ((step), ())
+	`, from, to)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	steps := make(map[string]int)
+	var unique int
+	for rows.Next() {
+		var step string
+		var n int
+		if err := rows.Scan(&step, &n); err != nil {
+			return nil, 0, err
+		}
+		if step == "" {
+			unique = n
+			continue
+		}
+		steps[step] = n
+	}
+	return steps, unique, rows.Err()
+}
+
+// Daily возвращает уникальных пользователей по шагам и число спинов по дням в часовом поясе tz.
+// Дни без событий не возвращаются.
+func (r *StatsRepository) Daily(ctx context.Context, from, to time.Time, tz string) ([]*domain.DailyStats, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT (created_at AT TIME ZONE $3)::date AS day, step,
+		       COUNT(DISTINCT telegram_user_id), COUNT(*)
+		FROM (`+funnelStepsSQL+`) f
+		GROUP BY day, step
+		ORDER BY day
+	`, from, to, tz)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*domain.DailyStats
+	for rows.Next() {
+		var day time.Time
+		var step string
+		var users, events int
+		if err := rows.Scan(&day, &step, &users, &events); err != nil {
+			return nil, err
+		}
+		if len(result) == 0 || !result[len(result)-1].Date.Equal(day) {
+			result = append(result, &domain.DailyStats{Date: day, Steps: make(map[string]int)})
+		}
+		d := result[len(result)-1]
+		d.Steps[step] = users
+		if step == domain.FunnelStepSpun {
+			d.Spins = events
+		}
+	}
+	return result, rows.Err()
+}
+
+// PrizeDistribution возвращает число выигрышей по каждому призу за период (включая призы без выигрышей).
+func (r *StatsRepository) PrizeDistribution(ctx context.Context, from, to time.Time) ([]*domain.PrizeStats, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT p.id, p.name, p.type, p.is_active, p.probability_weight, COUNT(s.id)
+		FROM prizes p
+		LEFT JOIN spins s ON s.prize_id = p.id AND s.created_at >= $1 AND s.created_at < $2
+		GROUP BY p.id
+		ORDER BY p.id
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*domain.PrizeStats
+	for rows.Next() {
+		var p domain.PrizeStats
+		if err := rows.Scan(&p.PrizeID, &p.Name, &p.Type, &p.IsActive, &p.Weight, &p.Spins); err != nil {
+			return nil, err
+		}
+		result = append(result, &p)
+	}
+	return result, rows.Err()
+}
+
+// Digest считает новых пользователей, спины по призам, погашенные ваучеры и блокировки бота за период.
+func (r *StatsRepository) Digest(ctx context.Context, from, to time.Time) (*domain.Digest, error) {
+	d := &domain.Digest{From: from, To: to}
+	err := r.pool.QueryRow(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM users WHERE created_at >= $1 AND created_at < $2),
+			(SELECT COUNT(*) FROM spins WHERE created_at >= $1 AND created_at < $2),
+			(SELECT COUNT(*) FROM spins WHERE redeemed_at >= $1 AND redeemed_at < $2),
+			(SELECT COUNT(DISTINCT telegram_user_id) FROM funnel_events
+			 WHERE event = 'bot_blocked' AND created_at >= $1 AND created_at < $2)
+	`, from, to).Scan(&d.NewUsers, &d.Spins, &d.Redemptions, &d.Unsubscribes)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT p.name, COUNT(*)
+		FROM spins s
+		JOIN prizes p ON p.id = s.prize_id
+		WHERE s.created_at >= $1 AND s.created_at < $2
+		GROUP BY p.name
+		ORDER BY COUNT(*) DESC, p.name
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var pc domain.PrizeCount
+		if err := rows.Scan(&pc.Name, &pc.Count); err != nil {
+			return nil, err
+		}
+		d.SpinsByPrize = append(d.SpinsByPrize, pc)
+	}
+	return d, rows.Err()
+}
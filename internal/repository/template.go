@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type TemplateRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewTemplateRepository(pool *pgxpool.Pool) *TemplateRepository {
+	return &TemplateRepository{pool: pool}
+}
+
+// List возвращает изменённые шаблоны: событие → текст шаблона.
+func (r *TemplateRepository) List(ctx context.Context) (map[string]string, error) {
+	rows, err := r.pool.Query(ctx, `SELECT event, body FROM notification_templates`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]string)
+	for rows.Next() {
+		var event, body string
+		if err := rows.Scan(&event, &body); err != nil {
+			return nil, err
+		}
+		result[event] = body
+	}
+	return result, rows.Err()
+}
+
+func (r *TemplateRepository) Set(ctx context.Context, event, body string, updatedBy int64) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO notification_templates (event, body, updated_by, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (event) DO UPDATE SET body = EXCLUDED.body, updated_by = EXCLUDED.updated_by, updated_at = NOW()
+	`, event, body, updatedBy)
+	return err
+}
+
+func (r *TemplateRepository) Delete(ctx context.Context, event string) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM notification_templates WHERE event = $1`, event)
+	return err
+}
@@ -5,6 +5,7 @@ import (
 
 	"era_sporta_bot_ruletka/internal/domain"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -16,13 +17,17 @@ func NewUserRepository(pool *pgxpool.Pool) *UserRepository {
 	return &UserRepository{pool: pool}
 }
 
-func (r *UserRepository) GetByTelegramID(ctx context.Context, telegramUserID int64) (*domain.User, error) {
+const userColumns = `
+	id, telegram_user_id, phone, first_name, last_name, username,
+	COALESCE(start_param, ''), COALESCE(campaign, ''), COALESCE(referrer_telegram_id, 0),
+	is_premium, blocked_at, COALESCE(block_reason, ''), COALESCE(blocked_by, ''), created_at, updated_at`
+
+func scanUser(row pgx.Row) (*domain.User, error) {
 	var u domain.User
-	err := r.pool.QueryRow(ctx, `
-		SELECT id, telegram_user_id, phone, first_name, last_name, username, created_at, updated_at
-		FROM users WHERE telegram_user_id = $1
-	`, telegramUserID).Scan(
-		&u.ID, &u.TelegramUserID, &u.Phone, &u.FirstName, &u.LastName, &u.Username, &u.CreatedAt, &u.UpdatedAt,
+	err := row.Scan(
+		&u.ID, &u.TelegramUserID, &u.Phone, &u.FirstName, &u.LastName, &u.Username,
+		&u.Attribution.StartParam, &u.Attribution.Campaign, &u.Attribution.ReferrerTelegramID,
+		&u.IsPremium, &u.BlockedAt, &u.BlockReason, &u.BlockedBy, &u.CreatedAt, &u.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -30,30 +35,83 @@ func (r *UserRepository) GetByTelegramID(ctx context.Context, telegramUserID int
 	return &u, nil
 }
 
+func (r *UserRepository) GetByTelegramID(ctx context.Context, telegramUserID int64) (*domain.User, error) {
+	return scanUser(r.pool.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE telegram_user_id = $1`, telegramUserID))
+}
+
 func (r *UserRepository) GetByID(ctx context.Context, id int64) (*domain.User, error) {
-	var u domain.User
-	err := r.pool.QueryRow(ctx, `
-		SELECT id, telegram_user_id, phone, first_name, last_name, username, created_at, updated_at
-		FROM users WHERE id = $1
-	`, id).Scan(
-		&u.ID, &u.TelegramUserID, &u.Phone, &u.FirstName, &u.LastName, &u.Username, &u.CreatedAt, &u.UpdatedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
-	return &u, nil
+	return scanUser(r.pool.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, id))
 }
 
-func (r *UserRepository) Upsert(ctx context.Context, u *domain.User) error {
-	return r.pool.QueryRow(ctx, `
-		INSERT INTO users (telegram_user_id, phone, first_name, last_name, username, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+// Upsert сохраняет пользователя. Если его номер забанен (phone_bans), пользователь сразу
+// блокируется с причиной бана — так бан переживает повторную регистрацию.
+// Возвращает true, если пользователь создан или сменил номер, — повторная отправка
+// того же контакта регистрацией не считается.
+func (r *UserRepository) Upsert(ctx context.Context, u *domain.User) (bool, error) {
+	var registered bool
+	err := r.pool.QueryRow(ctx, `
+		WITH ban AS (
+			SELECT reason, banned_by FROM phone_bans WHERE phone = $6
+		), prev AS (
+			SELECT phone FROM users WHERE telegram_user_id = $1
+		)
+		INSERT INTO users (telegram_user_id, phone, first_name, last_name, username,
+			blocked_at, block_reason, blocked_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5,
+			(SELECT NOW() FROM ban), (SELECT reason FROM ban), (SELECT banned_by FROM ban), NOW(), NOW())
 		ON CONFLICT (telegram_user_id) DO UPDATE SET
 			phone = EXCLUDED.phone,
 			first_name = EXCLUDED.first_name,
 			last_name = EXCLUDED.last_name,
 			username = EXCLUDED.username,
+			blocked_at = COALESCE(users.blocked_at, EXCLUDED.blocked_at),
+			block_reason = CASE WHEN users.blocked_at IS NULL THEN EXCLUDED.block_reason ELSE users.block_reason END,
+			blocked_by = CASE WHEN users.blocked_at IS NULL THEN EXCLUDED.blocked_by ELSE users.blocked_by END,
+			updated_at = NOW()
+		RETURNING id, blocked_at, COALESCE(block_reason, ''), COALESCE(blocked_by, ''), created_at, updated_at,
+			users.phone IS DISTINCT FROM (SELECT phone FROM prev)
+	`, u.TelegramUserID, u.Phone, u.FirstName, u.LastName, u.Username, domain.PhoneBanKey(u.Phone)).Scan(
+		&u.ID, &u.BlockedAt, &u.BlockReason, &u.BlockedBy, &u.CreatedAt, &u.UpdatedAt, &registered)
+	return registered, err
+}
+
+// SetAttribution сохраняет первый источник пользователя (first touch): уже записанный не перезаписывается.
+// Возвращает true, если источник был записан.
+func (r *UserRepository) SetAttribution(ctx context.Context, userID int64, a domain.Attribution) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE users SET
+			start_param = $2,
+			campaign = NULLIF($3, ''),
+			referrer_telegram_id = NULLIF($4, 0),
 			updated_at = NOW()
-		RETURNING id, created_at, updated_at
-	`, u.TelegramUserID, u.Phone, u.FirstName, u.LastName, u.Username).Scan(&u.ID, &u.CreatedAt, &u.UpdatedAt)
+		WHERE id = $1 AND start_param IS NULL
+	`, userID, a.StartParam, a.Campaign, a.ReferrerTelegramID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// SetBotBlocked отмечает, что пользователь заблокировал бота (blocked) или разблокировал его.
+func (r *UserRepository) SetBotBlocked(ctx context.Context, telegramUserID int64, blocked bool) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE users SET bot_blocked_at = CASE WHEN $2 THEN COALESCE(bot_blocked_at, NOW()) END
+		WHERE telegram_user_id = $1
+	`, telegramUserID, blocked)
+	return err
+}
+
+// SetRemindersOptOut отключает (optOut) или снова включает напоминания о сгорающих ваучерах.
+func (r *UserRepository) SetRemindersOptOut(ctx context.Context, telegramUserID int64, optOut bool) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE users SET reminders_opt_out_at = CASE WHEN $2 THEN COALESCE(reminders_opt_out_at, NOW()) END
+		WHERE telegram_user_id = $1
+	`, telegramUserID, optOut)
+	return err
+}
+
+// SetPremium сохраняет признак Telegram Premium из initData.
+func (r *UserRepository) SetPremium(ctx context.Context, userID int64, premium bool) error {
+	_, err := r.pool.Exec(ctx, `UPDATE users SET is_premium = $2 WHERE id = $1`, userID, premium)
+	return err
 }
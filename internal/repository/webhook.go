@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"era_sporta_bot_ruletka/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type WebhookRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewWebhookRepository(pool *pgxpool.Pool) *WebhookRepository {
+	return &WebhookRepository{pool: pool}
+}
+
+const webhookColumns = `
+	id, event_id, event, url, payload, status, attempts, COALESCE(response_code, 0),
+	COALESCE(last_error, ''), created_at, delivered_at`
+
+func scanWebhookDelivery(row pgx.Row) (domain.WebhookDelivery, error) {
+	var d domain.WebhookDelivery
+	err := row.Scan(
+		&d.ID, &d.EventID, &d.Event, &d.URL, &d.Payload, &d.Status, &d.Attempts, &d.ResponseCode,
+		&d.LastError, &d.CreatedAt, &d.DeliveredAt,
+	)
+	return d, err
+}
+
+func (r *WebhookRepository) Enqueue(ctx context.Context, d *domain.WebhookDelivery) error {
+	return r.pool.QueryRow(ctx, `
+		INSERT INTO webhook_deliveries (event_id, event, url, payload)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, status, created_at
+	`, d.EventID, string(d.Event), d.URL, d.Payload).Scan(&d.ID, &d.Status, &d.CreatedAt)
+}
+
+// Claim забирает до limit доставок, готовых к отправке, и откладывает их на lease
+// (как OutboxRepository.Claim). Счётчик попыток увеличивается сразу.
+func (r *WebhookRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDelivery, error) {
+	rows, err := r.pool.Query(ctx, `
+		UPDATE webhook_deliveries
+		SET attempts = attempts + 1, next_attempt_at = NOW() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+webhookColumns, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []domain.WebhookDelivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, d)
+	}
+	return result, rows.Err()
+}
+
+func (r *WebhookRepository) MarkDelivered(ctx context.Context, id int64, responseCode int) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE webhook_deliveries
+		SET status = 'delivered', delivered_at = NOW(), response_code = $2, last_error = NULL
+		WHERE id = $1
+	`, id, responseCode)
+	return err
+}
+
+// Retry возвращает доставку в очередь с отправкой не раньше at. responseCode 0 — ответа не было.
+func (r *WebhookRepository) Retry(ctx context.Context, id int64, at time.Time, responseCode int, lastError string) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE webhook_deliveries SET next_attempt_at = $2, response_code = NULLIF($3, 0), last_error = $4
+		WHERE id = $1
+	`, id, at, responseCode, lastError)
+	return err
+}
+
+func (r *WebhookRepository) MarkFailed(ctx context.Context, id int64, responseCode int, lastError string) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE webhook_deliveries SET status = 'failed', response_code = NULLIF($2, 0), last_error = $3
+		WHERE id = $1
+	`, id, responseCode, lastError)
+	return err
+}
+
+// List возвращает последние доставки, новые первыми; пустой status — все.
+func (r *WebhookRepository) List(ctx context.Context, status domain.WebhookStatus, limit int) ([]domain.WebhookDelivery, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+webhookColumns+`
+		FROM webhook_deliveries
+		WHERE $1 = '' OR status = $1
+		ORDER BY id DESC
+		LIMIT $2
+	`, string(status), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []domain.WebhookDelivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, d)
+	}
+	return result, rows.Err()
+}
+
+// DeleteDeliveredBefore удаляет доставленные записи журнала старше before.
+func (r *WebhookRepository) DeleteDeliveredBefore(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM webhook_deliveries WHERE status = 'delivered' AND delivered_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
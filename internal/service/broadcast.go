@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrBroadcastNotFound — рассылки с таким id нет.
+var ErrBroadcastNotFound = errors.New("broadcast not found")
+
+// ErrBroadcastNotDraft — рассылку уже запустили или отменили.
+var ErrBroadcastNotDraft = errors.New("broadcast is not a draft")
+
+type BroadcastService struct {
+	broadcastRepo *repository.BroadcastRepository
+}
+
+func NewBroadcastService(broadcastRepo *repository.BroadcastRepository) *BroadcastService {
+	return &BroadcastService{broadcastRepo: broadcastRepo}
+}
+
+// CreateDraft сохраняет черновик рассылки и считает размер аудитории.
+func (s *BroadcastService) CreateDraft(ctx context.Context, b *domain.Broadcast) error {
+	recipients, err := s.broadcastRepo.Recipients(ctx, b.Audience, b.PrizeID)
+	if err != nil {
+		return fmt.Errorf("count recipients: %w", err)
+	}
+	b.Total = len(recipients)
+	return s.broadcastRepo.Create(ctx, b)
+}
+
+func (s *BroadcastService) Get(ctx context.Context, id int64) (*domain.Broadcast, error) {
+	b, err := s.broadcastRepo.GetByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrBroadcastNotFound
+	}
+	return b, err
+}
+
+func (s *BroadcastService) ListRecent(ctx context.Context, limit int) ([]*domain.Broadcast, error) {
+	return s.broadcastRepo.ListRecent(ctx, limit)
+}
+
+// Start переводит черновик в running и возвращает актуальный список получателей.
+func (s *BroadcastService) Start(ctx context.Context, id int64) (*domain.Broadcast, []domain.Recipient, error) {
+	b, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if b.Status != domain.BroadcastDraft {
+		return nil, nil, ErrBroadcastNotDraft
+	}
+	recipients, err := s.broadcastRepo.Recipients(ctx, b.Audience, b.PrizeID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list recipients: %w", err)
+	}
+	ok, err := s.broadcastRepo.Start(ctx, id, len(recipients))
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return nil, nil, ErrBroadcastNotDraft
+	}
+	b.Status = domain.BroadcastRunning
+	b.Total = len(recipients)
+	return b, recipients, nil
+}
+
+// Cancel отменяет черновик или идущую рассылку.
+func (s *BroadcastService) Cancel(ctx context.Context, id int64) error {
+	ok, err := s.broadcastRepo.Cancel(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrBroadcastNotDraft
+	}
+	return nil
+}
+
+func (s *BroadcastService) UpdateProgress(ctx context.Context, b *domain.Broadcast) error {
+	return s.broadcastRepo.UpdateProgress(ctx, b)
+}
+
+func (s *BroadcastService) Finish(ctx context.Context, b *domain.Broadcast) error {
+	return s.broadcastRepo.Finish(ctx, b)
+}
+
+// FailInterrupted помечает как failed рассылки, оставшиеся running после перезапуска.
+func (s *BroadcastService) FailInterrupted(ctx context.Context) (int64, error) {
+	return s.broadcastRepo.FailRunning(ctx)
+}
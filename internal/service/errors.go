@@ -0,0 +1,15 @@
+package service
+
+import "errors"
+
+// Ошибки сервисов, которые handlers переводят в коды API (см. internal/api/apierror).
+var (
+	ErrSpinLimitExceeded = errors.New("spin limit exceeded")
+	ErrUserNotFound      = errors.New("user not found")
+	ErrPhoneRequired     = errors.New("phone required")
+	ErrCampaignClosed    = errors.New("campaign closed")
+	ErrRateLimited       = errors.New("rate limited")
+	ErrSpinRejected      = errors.New("spin rejected by fraud rules")
+	ErrUserBlocked       = errors.New("user blocked")
+	ErrInvalidPhone      = errors.New("invalid phone number")
+)
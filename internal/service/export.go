@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/export"
+	"era_sporta_bot_ruletka/internal/repository"
+)
+
+type ExportService struct {
+	exportRepo *repository.ExportRepository
+	loc        *time.Location
+}
+
+// NewExportService создаёт сервис выгрузки. loc — часовой пояс дат в файле и дат YYYY-MM-DD в фильтре.
+func NewExportService(exportRepo *repository.ExportRepository, loc *time.Location) *ExportService {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return &ExportService{exportRepo: exportRepo, loc: loc}
+}
+
+// Location возвращает часовой пояс выгрузки.
+func (s *ExportService) Location() *time.Location {
+	return s.loc
+}
+
+// Write выгружает пользователей и спины по фильтру в w. Возвращает число строк.
+func (s *ExportService) Write(ctx context.Context, w io.Writer, format export.Format, f domain.ExportFilter) (int, error) {
+	rows, err := s.exportRepo.Rows(ctx, f)
+	if err != nil {
+		return 0, fmt.Errorf("export rows: %w", err)
+	}
+	if err := export.Write(w, format, rows, s.loc); err != nil {
+		return 0, fmt.Errorf("write %s: %w", format, err)
+	}
+	return len(rows), nil
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/repository"
+)
+
+// FunnelService записывает события воронки. Ошибки записи не прерывают основной сценарий.
+type FunnelService struct {
+	eventRepo *repository.EventRepository
+}
+
+func NewFunnelService(eventRepo *repository.EventRepository) *FunnelService {
+	return &FunnelService{eventRepo: eventRepo}
+}
+
+// Track записывает событие event пользователя telegramUserID.
+func (s *FunnelService) Track(ctx context.Context, telegramUserID int64, event domain.FunnelEvent, startParam string) {
+	if s == nil {
+		return
+	}
+	if err := s.eventRepo.Record(ctx, telegramUserID, event, startParam); err != nil {
+		slog.WarnContext(ctx, "record funnel event failed", "error", err, "event", string(event))
+	}
+}
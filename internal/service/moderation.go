@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ModerationService блокирует пользователей и номера по решению менеджеров.
+// Заблокированный пользователь не проходит /api/auth, не крутит рулетку и не получает
+// кнопку приложения в боте; блокировка пользователя банит и его номер, поэтому
+// повторная регистрация с тем же номером тоже заблокирована.
+type ModerationService struct {
+	repo *repository.ModerationRepository
+}
+
+func NewModerationService(repo *repository.ModerationRepository) *ModerationService {
+	return &ModerationService{repo: repo}
+}
+
+// BlockUser блокирует пользователя по Telegram ID. actor — кто заблокировал (менеджер или «admin_api»).
+func (s *ModerationService) BlockUser(ctx context.Context, telegramUserID int64, reason, actor string) (*domain.User, error) {
+	user, err := s.repo.BlockUser(ctx, telegramUserID, reason, actor)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "user blocked", "blocked_telegram_id", telegramUserID, "reason", reason, "actor", actor)
+	return user, nil
+}
+
+// UnblockUser снимает блокировку с пользователя и бан с его номера.
+func (s *ModerationService) UnblockUser(ctx context.Context, telegramUserID int64, actor string) (*domain.User, error) {
+	user, err := s.repo.UnblockUser(ctx, telegramUserID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "user unblocked", "blocked_telegram_id", telegramUserID, "actor", actor)
+	return user, nil
+}
+
+// BanPhone банит номер и блокирует все аккаунты с ним, в том числе будущие.
+// Возвращает ключ бана (номер цифрами) и сколько аккаунтов заблокировано.
+func (s *ModerationService) BanPhone(ctx context.Context, phone, reason, actor string) (string, int, error) {
+	key := domain.PhoneBanKey(phone)
+	if key == "" {
+		return "", 0, ErrInvalidPhone
+	}
+	blocked, err := s.repo.BanPhone(ctx, key, reason, actor)
+	if err != nil {
+		return "", 0, err
+	}
+	slog.InfoContext(ctx, "phone banned", "reason", reason, "actor", actor, "blocked_users", blocked)
+	return key, blocked, nil
+}
+
+// UnbanPhone снимает бан с номера и блокировку с аккаунтов с ним.
+// Возвращает false, если номер не был забанен.
+func (s *ModerationService) UnbanPhone(ctx context.Context, phone, actor string) (bool, error) {
+	key := domain.PhoneBanKey(phone)
+	if key == "" {
+		return false, ErrInvalidPhone
+	}
+	found, err := s.repo.UnbanPhone(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if found {
+		slog.InfoContext(ctx, "phone unbanned", "actor", actor)
+	}
+	return found, nil
+}
+
+// ListBlocked возвращает заблокированных пользователей и баны номеров, новые первыми.
+func (s *ModerationService) ListBlocked(ctx context.Context, limit int) ([]*domain.User, []domain.PhoneBan, error) {
+	users, err := s.repo.ListBlockedUsers(ctx, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	bans, err := s.repo.ListPhoneBans(ctx, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	return users, bans, nil
+}
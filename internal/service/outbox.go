@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/notifier"
+	"era_sporta_bot_ruletka/internal/repository"
+)
+
+const (
+	// OutboxMaxAttempts — после стольких неудачных попыток сообщение помечается failed.
+	OutboxMaxAttempts = 8
+
+	// outboxLease — на сколько сообщение откладывается, пока его отправляет один обработчик.
+	outboxLease = 2 * time.Minute
+
+	// outboxRetention — сколько хранить доставленные сообщения.
+	outboxRetention = 30 * 24 * time.Hour
+)
+
+// OutboxService — очередь исходящих сообщений бота (см. migrations/011_create_notification_outbox.sql).
+type OutboxService struct {
+	outboxRepo *repository.OutboxRepository
+	router     *notifier.Router
+}
+
+func NewOutboxService(outboxRepo *repository.OutboxRepository, router *notifier.Router) *OutboxService {
+	return &OutboxService{outboxRepo: outboxRepo, router: router}
+}
+
+// Enqueue ставит сообщение в очередь как есть (с разметкой и кнопками).
+func (s *OutboxService) Enqueue(ctx context.Context, m *domain.OutboxMessage) error {
+	return s.outboxRepo.Enqueue(ctx, m)
+}
+
+// EnqueueEvent ставит уведомление события в очередь для каждого получателя по правилам
+// маршрутизации; prize (может быть nil) учитывается правилами с фильтром по призу.
+func (s *OutboxService) EnqueueEvent(ctx context.Context, e notifier.Event, prize *domain.Prize, m domain.OutboxMessage) error {
+	for _, name := range s.router.Resolve(e, prize) {
+		msg := m
+		msg.Kind = domain.OutboxAdmin
+		msg.Destination = name
+		msg.Event = string(e)
+		if err := s.outboxRepo.Enqueue(ctx, &msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnqueueAdmin ставит в очередь сообщение получателю по умолчанию (админский чат).
+func (s *OutboxService) EnqueueAdmin(ctx context.Context, text string) error {
+	return s.outboxRepo.Enqueue(ctx, &domain.OutboxMessage{Kind: domain.OutboxAdmin, Destination: notifier.DefaultDestination, Text: text})
+}
+
+// EnqueueUser ставит в очередь сообщение в личный чат пользователя.
+func (s *OutboxService) EnqueueUser(ctx context.Context, telegramUserID int64, text string) error {
+	return s.outboxRepo.Enqueue(ctx, &domain.OutboxMessage{Kind: domain.OutboxUser, ChatID: telegramUserID, Text: text})
+}
+
+// Claim забирает до limit сообщений, готовых к отправке.
+func (s *OutboxService) Claim(ctx context.Context, limit int) ([]domain.OutboxMessage, error) {
+	return s.outboxRepo.Claim(ctx, limit, outboxLease)
+}
+
+func (s *OutboxService) MarkSent(ctx context.Context, id int64) error {
+	return s.outboxRepo.MarkSent(ctx, id)
+}
+
+// Retry планирует повтор с экспоненциальной задержкой (или через retryAfter, если Telegram его указал).
+// После OutboxMaxAttempts попыток сообщение помечается failed; возвращает true, если повтор запланирован.
+func (s *OutboxService) Retry(ctx context.Context, m domain.OutboxMessage, retryAfter time.Duration, cause error) (bool, error) {
+	if m.Attempts >= OutboxMaxAttempts {
+		return false, s.outboxRepo.MarkFailed(ctx, m.ID, cause.Error())
+	}
+	delay := retryAfter
+	if delay <= 0 {
+		delay = time.Duration(1<<m.Attempts) * 10 * time.Second
+	}
+	return true, s.outboxRepo.Retry(ctx, m.ID, time.Now().Add(delay), cause.Error())
+}
+
+// Fail помечает сообщение недоставляемым без повторов.
+func (s *OutboxService) Fail(ctx context.Context, id int64, cause error) error {
+	return s.outboxRepo.MarkFailed(ctx, id, cause.Error())
+}
+
+// Cleanup удаляет доставленные сообщения старше срока хранения.
+func (s *OutboxService) Cleanup(ctx context.Context) (int64, error) {
+	return s.outboxRepo.DeleteSentBefore(ctx, time.Now().Add(-outboxRetention))
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/repository"
+)
+
+// reminderBatchSize — сколько напоминаний одного порога обрабатывается за проход.
+const reminderBatchSize = 500
+
+type ReminderService struct {
+	reminderRepo *repository.ReminderRepository
+	daysBefore   []int
+	loc          *time.Location
+}
+
+// NewReminderService создаёт сервис напоминаний. daysBefore — пороги в днях до истечения
+// ваучера (например, 3 и 1), loc — часовой пояс для дат в тексте напоминаний.
+func NewReminderService(reminderRepo *repository.ReminderRepository, daysBefore []int, loc *time.Location) *ReminderService {
+	days := append([]int(nil), daysBefore...)
+	sort.Ints(days)
+	return &ReminderService{reminderRepo: reminderRepo, daysBefore: days, loc: loc}
+}
+
+// Enabled сообщает, настроен ли хотя бы один порог.
+func (s *ReminderService) Enabled() bool {
+	return len(s.daysBefore) > 0
+}
+
+func (s *ReminderService) Location() *time.Location {
+	return s.loc
+}
+
+// Due возвращает напоминания, которые пора отправить. Ваучер, попадающий под несколько
+// порогов сразу, получает одно напоминание — по ближайшему к истечению.
+func (s *ReminderService) Due(ctx context.Context) ([]domain.VoucherReminder, error) {
+	var result []domain.VoucherReminder
+	seen := make(map[int64]bool)
+	for _, days := range s.daysBefore {
+		due, err := s.reminderRepo.Due(ctx, days, reminderBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("due reminders (%d days): %w", days, err)
+		}
+		for _, rm := range due {
+			if seen[rm.SpinID] {
+				continue
+			}
+			seen[rm.SpinID] = true
+			result = append(result, rm)
+		}
+	}
+	return result, nil
+}
+
+// MarkSent сохраняет отправленное напоминание, чтобы не повторять его.
+func (s *ReminderService) MarkSent(ctx context.Context, rm domain.VoucherReminder) error {
+	return s.reminderRepo.Record(ctx, rm)
+}
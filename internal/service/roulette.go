@@ -2,22 +2,32 @@ package service
 
 import (
 	"context"
+	crand "crypto/rand"
 	"fmt"
+	"log/slog"
 	"math/rand"
 	"strings"
+	"time"
 
 	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/fraud"
+	"era_sporta_bot_ruletka/internal/iphash"
+	"era_sporta_bot_ruletka/internal/logger"
 	"era_sporta_bot_ruletka/internal/repository"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type RouletteService struct {
-	pool      *pgxpool.Pool
-	prizeRepo *repository.PrizeRepository
-	spinRepo  *repository.SpinRepository
-	userRepo  *repository.UserRepository
-	spinLimit int
+	pool       *pgxpool.Pool
+	prizeRepo  *repository.PrizeRepository
+	spinRepo   *repository.SpinRepository
+	userRepo   *repository.UserRepository
+	scorer     *fraud.Scorer
+	ipHasher   *iphash.Hasher
+	spinLimit  int
+	voucherTTL time.Duration
+	throttle   *spinThrottle
 }
 
 func NewRouletteService(
@@ -25,39 +35,73 @@ func NewRouletteService(
 	prizeRepo *repository.PrizeRepository,
 	spinRepo *repository.SpinRepository,
 	userRepo *repository.UserRepository,
+	scorer *fraud.Scorer,
+	ipHasher *iphash.Hasher,
 	spinLimit int,
+	voucherTTL time.Duration,
+	attemptInterval time.Duration,
 ) *RouletteService {
 	return &RouletteService{
-		pool:      pool,
-		prizeRepo: prizeRepo,
-		spinRepo:  spinRepo,
-		userRepo:  userRepo,
-		spinLimit: spinLimit,
+		pool:       pool,
+		prizeRepo:  prizeRepo,
+		spinRepo:   spinRepo,
+		userRepo:   userRepo,
+		scorer:     scorer,
+		ipHasher:   ipHasher,
+		spinLimit:  spinLimit,
+		voucherTTL: voucherTTL,
+		throttle:   newSpinThrottle(attemptInterval),
 	}
 }
 
-func (s *RouletteService) Spin(ctx context.Context, userID int64, ipHash string) (*domain.SpinWithPrize, error) {
+// Spin крутит рулетку для пользователя. clientIP сохраняется только хэшем (internal/iphash),
+// source — источник (start_param), которым помечается спин.
+// Вместе со спином возвращается оценка риска (internal/fraud); если по ней спин отклонён,
+// ошибка — ErrSpinRejected, а оценка заполнена для уведомления менеджеров.
+func (s *RouletteService) Spin(ctx context.Context, user *domain.User, clientIP string, source domain.Attribution) (*domain.SpinWithPrize, fraud.Assessment, error) {
+	userID := user.ID
+	var assessment fraud.Assessment
+	if user.IsBlocked() {
+		slog.InfoContext(ctx, "spin by blocked user refused")
+		return nil, assessment, ErrUserBlocked
+	}
+	if !s.throttle.allow(userID, time.Now()) {
+		slog.InfoContext(ctx, "spin attempt throttled")
+		return nil, assessment, ErrRateLimited
+	}
 	// Check limit
 	count, err := s.spinRepo.CountByUserID(ctx, userID)
 	if err != nil {
-		return nil, fmt.Errorf("count spins: %w", err)
+		return nil, assessment, fmt.Errorf("count spins: %w", err)
 	}
 	if count >= s.spinLimit {
-		return nil, ErrSpinLimitExceeded
+		slog.InfoContext(ctx, "spin limit exceeded", "spins_used", count, "spin_limit", s.spinLimit)
+		return nil, assessment, ErrSpinLimitExceeded
+	}
+
+	if s.scorer != nil {
+		assessment, err = s.scorer.Assess(ctx, user, clientIP, time.Now())
+		if err != nil {
+			slog.WarnContext(ctx, "fraud assessment incomplete", "error", err)
+		}
+		if assessment.Action == fraud.ActionBlock {
+			slog.WarnContext(ctx, "spin rejected by fraud rules", "fraud_score", assessment.Score, "fraud_reasons", assessment.Reasons)
+			return nil, assessment, ErrSpinRejected
+		}
 	}
 
 	prizes, err := s.prizeRepo.ListActive(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("list prizes: %w", err)
+		return nil, assessment, fmt.Errorf("list prizes: %w", err)
 	}
 	if len(prizes) == 0 {
-		return nil, fmt.Errorf("no active prizes")
+		return nil, assessment, ErrCampaignClosed
 	}
 
 	// Weighted random from allowed prizes only.
 	randomPrizes := filterAllowedPrizes(prizes)
 	if len(randomPrizes) == 0 {
-		return nil, fmt.Errorf("no random prizes configured")
+		return nil, assessment, ErrCampaignClosed
 	}
 
 	var totalWeight int
@@ -77,50 +121,69 @@ func (s *RouletteService) Spin(ctx context.Context, userID int64, ipHash string)
 		chosen = randomPrizes[len(randomPrizes)-1]
 	}
 
+	voucherCode, err := newVoucherCode()
+	if err != nil {
+		return nil, assessment, fmt.Errorf("voucher code: %w", err)
+	}
+	ipHash, ipHashVersion := s.ipHasher.Hash(clientIP, time.Now())
 	spin := &domain.Spin{
-		UserID:      userID,
-		PrizeID:     chosen.ID,
-		ResultValue: chosen.Value,
-		IPHash:      ipHash,
+		UserID:        userID,
+		PrizeID:       chosen.ID,
+		ResultValue:   chosen.Value,
+		IPHash:        ipHash,
+		IPHashVersion: ipHashVersion,
+		StartParam:    source.StartParam,
+		Campaign:      source.Campaign,
+		VoucherCode:   voucherCode,
+		FraudScore:    assessment.Score,
+		FraudReasons:  assessment.Reasons,
+	}
+	if s.voucherTTL > 0 {
+		expiresAt := time.Now().Add(s.voucherTTL)
+		spin.ExpiresAt = &expiresAt
 	}
 
 	// Use transaction with advisory lock to prevent race
 	tx, err := s.pool.Begin(ctx)
 	if err != nil {
-		return nil, err
+		return nil, assessment, err
 	}
 	defer tx.Rollback(ctx)
 
 	// Advisory lock by user_id
 	_, err = tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", userID)
 	if err != nil {
-		return nil, fmt.Errorf("advisory lock: %w", err)
+		return nil, assessment, fmt.Errorf("advisory lock: %w", err)
 	}
 
 	// Recheck limit inside transaction
 	var cnt int
 	err = tx.QueryRow(ctx, "SELECT COUNT(*) FROM spins WHERE user_id = $1", userID).Scan(&cnt)
 	if err != nil {
-		return nil, err
+		return nil, assessment, err
 	}
 	if cnt >= s.spinLimit {
-		return nil, ErrSpinLimitExceeded
+		return nil, assessment, ErrSpinLimitExceeded
 	}
 
 	err = tx.QueryRow(ctx, `
-		INSERT INTO spins (user_id, prize_id, result_value, ip_hash, created_at)
-		VALUES ($1, $2, $3, $4, NOW())
-		RETURNING id, created_at
-	`, spin.UserID, spin.PrizeID, spin.ResultValue, spin.IPHash).Scan(&spin.ID, &spin.CreatedAt)
+		INSERT INTO spins (user_id, prize_id, result_value, ip_hash, ip_hash_version, start_param, campaign, voucher_code,
+			expires_at, fraud_score, fraud_reasons, created_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''), $9, $10, $11, NOW())
+		RETURNING id, status, created_at
+	`, spin.UserID, spin.PrizeID, spin.ResultValue, spin.IPHash, spin.IPHashVersion, spin.StartParam, spin.Campaign,
+		spin.VoucherCode, spin.ExpiresAt, spin.FraudScore, spin.FraudReasons).Scan(&spin.ID, &spin.Status, &spin.CreatedAt)
 	if err != nil {
-		return nil, err
+		return nil, assessment, err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		return nil, err
+		return nil, assessment, err
 	}
+	slog.InfoContext(ctx, "spin created", logger.KeySpinID, spin.ID, "prize_id", chosen.ID, "prize", chosen.Name,
+		"fraud_score", spin.FraudScore)
 
-	return &domain.SpinWithPrize{Spin: *spin, Prize: chosen}, nil
+	return &domain.SpinWithPrize{Spin: *spin, Prize: chosen}, assessment, nil
 }
 
 const disabledPrizeName = "Безлимит посещений на 1 месяц"
@@ -160,8 +223,52 @@ func (s *RouletteService) GetConfig(ctx context.Context) ([]*domain.Prize, error
 	return s.prizeRepo.ListActive(ctx)
 }
 
-func (s *RouletteService) GetHistory(ctx context.Context, userID int64, limit int) ([]*domain.SpinWithPrize, error) {
-	return s.spinRepo.ListByUserID(ctx, userID, limit)
+// HistoryPage — страница истории спинов. NextCursor пустой, если страница последняя.
+type HistoryPage struct {
+	Spins      []*domain.SpinWithPrize
+	Total      int
+	NextCursor *domain.SpinCursor
 }
 
-var ErrSpinLimitExceeded = fmt.Errorf("spin limit exceeded")
+const maxHistoryLimit = 100
+
+// GetHistory возвращает страницу истории спинов пользователя и общее число спинов по фильтру.
+func (s *RouletteService) GetHistory(ctx context.Context, userID int64, f domain.SpinFilter) (*HistoryPage, error) {
+	if f.Limit <= 0 || f.Limit > maxHistoryLimit {
+		f.Limit = maxHistoryLimit
+	}
+	// Берём на одну запись больше, чтобы понять, есть ли следующая страница
+	page := f
+	page.Limit = f.Limit + 1
+	spins, err := s.spinRepo.ListByUserID(ctx, userID, page)
+	if err != nil {
+		return nil, fmt.Errorf("list spins: %w", err)
+	}
+	total, err := s.spinRepo.CountByUserIDFiltered(ctx, userID, f)
+	if err != nil {
+		return nil, fmt.Errorf("count spins: %w", err)
+	}
+
+	result := &HistoryPage{Spins: spins, Total: total}
+	if len(spins) > f.Limit {
+		result.Spins = spins[:f.Limit]
+		last := result.Spins[f.Limit-1]
+		result.NextCursor = &domain.SpinCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+	return result, nil
+}
+
+// voucherAlphabet — символы кода ваучера без легко путаемых (0/O, 1/I/L).
+const voucherAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+// newVoucherCode генерирует код вида ES-XXXXXXXX, который клиент называет администратору.
+func newVoucherCode() (string, error) {
+	b := make([]byte, 8)
+	if _, err := crand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = voucherAlphabet[int(b[i])%len(voucherAlphabet)]
+	}
+	return "ES-" + string(b), nil
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+	_ "time/tzdata" // STATS_TIMEZONE должен работать и в образах без системной базы часовых поясов
+
+	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/repository"
+)
+
+type StatsService struct {
+	statsRepo *repository.StatsRepository
+	loc       *time.Location
+}
+
+// NewStatsService создаёт сервис аналитики. loc — часовой пояс, в котором считаются дни.
+func NewStatsService(statsRepo *repository.StatsRepository, loc *time.Location) *StatsService {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return &StatsService{statsRepo: statsRepo, loc: loc}
+}
+
+// FunnelStepStats — шаг воронки: уникальные пользователи и конверсия из предыдущего шага и из /start.
+type FunnelStepStats struct {
+	Step            string
+	Users           int
+	ConversionPrev  float64
+	ConversionStart float64
+}
+
+type Stats struct {
+	From        time.Time
+	To          time.Time
+	UniqueUsers int
+	Funnel      []FunnelStepStats
+	Daily       []*domain.DailyStats
+	Prizes      []*domain.PrizeStats
+}
+
+// Location возвращает часовой пояс, в котором считаются дни.
+func (s *StatsService) Location() *time.Location {
+	return s.loc
+}
+
+// Get считает аналитику за период [from, to).
+func (s *StatsService) Get(ctx context.Context, from, to time.Time) (*Stats, error) {
+	totals, unique, err := s.statsRepo.FunnelTotals(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("funnel totals: %w", err)
+	}
+	daily, err := s.statsRepo.Daily(ctx, from, to, s.loc.String())
+	if err != nil {
+		return nil, fmt.Errorf("daily stats: %w", err)
+	}
+	prizes, err := s.statsRepo.PrizeDistribution(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("prize distribution: %w", err)
+	}
+
+	stats := &Stats{
+		From:        from,
+		To:          to,
+		UniqueUsers: unique,
+		Daily:       fillDays(daily, from, to, s.loc),
+		Prizes:      prizes,
+	}
+
+	first := totals[domain.FunnelSteps[0]]
+	prev := 0
+	for i, step := range domain.FunnelSteps {
+		n := totals[step]
+		st := FunnelStepStats{Step: step, Users: n, ConversionStart: rate(n, first)}
+		if i == 0 {
+			st.ConversionPrev = 1
+			if n == 0 {
+				st.ConversionPrev = 0
+			}
+		} else {
+			st.ConversionPrev = rate(n, prev)
+		}
+		stats.Funnel = append(stats.Funnel, st)
+		prev = n
+	}
+
+	// Заданная доля считается так же, как розыгрыш: только активные и разрешённые призы
+	var totalWeight, totalSpins int
+	for _, p := range prizes {
+		if p.IsActive && !isDisallowedPrize(&domain.Prize{Name: p.Name, Type: p.Type}) {
+			totalWeight += p.Weight
+		}
+		totalSpins += p.Spins
+	}
+	for _, p := range prizes {
+		if p.IsActive && !isDisallowedPrize(&domain.Prize{Name: p.Name, Type: p.Type}) {
+			p.ConfiguredRate = rate(p.Weight, totalWeight)
+		}
+		p.ActualRate = rate(p.Spins, totalSpins)
+	}
+	return stats, nil
+}
+
+// Digest возвращает сводку для админского чата за период [from, to).
+func (s *StatsService) Digest(ctx context.Context, from, to time.Time) (*domain.Digest, error) {
+	d, err := s.statsRepo.Digest(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("digest: %w", err)
+	}
+	return d, nil
+}
+
+// fillDays дополняет выборку днями без событий, чтобы ряд был непрерывным.
+func fillDays(daily []*domain.DailyStats, from, to time.Time, loc *time.Location) []*domain.DailyStats {
+	byDay := make(map[string]*domain.DailyStats, len(daily))
+	for _, d := range daily {
+		byDay[d.Date.Format(time.DateOnly)] = d
+	}
+	var result []*domain.DailyStats
+	start := from.In(loc)
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		key := day.Format(time.DateOnly)
+		if d, ok := byDay[key]; ok {
+			d.Date = day
+			result = append(result, d)
+			continue
+		}
+		result = append(result, &domain.DailyStats{Date: day, Steps: make(map[string]int)})
+	}
+	return result
+}
+
+func rate(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"sync"
+	"text/template"
+	"time"
+
+	"era_sporta_bot_ruletka/internal/notifier"
+	"era_sporta_bot_ruletka/internal/repository"
+)
+
+// templateCacheTTL — как быстро изменения шаблонов из другого процесса (бот ↔ API) вступают в силу.
+const templateCacheTTL = time.Minute
+
+// TemplateService отрисовывает уведомления по шаблонам: изменённым менеджерами (из БД)
+// или по умолчанию (notifier.DefaultTemplate).
+type TemplateService struct {
+	templateRepo *repository.TemplateRepository
+	loc          *time.Location
+
+	mu       sync.Mutex
+	custom   map[notifier.Event]*template.Template
+	loadedAt time.Time
+}
+
+func NewTemplateService(templateRepo *repository.TemplateRepository, loc *time.Location) *TemplateService {
+	return &TemplateService{templateRepo: templateRepo, loc: loc}
+}
+
+// Location — часовой пояс, в котором форматируются даты в уведомлениях.
+func (s *TemplateService) Location() *time.Location {
+	return s.loc
+}
+
+// Render отрисовывает уведомление события. Если изменённый шаблон не загрузился или упал
+// на этих данных, используется шаблон по умолчанию — уведомление не теряется.
+func (s *TemplateService) Render(ctx context.Context, e notifier.Event, data any) (string, error) {
+	if t := s.customTemplate(ctx, e); t != nil {
+		var buf bytes.Buffer
+		err := t.Execute(&buf, data)
+		if err == nil {
+			return buf.String(), nil
+		}
+		slog.WarnContext(ctx, "custom notification template failed, using default", "error", err, "event", string(e))
+	}
+	var buf bytes.Buffer
+	if err := notifier.Default(e).Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Body возвращает текущий текст шаблона и true, если он изменён менеджерами.
+func (s *TemplateService) Body(ctx context.Context, e notifier.Event) (string, bool, error) {
+	custom, err := s.templateRepo.List(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	if body, ok := custom[string(e)]; ok {
+		return body, true, nil
+	}
+	return notifier.DefaultTemplate(e), false, nil
+}
+
+// Set проверяет и сохраняет шаблон события.
+func (s *TemplateService) Set(ctx context.Context, e notifier.Event, body string, updatedBy int64) error {
+	if _, err := notifier.ParseTemplate(e, body); err != nil {
+		return err
+	}
+	if err := s.templateRepo.Set(ctx, string(e), body, updatedBy); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// Reset возвращает шаблон по умолчанию.
+func (s *TemplateService) Reset(ctx context.Context, e notifier.Event) error {
+	if err := s.templateRepo.Delete(ctx, string(e)); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+func (s *TemplateService) invalidate() {
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+}
+
+// customTemplate возвращает изменённый шаблон события из кэша, перечитывая БД раз в templateCacheTTL.
+func (s *TemplateService) customTemplate(ctx context.Context, e notifier.Event) *template.Template {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Since(s.loadedAt) >= templateCacheTTL {
+		bodies, err := s.templateRepo.List(ctx)
+		if err != nil {
+			// Оставляем прежний кэш, повторим при следующем уведомлении
+			slog.WarnContext(ctx, "load notification templates failed", "error", err)
+		} else {
+			custom := make(map[notifier.Event]*template.Template, len(bodies))
+			for name, body := range bodies {
+				ev, ok := notifier.ParseEvent(name)
+				if !ok {
+					continue
+				}
+				t, err := notifier.ParseTemplate(ev, body)
+				if err != nil {
+					slog.WarnContext(ctx, "invalid notification template ignored", "error", err, "event", name)
+					continue
+				}
+				custom[ev] = t
+			}
+			s.custom = custom
+			s.loadedAt = time.Now()
+		}
+	}
+	return s.custom[e]
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// spinThrottle пропускает не больше одной попытки спина пользователя за interval:
+// повторные нажатия и параллельные запросы отклоняются до обращения к БД.
+// Хранится в памяти процесса.
+type spinThrottle struct {
+	interval time.Duration
+
+	mu        sync.Mutex
+	last      map[int64]time.Time
+	lastSweep time.Time
+}
+
+// newSpinThrottle возвращает nil, если interval не положительный: ограничение выключено.
+func newSpinThrottle(interval time.Duration) *spinThrottle {
+	if interval <= 0 {
+		return nil
+	}
+	return &spinThrottle{interval: interval, last: make(map[int64]time.Time)}
+}
+
+// allow отмечает попытку пользователя userID. Возвращает false, если предыдущая была меньше interval назад.
+func (t *spinThrottle) allow(userID int64, now time.Time) bool {
+	if t == nil {
+		return true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if now.Sub(t.lastSweep) > t.interval {
+		for id, at := range t.last {
+			if now.Sub(at) >= t.interval {
+				delete(t.last, id)
+			}
+		}
+		t.lastSweep = now
+	}
+
+	if at, ok := t.last[userID]; ok && now.Sub(at) < t.interval {
+		return false
+	}
+	t.last[userID] = now
+	return true
+}
@@ -2,9 +2,12 @@ package service
 
 import (
 	"context"
+	"errors"
 
 	"era_sporta_bot_ruletka/internal/domain"
 	"era_sporta_bot_ruletka/internal/repository"
+
+	"github.com/jackc/pgx/v5"
 )
 
 type UserService struct {
@@ -16,18 +19,75 @@ func NewUserService(userRepo *repository.UserRepository, spinRepo *repository.Sp
 	return &UserService{userRepo: userRepo, spinRepo: spinRepo}
 }
 
+// GetByTelegramID возвращает пользователя или ErrUserNotFound.
 func (s *UserService) GetByTelegramID(ctx context.Context, telegramUserID int64) (*domain.User, error) {
-	return s.userRepo.GetByTelegramID(ctx, telegramUserID)
+	u, err := s.userRepo.GetByTelegramID(ctx, telegramUserID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	return u, err
+}
+
+// GetRegistered возвращает пользователя, поделившегося номером, иначе ErrPhoneRequired;
+// заблокированному — ErrUserBlocked. Ошибки БД возвращаются как есть, чтобы не выдавать
+// их за отсутствие номера.
+func (s *UserService) GetRegistered(ctx context.Context, telegramUserID int64) (*domain.User, error) {
+	u, err := s.GetByTelegramID(ctx, telegramUserID)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil, ErrPhoneRequired
+	}
+	if err != nil {
+		return nil, err
+	}
+	if u.Phone == "" {
+		return nil, ErrPhoneRequired
+	}
+	if u.IsBlocked() {
+		return nil, ErrUserBlocked
+	}
+	return u, nil
 }
 
 func (s *UserService) GetByID(ctx context.Context, id int64) (*domain.User, error) {
-	return s.userRepo.GetByID(ctx, id)
+	u, err := s.userRepo.GetByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	return u, err
 }
 
-func (s *UserService) Upsert(ctx context.Context, u *domain.User) error {
+// Upsert сохраняет пользователя; true — пользователь новый или сменил номер.
+func (s *UserService) Upsert(ctx context.Context, u *domain.User) (bool, error) {
 	return s.userRepo.Upsert(ctx, u)
 }
 
+// Attribute привязывает пользователя к источнику из start_param, если источник ещё не записан.
+func (s *UserService) Attribute(ctx context.Context, user *domain.User, a domain.Attribution) error {
+	if a.IsZero() || !user.Attribution.IsZero() {
+		return nil
+	}
+	ok, err := s.userRepo.SetAttribution(ctx, user.ID, a)
+	if err != nil {
+		return err
+	}
+	if ok {
+		user.Attribution = a
+	}
+	return nil
+}
+
+// SetPremium обновляет признак Telegram Premium, если он изменился.
+func (s *UserService) SetPremium(ctx context.Context, user *domain.User, premium bool) error {
+	if user.IsPremium == premium {
+		return nil
+	}
+	if err := s.userRepo.SetPremium(ctx, user.ID, premium); err != nil {
+		return err
+	}
+	user.IsPremium = premium
+	return nil
+}
+
 func (s *UserService) GetUserState(ctx context.Context, user *domain.User, spinLimit int) (*UserState, error) {
 	spinCount, err := s.spinRepo.CountByUserID(ctx, user.ID)
 	if err != nil {
@@ -47,3 +107,13 @@ type UserState struct {
 	SpinsUsed     int
 	SpinLimit     int
 }
+
+// SetBotBlocked отмечает, что пользователь заблокировал или разблокировал бота.
+func (s *UserService) SetBotBlocked(ctx context.Context, telegramUserID int64, blocked bool) error {
+	return s.userRepo.SetBotBlocked(ctx, telegramUserID, blocked)
+}
+
+// SetRemindersOptOut отключает или снова включает напоминания о сгорающих ваучерах.
+func (s *UserService) SetRemindersOptOut(ctx context.Context, telegramUserID int64, optOut bool) error {
+	return s.userRepo.SetRemindersOptOut(ctx, telegramUserID, optOut)
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrSpinNotFound — спина с таким id нет.
+var ErrSpinNotFound = errors.New("spin not found")
+
+// ErrVoucherStatusConflict — переход недопустим: ваучер уже погашен, истёк или уже в этом статусе.
+var ErrVoucherStatusConflict = errors.New("voucher status conflict")
+
+// VoucherService — действия менеджеров с выигрышами из админского чата.
+type VoucherService struct {
+	spinRepo *repository.SpinRepository
+	userRepo *repository.UserRepository
+}
+
+func NewVoucherService(spinRepo *repository.SpinRepository, userRepo *repository.UserRepository) *VoucherService {
+	return &VoucherService{spinRepo: spinRepo, userRepo: userRepo}
+}
+
+// Details возвращает спин вместе с пользователем для уведомления в админский чат.
+func (s *VoucherService) Details(ctx context.Context, spinID int64) (*domain.SpinWithPrize, *domain.User, error) {
+	spin, err := s.spinRepo.GetByID(ctx, spinID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, ErrSpinNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("get spin: %w", err)
+	}
+	user, err := s.userRepo.GetByID(ctx, spin.UserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get user: %w", err)
+	}
+	return spin, user, nil
+}
+
+// MarkCalled отмечает, что менеджер связался с победителем (issued → claimed).
+func (s *VoucherService) MarkCalled(ctx context.Context, spinID int64) error {
+	ok, err := s.spinRepo.MarkClaimed(ctx, spinID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrVoucherStatusConflict
+	}
+	return nil
+}
+
+// MarkRedeemed отмечает, что приз выдан (issued/claimed → redeemed).
+func (s *VoucherService) MarkRedeemed(ctx context.Context, spinID int64) error {
+	ok, err := s.spinRepo.MarkRedeemed(ctx, spinID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrVoucherStatusConflict
+	}
+	return nil
+}
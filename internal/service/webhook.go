@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/repository"
+)
+
+const (
+	// WebhookMaxAttempts — после стольких неудачных попыток доставка помечается failed.
+	WebhookMaxAttempts = 10
+
+	// webhookLease — на сколько доставка откладывается, пока её отправляет один обработчик.
+	webhookLease = 2 * time.Minute
+
+	// webhookRetention — сколько хранить успешные доставки в журнале.
+	webhookRetention = 30 * 24 * time.Hour
+)
+
+// WebhookPayload — тело исходящего webhook. EventID одинаков для всех URL и повторов,
+// по нему получатель отбрасывает дубли.
+type WebhookPayload struct {
+	EventID    string              `json:"event_id"`
+	Event      domain.WebhookEvent `json:"event"`
+	OccurredAt time.Time           `json:"occurred_at"`
+	Data       WebhookData         `json:"data"`
+}
+
+type WebhookData struct {
+	User       *WebhookUser `json:"user"`
+	Spin       *WebhookSpin `json:"spin,omitempty"`
+	RedeemedBy string       `json:"redeemed_by,omitempty"`
+}
+
+type WebhookUser struct {
+	ID             int64  `json:"id"`
+	TelegramUserID int64  `json:"telegram_user_id"`
+	Phone          string `json:"phone"`
+	FirstName      string `json:"first_name,omitempty"`
+	LastName       string `json:"last_name,omitempty"`
+	Username       string `json:"username,omitempty"`
+	Campaign       string `json:"campaign,omitempty"`
+	StartParam     string `json:"start_param,omitempty"`
+}
+
+type WebhookSpin struct {
+	ID          int64      `json:"id"`
+	PrizeID     int        `json:"prize_id"`
+	PrizeName   string     `json:"prize_name"`
+	PrizeType   string     `json:"prize_type"`
+	Value       float64    `json:"value"`
+	Status      string     `json:"status"`
+	VoucherCode string     `json:"voucher_code,omitempty"`
+	Campaign    string     `json:"campaign,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	RedeemedAt  *time.Time `json:"redeemed_at,omitempty"`
+}
+
+// WebhookService ставит события в очередь исходящих webhook (migrations/015_create_webhook_deliveries.sql)
+// и ведёт журнал доставки. Без URL события не записываются.
+type WebhookService struct {
+	webhookRepo *repository.WebhookRepository
+	urls        []string
+	events      map[domain.WebhookEvent]bool
+}
+
+// NewWebhookService проверяет настройки: urls — адреса получателей (WEBHOOK_URLS),
+// events — имена событий (WEBHOOK_EVENTS, пусто — все события).
+func NewWebhookService(webhookRepo *repository.WebhookRepository, urls, events []string) (*WebhookService, error) {
+	for _, u := range urls {
+		parsed, err := url.Parse(u)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("WEBHOOK_URLS: %q is not an http(s) URL", u)
+		}
+	}
+	enabled := make(map[domain.WebhookEvent]bool)
+	for _, name := range events {
+		e, ok := domain.ParseWebhookEvent(name)
+		if !ok {
+			return nil, fmt.Errorf("WEBHOOK_EVENTS: unknown event %q", name)
+		}
+		enabled[e] = true
+	}
+	if len(enabled) == 0 {
+		for _, e := range domain.WebhookEvents {
+			enabled[e] = true
+		}
+	}
+	return &WebhookService{webhookRepo: webhookRepo, urls: urls, events: enabled}, nil
+}
+
+// Enabled сообщает, что есть хотя бы один URL для отправки.
+func (s *WebhookService) Enabled() bool {
+	return s != nil && len(s.urls) > 0
+}
+
+// UserRegistered — пользователь поделился номером телефона.
+func (s *WebhookService) UserRegistered(ctx context.Context, user *domain.User) {
+	s.emit(ctx, domain.WebhookUserRegistered, WebhookData{User: webhookUser(user)})
+}
+
+// SpinCreated — пользователь прокрутил рулетку.
+func (s *WebhookService) SpinCreated(ctx context.Context, user *domain.User, spin *domain.SpinWithPrize) {
+	s.emit(ctx, domain.WebhookSpinCreated, WebhookData{User: webhookUser(user), Spin: webhookSpin(spin)})
+}
+
+// VoucherRedeemed — менеджер отметил ваучер погашенным; redeemedBy — кто отметил.
+func (s *WebhookService) VoucherRedeemed(ctx context.Context, user *domain.User, spin *domain.SpinWithPrize, redeemedBy string) {
+	s.emit(ctx, domain.WebhookVoucherRedeemed, WebhookData{User: webhookUser(user), Spin: webhookSpin(spin), RedeemedBy: redeemedBy})
+}
+
+// emit ставит событие в очередь для каждого URL. Ошибки только логируются: webhook не должен
+// ломать основной сценарий (как FunnelService.Track).
+func (s *WebhookService) emit(ctx context.Context, e domain.WebhookEvent, data WebhookData) {
+	if !s.Enabled() || !s.events[e] {
+		return
+	}
+	eventID, err := newEventID()
+	if err != nil {
+		slog.ErrorContext(ctx, "generate webhook event id failed", "error", err)
+		return
+	}
+	payload, err := json.Marshal(WebhookPayload{EventID: eventID, Event: e, OccurredAt: time.Now().UTC(), Data: data})
+	if err != nil {
+		slog.ErrorContext(ctx, "encode webhook payload failed", "error", err, "event", string(e))
+		return
+	}
+	for _, u := range s.urls {
+		d := &domain.WebhookDelivery{EventID: eventID, Event: e, URL: u, Payload: payload}
+		if err := s.webhookRepo.Enqueue(ctx, d); err != nil {
+			slog.ErrorContext(ctx, "enqueue webhook failed", "error", err, "event", string(e), "url", u)
+		}
+	}
+}
+
+func newEventID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func webhookUser(u *domain.User) *WebhookUser {
+	return &WebhookUser{
+		ID:             u.ID,
+		TelegramUserID: u.TelegramUserID,
+		Phone:          u.Phone,
+		FirstName:      u.FirstName,
+		LastName:       u.LastName,
+		Username:       u.Username,
+		Campaign:       u.Attribution.Campaign,
+		StartParam:     u.Attribution.StartParam,
+	}
+}
+
+func webhookSpin(s *domain.SpinWithPrize) *WebhookSpin {
+	ws := &WebhookSpin{
+		ID:          s.ID,
+		PrizeID:     s.PrizeID,
+		Value:       s.ResultValue,
+		Status:      string(s.Status),
+		VoucherCode: s.VoucherCode,
+		Campaign:    s.Campaign,
+		CreatedAt:   s.CreatedAt,
+		ExpiresAt:   s.ExpiresAt,
+		RedeemedAt:  s.RedeemedAt,
+	}
+	if s.Prize != nil {
+		ws.PrizeName = s.Prize.Name
+		ws.PrizeType = s.Prize.Type
+	}
+	return ws
+}
+
+// Claim забирает до limit доставок, готовых к отправке.
+func (s *WebhookService) Claim(ctx context.Context, limit int) ([]domain.WebhookDelivery, error) {
+	return s.webhookRepo.Claim(ctx, limit, webhookLease)
+}
+
+func (s *WebhookService) MarkDelivered(ctx context.Context, id int64, responseCode int) error {
+	return s.webhookRepo.MarkDelivered(ctx, id, responseCode)
+}
+
+// Retry планирует повтор с экспоненциальной задержкой (или через retryAfter из ответа получателя).
+// После WebhookMaxAttempts попыток доставка помечается failed; возвращает true, если повтор запланирован.
+func (s *WebhookService) Retry(ctx context.Context, d domain.WebhookDelivery, retryAfter time.Duration, responseCode int, cause error) (bool, error) {
+	if d.Attempts >= WebhookMaxAttempts {
+		return false, s.webhookRepo.MarkFailed(ctx, d.ID, responseCode, cause.Error())
+	}
+	delay := retryAfter
+	if delay <= 0 {
+		delay = time.Duration(1<<d.Attempts) * 15 * time.Second
+	}
+	return true, s.webhookRepo.Retry(ctx, d.ID, time.Now().Add(delay), responseCode, cause.Error())
+}
+
+// Fail помечает доставку неудачной без повторов.
+func (s *WebhookService) Fail(ctx context.Context, id int64, responseCode int, cause error) error {
+	return s.webhookRepo.MarkFailed(ctx, id, responseCode, cause.Error())
+}
+
+// List возвращает журнал доставок, новые первыми; пустой status — все.
+func (s *WebhookService) List(ctx context.Context, status domain.WebhookStatus, limit int) ([]domain.WebhookDelivery, error) {
+	return s.webhookRepo.List(ctx, status, limit)
+}
+
+// Cleanup удаляет из журнала успешные доставки старше срока хранения.
+func (s *WebhookService) Cleanup(ctx context.Context) (int64, error) {
+	return s.webhookRepo.DeleteDeliveredBefore(ctx, time.Now().Add(-webhookRetention))
+}
@@ -0,0 +1,42 @@
+package session
+
+import (
+	"sync"
+	"time"
+)
+
+// NonceStore запоминает использованные nonce на время ttl, чтобы один и тот же
+// запрос на спин нельзя было повторить. Хранится в памяти процесса.
+type NonceStore struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	lastSweep time.Time
+}
+
+func NewNonceStore(ttl time.Duration) *NonceStore {
+	return &NonceStore{ttl: ttl, seen: make(map[string]time.Time)}
+}
+
+// Use помечает nonce использованным. Возвращает false, если он уже встречался.
+func (s *NonceStore) Use(key string) bool {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) > s.ttl {
+		for k, exp := range s.seen {
+			if now.After(exp) {
+				delete(s.seen, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	if exp, ok := s.seen[key]; ok && now.Before(exp) {
+		return false
+	}
+	s.seen[key] = now.Add(s.ttl)
+	return true
+}
@@ -0,0 +1,190 @@
+package session
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const issuer = "era_sporta_api"
+
+// Типы токенов: access предъявляется на защищённых эндпоинтах, refresh — только на /api/auth/refresh.
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid session token")
+	// ErrTokenReused — refresh-токен уже обменян на новую пару: повтор означает утечку или гонку.
+	ErrTokenReused = errors.New("refresh token already used")
+)
+
+// RefreshStore запоминает использованные refresh-токены. Хранилище общее для всех
+// процессов API, иначе токен можно обменять повторно на другом экземпляре.
+type RefreshStore interface {
+	// UseRefreshToken помечает токен jti использованным; false — если он уже был использован.
+	UseRefreshToken(ctx context.Context, jti string, telegramUserID int64, expiresAt time.Time) (bool, error)
+}
+
+// Identity — то, что /api/auth знает о пользователе после проверки initData.
+type Identity struct {
+	TelegramUserID int64
+	UserID         int64
+	PhoneVerified  bool
+	StartParam     string
+}
+
+// Claims — содержимое сессионного токена Mini App.
+type Claims struct {
+	TelegramUserID int64  `json:"tid"`
+	UserID         int64  `json:"uid"`
+	PhoneVerified  bool   `json:"pv"`
+	StartParam     string `json:"sp,omitempty"`
+	Type           string `json:"typ"`
+	jwt.RegisteredClaims
+}
+
+// Identity возвращает данные пользователя из токена.
+func (c *Claims) Identity() Identity {
+	return Identity{
+		TelegramUserID: c.TelegramUserID,
+		UserID:         c.UserID,
+		PhoneVerified:  c.PhoneVerified,
+		StartParam:     c.StartParam,
+	}
+}
+
+// Tokens — пара access/refresh, которую получает Mini App.
+type Tokens struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// Manager выпускает и проверяет сессионные токены (JWT, HS256), которые /api/auth
+// выдаёт после проверки initData: короткоживущий access и refresh для его продления.
+// Refresh-токены одноразовые: у каждого свой jti, и при обмене он отмечается в refreshes.
+type Manager struct {
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	refreshes  RefreshStore
+}
+
+// NewManager создаёт менеджер. Если secret пуст, ключ выводится из токена бота,
+// чтобы сессии работали без отдельной настройки.
+func NewManager(secret, botToken string, accessTTL, refreshTTL time.Duration, refreshes RefreshStore) *Manager {
+	key := []byte(secret)
+	if len(key) == 0 {
+		mac := hmac.New(sha256.New, []byte("SessionKey"))
+		mac.Write([]byte(botToken))
+		key = mac.Sum(nil)
+	}
+	return &Manager{secret: key, accessTTL: accessTTL, refreshTTL: refreshTTL, refreshes: refreshes}
+}
+
+// TTL возвращает время жизни access-токенов.
+func (m *Manager) TTL() time.Duration {
+	return m.accessTTL
+}
+
+// Issue выпускает пару токенов. StartParam сохраняется в токенах,
+// чтобы источник из ссылки startapp был доступен и после обмена initData на токен.
+func (m *Manager) Issue(id Identity) (*Tokens, error) {
+	now := time.Now()
+	access, accessExp, err := m.sign(id, TokenTypeAccess, now, m.accessTTL)
+	if err != nil {
+		return nil, err
+	}
+	refresh, refreshExp, err := m.sign(id, TokenTypeRefresh, now, m.refreshTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &Tokens{
+		AccessToken:      access,
+		AccessExpiresAt:  accessExp,
+		RefreshToken:     refresh,
+		RefreshExpiresAt: refreshExp,
+	}, nil
+}
+
+func (m *Manager) sign(id Identity, typ string, now time.Time, ttl time.Duration) (string, time.Time, error) {
+	expiresAt := now.Add(ttl)
+	var jti string
+	if typ == TokenTypeRefresh {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return "", time.Time{}, fmt.Errorf("generate token id: %w", err)
+		}
+		jti = hex.EncodeToString(b)
+	}
+	claims := Claims{
+		TelegramUserID: id.TelegramUserID,
+		UserID:         id.UserID,
+		PhoneVerified:  id.PhoneVerified,
+		StartParam:     id.StartParam,
+		Type:           typ,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   strconv.FormatInt(id.TelegramUserID, 10),
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("sign session token: %w", err)
+	}
+	return token, expiresAt, nil
+}
+
+// ParseAccess проверяет access-токен.
+func (m *Manager) ParseAccess(token string) (*Claims, error) {
+	return m.parse(token, TokenTypeAccess)
+}
+
+// ParseRefresh проверяет refresh-токен и помечает его использованным: повторный
+// обмен того же токена возвращает ErrTokenReused.
+func (m *Manager) ParseRefresh(ctx context.Context, token string) (*Claims, error) {
+	claims, err := m.parse(token, TokenTypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+	if claims.ID == "" {
+		return nil, ErrInvalidToken
+	}
+	fresh, err := m.refreshes.UseRefreshToken(ctx, claims.ID, claims.TelegramUserID, claims.ExpiresAt.Time)
+	if err != nil {
+		return nil, fmt.Errorf("mark refresh token used: %w", err)
+	}
+	if !fresh {
+		return nil, ErrTokenReused
+	}
+	return claims, nil
+}
+
+func (m *Manager) parse(token, typ string) (*Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		return m.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || claims.TelegramUserID == 0 || claims.Type != typ {
+		return nil, ErrInvalidToken
+	}
+	return &claims, nil
+}
@@ -0,0 +1,227 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "test-session-secret"
+
+// memoryRefreshStore — RefreshStore в памяти, как refresh_token_uses в тестах без БД.
+type memoryRefreshStore struct {
+	used map[string]bool
+	err  error
+}
+
+func (s *memoryRefreshStore) UseRefreshToken(_ context.Context, jti string, _ int64, _ time.Time) (bool, error) {
+	if s.err != nil {
+		return false, s.err
+	}
+	if s.used[jti] {
+		return false, nil
+	}
+	s.used[jti] = true
+	return true, nil
+}
+
+func newTestManager(secret string, accessTTL, refreshTTL time.Duration) (*Manager, *memoryRefreshStore) {
+	store := &memoryRefreshStore{used: make(map[string]bool)}
+	return NewManager(secret, "123456:test-bot-token", accessTTL, refreshTTL, store), store
+}
+
+var testIdentity = Identity{TelegramUserID: 42, UserID: 7, PhoneVerified: true, StartParam: "promo"}
+
+// TestIssueAndParse: выпущенные токены проверяются и сохраняют данные пользователя.
+func TestIssueAndParse(t *testing.T) {
+	m, _ := newTestManager(testSecret, time.Hour, 24*time.Hour)
+	tokens, err := m.Issue(testIdentity)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	access, err := m.ParseAccess(tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("ParseAccess: %v", err)
+	}
+	if access.Identity() != testIdentity {
+		t.Fatalf("access identity = %+v, want %+v", access.Identity(), testIdentity)
+	}
+
+	refresh, err := m.ParseRefresh(context.Background(), tokens.RefreshToken)
+	if err != nil {
+		t.Fatalf("ParseRefresh: %v", err)
+	}
+	if refresh.Identity() != testIdentity {
+		t.Fatalf("refresh identity = %+v, want %+v", refresh.Identity(), testIdentity)
+	}
+	if refresh.ID == "" {
+		t.Fatal("refresh token has no jti")
+	}
+}
+
+// TestRefreshReuse: refresh-токен обменивается один раз, у каждой пары свой jti.
+func TestRefreshReuse(t *testing.T) {
+	m, _ := newTestManager(testSecret, time.Hour, 24*time.Hour)
+	first, err := m.Issue(testIdentity)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	second, err := m.Issue(testIdentity)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := m.ParseRefresh(ctx, first.RefreshToken); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	if _, err := m.ParseRefresh(ctx, first.RefreshToken); !errors.Is(err, ErrTokenReused) {
+		t.Fatalf("second use: err = %v, want ErrTokenReused", err)
+	}
+	if _, err := m.ParseRefresh(ctx, second.RefreshToken); err != nil {
+		t.Fatalf("other token: %v", err)
+	}
+}
+
+// TestRefreshStoreError: ошибка хранилища не превращается в успешный обмен.
+func TestRefreshStoreError(t *testing.T) {
+	m, store := newTestManager(testSecret, time.Hour, 24*time.Hour)
+	tokens, err := m.Issue(testIdentity)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	store.err = errors.New("db down")
+	if _, err := m.ParseRefresh(context.Background(), tokens.RefreshToken); err == nil || errors.Is(err, ErrTokenReused) {
+		t.Fatalf("err = %v, want store error", err)
+	}
+}
+
+// TestTokenTypeConfusion: access не принимается вместо refresh и наоборот.
+func TestTokenTypeConfusion(t *testing.T) {
+	m, store := newTestManager(testSecret, time.Hour, 24*time.Hour)
+	tokens, err := m.Issue(testIdentity)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if _, err := m.ParseRefresh(context.Background(), tokens.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("access as refresh: err = %v, want ErrInvalidToken", err)
+	}
+	if len(store.used) != 0 {
+		t.Fatal("access token was recorded as a used refresh token")
+	}
+	if _, err := m.ParseAccess(tokens.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("refresh as access: err = %v, want ErrInvalidToken", err)
+	}
+}
+
+// TestExpiredToken: истёкшие access и refresh отклоняются.
+func TestExpiredToken(t *testing.T) {
+	m, _ := newTestManager(testSecret, -time.Minute, -time.Minute)
+	tokens, err := m.Issue(testIdentity)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if _, err := m.ParseAccess(tokens.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("access: err = %v, want ErrInvalidToken", err)
+	}
+	if _, err := m.ParseRefresh(context.Background(), tokens.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("refresh: err = %v, want ErrInvalidToken", err)
+	}
+}
+
+// TestWrongKey: токен, подписанный другим ключом, отклоняется; ключ по умолчанию зависит от токена бота.
+func TestWrongKey(t *testing.T) {
+	m, _ := newTestManager(testSecret, time.Hour, 24*time.Hour)
+	tokens, err := m.Issue(testIdentity)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	other, _ := newTestManager("other-secret", time.Hour, 24*time.Hour)
+	if _, err := other.ParseAccess(tokens.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("other secret: err = %v, want ErrInvalidToken", err)
+	}
+
+	derived := NewManager("", "123456:bot-a", time.Hour, time.Hour, nil)
+	tokens, err = derived.Issue(testIdentity)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if _, err := NewManager("", "123456:bot-a", time.Hour, time.Hour, nil).ParseAccess(tokens.AccessToken); err != nil {
+		t.Fatalf("same bot token: %v", err)
+	}
+	if _, err := NewManager("", "123456:bot-b", time.Hour, time.Hour, nil).ParseAccess(tokens.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("other bot token: err = %v, want ErrInvalidToken", err)
+	}
+}
+
+// TestForgedClaims: токены с чужим алгоритмом или неполными claims отклоняются, даже подписанные нашим ключом.
+func TestForgedClaims(t *testing.T) {
+	m, _ := newTestManager(testSecret, time.Hour, 24*time.Hour)
+	now := time.Now()
+	valid := func(typ string) Claims {
+		return Claims{
+			TelegramUserID: testIdentity.TelegramUserID,
+			UserID:         testIdentity.UserID,
+			Type:           typ,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    issuer,
+				Subject:   strconv.FormatInt(testIdentity.TelegramUserID, 10),
+				ID:        "forged-jti",
+				IssuedAt:  jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			},
+		}
+	}
+	sign := func(method jwt.SigningMethod, key interface{}, claims Claims) string {
+		token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		return token
+	}
+
+	if _, err := m.ParseAccess(sign(jwt.SigningMethodHS256, []byte(testSecret), valid(TokenTypeAccess))); err != nil {
+		t.Fatalf("control token rejected: %v", err)
+	}
+
+	noExpiry := valid(TokenTypeAccess)
+	noExpiry.ExpiresAt = nil
+	otherIssuer := valid(TokenTypeAccess)
+	otherIssuer.Issuer = "someone_else"
+	noUser := valid(TokenTypeAccess)
+	noUser.TelegramUserID = 0
+	noJTI := valid(TokenTypeRefresh)
+	noJTI.ID = ""
+
+	cases := []struct {
+		name    string
+		token   string
+		refresh bool
+	}{
+		{name: "HS512", token: sign(jwt.SigningMethodHS512, []byte(testSecret), valid(TokenTypeAccess))},
+		{name: "alg none", token: sign(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, valid(TokenTypeAccess))},
+		{name: "no expiry", token: sign(jwt.SigningMethodHS256, []byte(testSecret), noExpiry)},
+		{name: "other issuer", token: sign(jwt.SigningMethodHS256, []byte(testSecret), otherIssuer)},
+		{name: "no telegram user", token: sign(jwt.SigningMethodHS256, []byte(testSecret), noUser)},
+		{name: "refresh without jti", token: sign(jwt.SigningMethodHS256, []byte(testSecret), noJTI), refresh: true},
+		{name: "garbage", token: "not.a.token"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var err error
+			if tc.refresh {
+				_, err = m.ParseRefresh(context.Background(), tc.token)
+			} else {
+				_, err = m.ParseAccess(tc.token)
+			}
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Fatalf("err = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
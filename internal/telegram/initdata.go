@@ -1,8 +1,10 @@
 package telegram
 
 import (
+	"crypto/ed25519"
 	"crypto/hmac"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -16,25 +18,106 @@ import (
 // InitDataUser represents user data from Telegram WebApp initData
 type InitDataUser struct {
 	ID           int64  `json:"id"`
+	IsBot        bool   `json:"is_bot"`
 	FirstName    string `json:"first_name"`
 	LastName     string `json:"last_name"`
 	Username     string `json:"username"`
 	LanguageCode string `json:"language_code"`
 	IsPremium    bool   `json:"is_premium"`
+	PhotoURL     string `json:"photo_url"`
 }
 
-// ValidateResult contains validation result and extracted user
+// InitDataChat — чат, из которого Mini App открыто через attachment menu.
+type InitDataChat struct {
+	ID       int64  `json:"id"`
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Username string `json:"username"`
+	PhotoURL string `json:"photo_url"`
+}
+
+// Схемы подписи initData.
+const (
+	SchemeHMAC    = "hmac"
+	SchemeEd25519 = "ed25519"
+)
+
+// ValidateResult contains validation result and all parsed initData fields
 type ValidateResult struct {
-	Valid bool
-	User  *InitDataUser
+	Valid  bool
+	Scheme string
+
+	User         *InitDataUser
+	Receiver     *InitDataUser
+	Chat         *InitDataChat
+	ChatType     string
+	ChatInstance string
+	StartParam   string
+	QueryID      string
+	CanSendAfter int
+	AuthDate     time.Time
+}
+
+// DefaultMaxInitDataAge — срок годности initData, если maxAge не задан.
+const DefaultMaxInitDataAge = 24 * time.Hour
+
+// Публичные ключи Telegram для проверки Ed25519-подписи initData (third-party validation).
+const (
+	productionPublicKeyHex = "e7bf03a2fa4602af4580703d88dda5bb59f32ed8b02a56c187fe7d34caed242d"
+	testPublicKeyHex       = "40055058a4ee38156a06562e52eece92a771bcd8346a8c4615cb7376eddf72ec"
+)
+
+// Validator проверяет initData по обеим схемам Telegram:
+// HMAC-SHA256 на токене бота (поле hash) и Ed25519 (поле signature) —
+// вторая не требует токена, только ID бота.
+type Validator struct {
+	botToken  string
+	botID     int64
+	publicKey ed25519.PublicKey
+	maxAge    time.Duration
+}
+
+// NewValidator создаёт валидатор для нашего бота. ID бота берётся из токена.
+// testEnv включает публичный ключ тестового окружения Telegram. maxAge <= 0 means DefaultMaxInitDataAge.
+func NewValidator(botToken string, maxAge time.Duration, testEnv bool) *Validator {
+	botID, _ := BotIDFromToken(botToken)
+	v := NewThirdPartyValidator(botID, maxAge, testEnv)
+	v.botToken = botToken
+	return v
+}
+
+// NewThirdPartyValidator создаёт валидатор только для Ed25519-подписи — для initData чужого бота.
+func NewThirdPartyValidator(botID int64, maxAge time.Duration, testEnv bool) *Validator {
+	if maxAge <= 0 {
+		maxAge = DefaultMaxInitDataAge
+	}
+	keyHex := productionPublicKeyHex
+	if testEnv {
+		keyHex = testPublicKeyHex
+	}
+	key, _ := hex.DecodeString(keyHex)
+	return &Validator{botID: botID, publicKey: ed25519.PublicKey(key), maxAge: maxAge}
 }
 
-const maxInitDataAge = 24 * time.Hour
+// BotIDFromToken возвращает числовой ID бота — часть токена до двоеточия.
+func BotIDFromToken(botToken string) (int64, error) {
+	idPart, _, ok := strings.Cut(botToken, ":")
+	if !ok {
+		return 0, fmt.Errorf("malformed bot token")
+	}
+	return strconv.ParseInt(idPart, 10, 64)
+}
 
 // ValidateInitData validates Telegram WebApp initData per official docs
 // https://core.telegram.org/bots/webapps#validating-data-received-via-the-mini-app
-func ValidateInitData(initData string, botToken string) (*ValidateResult, error) {
-	if initData == "" || botToken == "" {
+// maxAge <= 0 means DefaultMaxInitDataAge.
+func ValidateInitData(initData string, botToken string, maxAge time.Duration) (*ValidateResult, error) {
+	return NewValidator(botToken, maxAge, false).Validate(initData)
+}
+
+// Validate проверяет подпись (HMAC, затем Ed25519), срок годности и разбирает поля initData.
+func (v *Validator) Validate(initData string) (*ValidateResult, error) {
+	if initData == "" {
 		return &ValidateResult{Valid: false}, nil
 	}
 
@@ -42,63 +125,123 @@ func ValidateInitData(initData string, botToken string) (*ValidateResult, error)
 	if err != nil {
 		return nil, fmt.Errorf("parse initData: %w", err)
 	}
+	// Повторённый ключ: подпись считается по первому значению, а потребитель может взять другое
+	for _, vs := range values {
+		if len(vs) > 1 {
+			return &ValidateResult{Valid: false}, nil
+		}
+	}
 
-	hash := values.Get("hash")
-	if hash == "" {
+	var scheme string
+	switch {
+	case v.checkHMAC(values):
+		scheme = SchemeHMAC
+	case v.checkEd25519(values):
+		scheme = SchemeEd25519
+	default:
 		return &ValidateResult{Valid: false}, nil
 	}
-	values.Del("hash")
 
-	// Build data_check_string: sorted key=value pairs joined by \n
-	var keys []string
-	for k := range values {
-		keys = append(keys, k)
+	// Check auth_date (not older than maxAge)
+	authDateStr := values.Get("auth_date")
+	if authDateStr == "" {
+		return &ValidateResult{Valid: false}, nil
+	}
+	authDateUnix, err := strconv.ParseInt(authDateStr, 10, 64)
+	if err != nil {
+		return &ValidateResult{Valid: false}, nil
+	}
+	authDate := time.Unix(authDateUnix, 0)
+	if time.Since(authDate) > v.maxAge {
+		return &ValidateResult{Valid: false}, nil
 	}
-	sort.Strings(keys)
 
-	var parts []string
-	for _, k := range keys {
-		parts = append(parts, k+"="+values.Get(k))
+	result := &ValidateResult{
+		Valid:        true,
+		Scheme:       scheme,
+		ChatType:     values.Get("chat_type"),
+		ChatInstance: values.Get("chat_instance"),
+		StartParam:   values.Get("start_param"),
+		QueryID:      values.Get("query_id"),
+		AuthDate:     authDate,
+	}
+	if s := values.Get("can_send_after"); s != "" {
+		result.CanSendAfter, _ = strconv.Atoi(s)
+	}
+	if err := unmarshalField(values, "user", &result.User); err != nil {
+		return &ValidateResult{Valid: false}, nil
+	}
+	if err := unmarshalField(values, "receiver", &result.Receiver); err != nil {
+		return &ValidateResult{Valid: false}, nil
+	}
+	if err := unmarshalField(values, "chat", &result.Chat); err != nil {
+		return &ValidateResult{Valid: false}, nil
+	}
+	return result, nil
+}
+
+// checkHMAC: secret_key = HMAC-SHA256("WebAppData", bot_token),
+// hash = HMAC-SHA256(data_check_string, secret_key); в строку входят все поля, кроме hash.
+func (v *Validator) checkHMAC(values url.Values) bool {
+	hash := values.Get("hash")
+	if hash == "" || v.botToken == "" {
+		return false
 	}
-	dataCheckString := strings.Join(parts, "\n")
 
-	// secret_key = HMAC-SHA256("WebAppData", bot_token)
 	secretKey := hmac.New(sha256.New, []byte("WebAppData"))
-	secretKey.Write([]byte(botToken))
+	secretKey.Write([]byte(v.botToken))
 	secretKeyHash := secretKey.Sum(nil)
 
-	// hash = HMAC-SHA256(data_check_string, secret_key)
 	h := hmac.New(sha256.New, secretKeyHash)
-	h.Write([]byte(dataCheckString))
+	h.Write([]byte(dataCheckString(values, "hash")))
 	expectedHash := hex.EncodeToString(h.Sum(nil))
 
-	if hash != expectedHash {
-		return &ValidateResult{Valid: false}, nil
-	}
+	return hmac.Equal([]byte(hash), []byte(expectedHash))
+}
 
-	// Check auth_date (not older than 24h)
-	authDateStr := values.Get("auth_date")
-	if authDateStr == "" {
-		return &ValidateResult{Valid: false}, nil
+// checkEd25519: подпись signature (base64url) над "<bot_id>:WebAppData\n" + data_check_string
+// без полей hash и signature.
+func (v *Validator) checkEd25519(values url.Values) bool {
+	signature := values.Get("signature")
+	if signature == "" || v.botID == 0 || len(v.publicKey) != ed25519.PublicKeySize {
+		return false
 	}
-	authDate, err := strconv.ParseInt(authDateStr, 10, 64)
+	sig, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(signature, "="))
 	if err != nil {
-		return &ValidateResult{Valid: false}, nil
-	}
-	if time.Since(time.Unix(authDate, 0)) > maxInitDataAge {
-		return &ValidateResult{Valid: false}, nil
+		return false
 	}
+	message := strconv.FormatInt(v.botID, 10) + ":WebAppData\n" + dataCheckString(values, "hash", "signature")
+	return ed25519.Verify(v.publicKey, []byte(message), sig)
+}
 
-	// Parse user
-	userStr := values.Get("user")
-	if userStr == "" {
-		return &ValidateResult{Valid: true, User: nil}, nil
+// dataCheckString: sorted key=value pairs joined by \n, without excluded keys
+func dataCheckString(values url.Values, exclude ...string) string {
+	var keys []string
+	for k := range values {
+		skip := false
+		for _, e := range exclude {
+			if k == e {
+				skip = true
+				break
+			}
+		}
+		if !skip {
+			keys = append(keys, k)
+		}
 	}
+	sort.Strings(keys)
 
-	var user InitDataUser
-	if err := json.Unmarshal([]byte(userStr), &user); err != nil {
-		return &ValidateResult{Valid: false}, nil
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+values.Get(k))
 	}
+	return strings.Join(parts, "\n")
+}
 
-	return &ValidateResult{Valid: true, User: &user}, nil
+func unmarshalField(values url.Values, key string, dst interface{}) error {
+	raw := values.Get(key)
+	if raw == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(raw), dst)
 }
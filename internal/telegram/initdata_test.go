@@ -0,0 +1,186 @@
+package telegram
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	// testBotToken — токен бота 7342037359; hmacInitData подписана им.
+	testBotToken = "7342037359:AAHtestTokenForInitDataValidation"
+
+	hmacInitData = "query_id=AAHdF6IQAAAAAN0XohDhrOrc&user=%7B%22id%22%3A279058397%2C%22first_name%22%3A%22Vladislav%22%2C%22username%22%3A%22vdkfrost%22%2C%22language_code%22%3A%22ru%22%7D&auth_date=1733584787&hash=324d038a257c3a0840d4218ab5f366983916cec3a042172c5d950ac0e024adc3"
+
+	// ed25519InitData — настоящая initData бота 7342037359, подписанная production-ключом Telegram.
+	// Её hash посчитан на неизвестном нам токене, поэтому проходит только Ed25519.
+	ed25519InitData = "user=%7B%22id%22%3A279058397%2C%22first_name%22%3A%22Vladislav%20%2B%20-%20%3F%20%5C%2F%22%2C%22last_name%22%3A%22Kibenko%22%2C%22username%22%3A%22vdkfrost%22%2C%22language_code%22%3A%22ru%22%2C%22is_premium%22%3Atrue%2C%22allows_write_to_pm%22%3Atrue%2C%22photo_url%22%3A%22https%3A%5C%2F%5C%2Ft.me%5C%2Fi%5C%2Fuserpic%5C%2F320%5C%2F4FPEE4tmP3ATHa57u6MqTDih13LTOiMoKoLDRG4PnSA.svg%22%7D&chat_instance=8134722200314281151&chat_type=private&auth_date=1733584787&hash=2174df5b000556d044f3f020384e879c8efcab55ddea2ced4eb752e93e7080d6&signature=zL-ucjNyREiHDE8aihFwpfR9aggP2xiAo3NSpfe-p7IbCisNlDKlo7Kb6G4D0Ao2mBrSgEk4maLSdv6MLIlADQ"
+)
+
+// sampleMaxAge покрывает возраст образцов выше (auth_date — декабрь 2024).
+const sampleMaxAge = 100 * 365 * 24 * time.Hour
+
+// TestValidate проверяет обе схемы подписи на известных образцах и их порче.
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name       string
+		initData   string
+		maxAge     time.Duration
+		wantValid  bool
+		wantScheme string
+	}{
+		{name: "hmac", initData: hmacInitData, maxAge: sampleMaxAge, wantValid: true, wantScheme: SchemeHMAC},
+		{name: "ed25519", initData: ed25519InitData, maxAge: sampleMaxAge, wantValid: true, wantScheme: SchemeEd25519},
+
+		{name: "hmac tampered field", initData: strings.Replace(hmacInitData, "Vladislav", "Vladimir", 1), maxAge: sampleMaxAge},
+		{name: "ed25519 tampered field", initData: strings.Replace(ed25519InitData, "chat_type=private", "chat_type=group", 1), maxAge: sampleMaxAge},
+		{name: "ed25519 added field", initData: ed25519InitData + "&start_param=promo", maxAge: sampleMaxAge},
+		{name: "hmac tampered hash", initData: hmacInitData[:len(hmacInitData)-1] + "4", maxAge: sampleMaxAge},
+		{name: "ed25519 malformed signature", initData: strings.Replace(ed25519InitData, "signature=zL-", "signature=zL+", 1), maxAge: sampleMaxAge},
+
+		{name: "hash missing", initData: withoutParam(hmacInitData, "hash"), maxAge: sampleMaxAge},
+		{name: "signature missing", initData: withoutParam(ed25519InitData, "signature"), maxAge: sampleMaxAge},
+		{name: "hash duplicated", initData: hmacInitData + "&hash=" + param(hmacInitData, "hash"), maxAge: sampleMaxAge},
+		{name: "signature duplicated", initData: ed25519InitData + "&signature=" + param(ed25519InitData, "signature"), maxAge: sampleMaxAge},
+		{name: "field duplicated", initData: hmacInitData + "&user=%7B%22id%22%3A1%7D", maxAge: sampleMaxAge},
+
+		{name: "hmac expired", initData: hmacInitData, maxAge: time.Hour},
+		{name: "ed25519 expired", initData: ed25519InitData, maxAge: time.Hour},
+		{name: "empty", initData: "", maxAge: sampleMaxAge},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := NewValidator(testBotToken, tc.maxAge, false).Validate(tc.initData)
+			if err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			if res.Valid != tc.wantValid {
+				t.Fatalf("Valid = %v, want %v", res.Valid, tc.wantValid)
+			}
+			if res.Scheme != tc.wantScheme {
+				t.Fatalf("Scheme = %q, want %q", res.Scheme, tc.wantScheme)
+			}
+		})
+	}
+}
+
+// TestValidateEd25519Environment: подпись production-ключом не принимается с ключом тестового окружения
+// и не принимается для другого бота.
+func TestValidateEd25519Environment(t *testing.T) {
+	cases := []struct {
+		name      string
+		botID     int64
+		testEnv   bool
+		wantValid bool
+	}{
+		{name: "production", botID: 7342037359, wantValid: true},
+		{name: "test environment", botID: 7342037359, testEnv: true},
+		{name: "other bot", botID: 7342037358},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := NewThirdPartyValidator(tc.botID, sampleMaxAge, tc.testEnv).Validate(ed25519InitData)
+			if err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			if res.Valid != tc.wantValid {
+				t.Fatalf("Valid = %v, want %v", res.Valid, tc.wantValid)
+			}
+		})
+	}
+}
+
+// TestValidateFields проверяет разбор полей подписанной initData.
+func TestValidateFields(t *testing.T) {
+	authDate := time.Now().Add(-time.Minute).Truncate(time.Second)
+	values := url.Values{
+		"query_id":       {"AAHdF6IQAAAAAN0XohDhrOrc"},
+		"user":           {`{"id":42,"first_name":"Анна","username":"anna","language_code":"ru","is_premium":true}`},
+		"receiver":       {`{"id":77,"first_name":"Борис","is_bot":false}`},
+		"chat":           {`{"id":-100123,"type":"supergroup","title":"Эра Спорта"}`},
+		"chat_type":      {"supergroup"},
+		"chat_instance":  {"-7425069347458914036"},
+		"start_param":    {"promo_april"},
+		"can_send_after": {"15"},
+		"auth_date":      {strconv.FormatInt(authDate.Unix(), 10)},
+	}
+	values.Set("hash", signHMAC(values, testBotToken))
+
+	res, err := NewValidator(testBotToken, time.Hour, false).Validate(values.Encode())
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if !res.Valid || res.Scheme != SchemeHMAC {
+		t.Fatalf("Valid = %v, Scheme = %q", res.Valid, res.Scheme)
+	}
+	if res.QueryID != "AAHdF6IQAAAAAN0XohDhrOrc" {
+		t.Fatalf("QueryID = %q", res.QueryID)
+	}
+	if res.StartParam != "promo_april" {
+		t.Fatalf("StartParam = %q", res.StartParam)
+	}
+	if res.ChatType != "supergroup" || res.ChatInstance != "-7425069347458914036" {
+		t.Fatalf("ChatType = %q, ChatInstance = %q", res.ChatType, res.ChatInstance)
+	}
+	if res.CanSendAfter != 15 {
+		t.Fatalf("CanSendAfter = %d", res.CanSendAfter)
+	}
+	if !res.AuthDate.Equal(authDate) {
+		t.Fatalf("AuthDate = %v, want %v", res.AuthDate, authDate)
+	}
+	if res.User == nil || res.User.ID != 42 || res.User.FirstName != "Анна" || !res.User.IsPremium {
+		t.Fatalf("User = %+v", res.User)
+	}
+	if res.Receiver == nil || res.Receiver.ID != 77 || res.Receiver.FirstName != "Борис" {
+		t.Fatalf("Receiver = %+v", res.Receiver)
+	}
+	if res.Chat == nil || res.Chat.ID != -100123 || res.Chat.Type != "supergroup" || res.Chat.Title != "Эра Спорта" {
+		t.Fatalf("Chat = %+v", res.Chat)
+	}
+}
+
+// TestValidateMalformedJSONField: подписанное, но нечитаемое поле user делает initData недействительной.
+func TestValidateMalformedJSONField(t *testing.T) {
+	values := url.Values{
+		"user":      {`{"id":`},
+		"auth_date": {strconv.FormatInt(time.Now().Unix(), 10)},
+	}
+	values.Set("hash", signHMAC(values, testBotToken))
+
+	res, err := NewValidator(testBotToken, time.Hour, false).Validate(values.Encode())
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if res.Valid {
+		t.Fatal("Valid = true for malformed user")
+	}
+}
+
+func signHMAC(values url.Values, botToken string) string {
+	secret := hmac.New(sha256.New, []byte("WebAppData"))
+	secret.Write([]byte(botToken))
+	h := hmac.New(sha256.New, secret.Sum(nil))
+	h.Write([]byte(dataCheckString(values, "hash")))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func param(initData, key string) string {
+	values, _ := url.ParseQuery(initData)
+	return url.QueryEscape(values.Get(key))
+}
+
+func withoutParam(initData, key string) string {
+	var kept []string
+	for _, pair := range strings.Split(initData, "&") {
+		if !strings.HasPrefix(pair, key+"=") {
+			kept = append(kept, pair)
+		}
+	}
+	return strings.Join(kept, "&")
+}
@@ -0,0 +1,81 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+type getMeResponse struct {
+	Ok     bool `json:"ok"`
+	Result struct {
+		ID       int64  `json:"id"`
+		Username string `json:"username"`
+	} `json:"result"`
+	Description string `json:"description"`
+}
+
+// MeChecker проверяет валидность токена бота через getMe и кеширует результат,
+// чтобы частые /readyz не упирались в лимиты Telegram.
+type MeChecker struct {
+	botToken string
+	ttl      time.Duration
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	username  string
+	err       error
+}
+
+func NewMeChecker(botToken string, ttl time.Duration) *MeChecker {
+	return &MeChecker{botToken: botToken, ttl: ttl}
+}
+
+// Check возвращает username бота или ошибку последней проверки.
+func (m *MeChecker) Check(ctx context.Context) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.checkedAt.IsZero() && time.Since(m.checkedAt) < m.ttl {
+		return m.username, m.err
+	}
+	m.username, m.err = getMe(ctx, m.botToken)
+	m.checkedAt = time.Now()
+	return m.username, m.err
+}
+
+func getMe(ctx context.Context, botToken string) (string, error) {
+	if botToken == "" {
+		return "", fmt.Errorf("bot token is empty")
+	}
+	endpoint := fmt.Sprintf("https://api.telegram.org/bot%s/getMe", botToken)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		// url.Error содержит URL с токеном — не выносим его в ответ /readyz
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			return "", fmt.Errorf("getMe: %w", uerr.Err)
+		}
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var payload getMeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return "", err
+	}
+	if !payload.Ok {
+		return "", fmt.Errorf("telegram api error: %s", payload.Description)
+	}
+	return payload.Result.Username, nil
+}
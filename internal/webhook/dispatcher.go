@@ -0,0 +1,154 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"era_sporta_bot_ruletka/internal/domain"
+	"era_sporta_bot_ruletka/internal/metrics"
+	"era_sporta_bot_ruletka/internal/service"
+)
+
+const (
+	pollInterval    = 2 * time.Second
+	batchSize       = 20
+	cleanupInterval = time.Hour
+	requestTimeout  = 10 * time.Second
+	// maxErrorBody — сколько байт ответа получателя сохранять в журнал при ошибке.
+	maxErrorBody = 512
+)
+
+// Результаты доставки для метрик.
+const (
+	resultDelivered = "delivered"
+	resultRetry     = "retry"
+	resultFailed    = "failed"
+)
+
+// Dispatcher отправляет webhook из очереди с подписью и повторяет неудачные попытки с задержкой.
+type Dispatcher struct {
+	webhooks *service.WebhookService
+	secret   string
+	client   *http.Client
+}
+
+func NewDispatcher(webhooks *service.WebhookService, secret string) *Dispatcher {
+	return &Dispatcher{
+		webhooks: webhooks,
+		secret:   secret,
+		client:   &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// Run опрашивает очередь, пока не отменён ctx.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	var lastCleanup time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		d.dispatch(ctx)
+		if time.Since(lastCleanup) >= cleanupInterval {
+			lastCleanup = time.Now()
+			if n, err := d.webhooks.Cleanup(ctx); err != nil {
+				slog.WarnContext(ctx, "webhook log cleanup failed", "error", err)
+			} else if n > 0 {
+				slog.DebugContext(ctx, "webhook log cleaned up", "deleted", n)
+			}
+		}
+	}
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context) {
+	deliveries, err := d.webhooks.Claim(ctx, batchSize)
+	if err != nil {
+		slog.ErrorContext(ctx, "claim webhook deliveries failed", "error", err)
+		return
+	}
+	for _, dl := range deliveries {
+		if ctx.Err() != nil {
+			// Незабранные доставки вернутся в очередь по истечении аренды
+			return
+		}
+		d.deliver(ctx, dl)
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, dl domain.WebhookDelivery) {
+	ctx = context.WithoutCancel(ctx)
+	code, retryAfter, err := d.post(ctx, dl)
+	event := string(dl.Event)
+	if err == nil {
+		if err := d.webhooks.MarkDelivered(ctx, dl.ID, code); err != nil {
+			slog.ErrorContext(ctx, "mark webhook delivered failed", "error", err, "webhook_delivery_id", dl.ID)
+		}
+		metrics.WebhookDeliveries.WithLabelValues(event, resultDelivered).Inc()
+		return
+	}
+
+	// 410 Gone — получатель отказался от подписки, повторять бессмысленно
+	if code == http.StatusGone {
+		if err := d.webhooks.Fail(ctx, dl.ID, code, err); err != nil {
+			slog.ErrorContext(ctx, "mark webhook failed failed", "error", err, "webhook_delivery_id", dl.ID)
+		}
+		slog.WarnContext(ctx, "webhook dropped", "error", err, "webhook_delivery_id", dl.ID, "url", dl.URL)
+		metrics.WebhookDeliveries.WithLabelValues(event, resultFailed).Inc()
+		return
+	}
+
+	retried, rerr := d.webhooks.Retry(ctx, dl, retryAfter, code, err)
+	if rerr != nil {
+		slog.ErrorContext(ctx, "reschedule webhook failed", "error", rerr, "webhook_delivery_id", dl.ID)
+	}
+	if retried {
+		slog.WarnContext(ctx, "webhook delivery failed, will retry", "error", err, "webhook_delivery_id", dl.ID,
+			"url", dl.URL, "attempts", dl.Attempts)
+		metrics.WebhookDeliveries.WithLabelValues(event, resultRetry).Inc()
+		return
+	}
+	slog.ErrorContext(ctx, "webhook delivery failed permanently", "error", err, "webhook_delivery_id", dl.ID,
+		"url", dl.URL, "attempts", dl.Attempts)
+	metrics.WebhookDeliveries.WithLabelValues(event, resultFailed).Inc()
+}
+
+// post отправляет доставку и возвращает код ответа (0 — ответа нет), Retry-After получателя и ошибку.
+func (d *Dispatcher) post(ctx context.Context, dl domain.WebhookDelivery) (int, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dl.URL, bytes.NewReader(dl.Payload))
+	if err != nil {
+		return 0, 0, err
+	}
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "era-sporta-webhooks/1")
+	req.Header.Set(HeaderEvent, string(dl.Event))
+	req.Header.Set(HeaderEventID, dl.EventID)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(dl.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(d.secret, ts, dl.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, 0, nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	var retryAfter time.Duration
+	if sec, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && sec > 0 {
+		retryAfter = time.Duration(sec) * time.Second
+	}
+	return resp.StatusCode, retryAfter, fmt.Errorf("status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+}
@@ -0,0 +1,61 @@
+package webhook
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const testSecret = "whsec_test"
+
+// TestSignKnownVector: подпись совпадает с HMAC-SHA256, посчитанным независимо от кода.
+func TestSignKnownVector(t *testing.T) {
+	got := Sign(testSecret, 1767225600, []byte(`{"event":"user.registered"}`))
+	want := "sha256=ea857d43c51af0ad5ffc49cb45b105b6dbb01ca078862fd4258fe5f2df943a10"
+	if got != want {
+		t.Fatalf("Sign = %s, want %s", got, want)
+	}
+}
+
+// TestVerify: подписанный запрос проходит проверку, любая подмена — нет.
+func TestVerify(t *testing.T) {
+	now := time.Unix(1767225600, 0)
+	body := []byte(`{"event":"user.registered","data":{"phone":"+79001234567"}}`)
+	ts := strconv.FormatInt(now.Unix(), 10)
+	sig := Sign(testSecret, now.Unix(), body)
+
+	cases := []struct {
+		name      string
+		secret    string
+		timestamp string
+		signature string
+		body      []byte
+		now       time.Time
+		want      error
+	}{
+		{name: "round trip", secret: testSecret, timestamp: ts, signature: sig, body: body, now: now},
+		{name: "clock skew within tolerance", secret: testSecret, timestamp: ts, signature: sig, body: body, now: now.Add(DefaultTolerance)},
+		{name: "tampered body", secret: testSecret, timestamp: ts, signature: sig,
+			body: []byte(`{"event":"user.registered","data":{"phone":"+79007654321"}}`), now: now, want: ErrInvalidSignature},
+		{name: "tampered timestamp", secret: testSecret, timestamp: strconv.FormatInt(now.Unix()+1, 10), signature: sig, body: body, now: now,
+			want: ErrInvalidSignature},
+		{name: "other secret", secret: "whsec_other", timestamp: ts, signature: sig, body: body, now: now, want: ErrInvalidSignature},
+		{name: "no prefix", secret: testSecret, timestamp: ts, signature: sig[len(signaturePrefix):], body: body, now: now, want: ErrInvalidSignature},
+		{name: "malformed timestamp", secret: testSecret, timestamp: "yesterday", signature: sig, body: body, now: now, want: ErrInvalidSignature},
+		{name: "replayed later", secret: testSecret, timestamp: ts, signature: sig, body: body, now: now.Add(DefaultTolerance + time.Second),
+			want: ErrExpiredTimestamp},
+		{name: "from the future", secret: testSecret, timestamp: ts, signature: sig, body: body, now: now.Add(-DefaultTolerance - time.Second),
+			want: ErrExpiredTimestamp},
+		{name: "missing signature", secret: testSecret, timestamp: ts, body: body, now: now, want: ErrMissingSignature},
+		{name: "missing timestamp", secret: testSecret, signature: sig, body: body, now: now, want: ErrMissingSignature},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Verify(tc.secret, tc.timestamp, tc.signature, tc.body, tc.now, DefaultTolerance)
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("Verify = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
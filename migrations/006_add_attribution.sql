@@ -0,0 +1,20 @@
+-- +goose Up
+-- Атрибуция по start_param из ссылки t.me/<bot>/<app>?startapp=...
+ALTER TABLE users ADD COLUMN IF NOT EXISTS start_param VARCHAR(512);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS campaign VARCHAR(100);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS referrer_telegram_id BIGINT;
+
+ALTER TABLE spins ADD COLUMN IF NOT EXISTS start_param VARCHAR(512);
+ALTER TABLE spins ADD COLUMN IF NOT EXISTS campaign VARCHAR(100);
+
+CREATE INDEX IF NOT EXISTS idx_users_campaign ON users(campaign);
+CREATE INDEX IF NOT EXISTS idx_spins_campaign ON spins(campaign);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_spins_campaign;
+DROP INDEX IF EXISTS idx_users_campaign;
+ALTER TABLE spins DROP COLUMN IF EXISTS campaign;
+ALTER TABLE spins DROP COLUMN IF EXISTS start_param;
+ALTER TABLE users DROP COLUMN IF EXISTS referrer_telegram_id;
+ALTER TABLE users DROP COLUMN IF EXISTS campaign;
+ALTER TABLE users DROP COLUMN IF EXISTS start_param;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- Ваучер на выигранный приз: код, срок действия и статус получения
+ALTER TABLE spins ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'issued';
+ALTER TABLE spins ADD COLUMN IF NOT EXISTS voucher_code VARCHAR(32);
+ALTER TABLE spins ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMPTZ;
+ALTER TABLE spins ADD COLUMN IF NOT EXISTS redeemed_at TIMESTAMPTZ;
+ALTER TABLE spins ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+ALTER TABLE spins DROP CONSTRAINT IF EXISTS spins_status_check;
+ALTER TABLE spins ADD CONSTRAINT spins_status_check CHECK (status IN ('issued', 'claimed', 'redeemed', 'expired'));
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_spins_voucher_code ON spins(voucher_code);
+-- Курсорная пагинация истории: (created_at, id) по убыванию
+CREATE INDEX IF NOT EXISTS idx_spins_user_created_id ON spins(user_id, created_at DESC, id DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_spins_user_created_id;
+DROP INDEX IF EXISTS idx_spins_voucher_code;
+ALTER TABLE spins DROP CONSTRAINT IF EXISTS spins_status_check;
+ALTER TABLE spins DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE spins DROP COLUMN IF EXISTS redeemed_at;
+ALTER TABLE spins DROP COLUMN IF EXISTS claimed_at;
+ALTER TABLE spins DROP COLUMN IF EXISTS voucher_code;
+ALTER TABLE spins DROP COLUMN IF EXISTS status;
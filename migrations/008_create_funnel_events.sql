@@ -0,0 +1,15 @@
+-- +goose Up
+-- События воронки: /start, подписка, открытие Mini App. Номер и спин считаются по users и spins.
+CREATE TABLE IF NOT EXISTS funnel_events (
+    id BIGSERIAL PRIMARY KEY,
+    telegram_user_id BIGINT NOT NULL,
+    event VARCHAR(32) NOT NULL,
+    start_param VARCHAR(512),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_funnel_events_event_created ON funnel_events(event, created_at);
+CREATE INDEX IF NOT EXISTS idx_funnel_events_telegram_user_id ON funnel_events(telegram_user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS funnel_events;
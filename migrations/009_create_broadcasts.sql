@@ -0,0 +1,30 @@
+-- +goose Up
+-- Пользователь заблокировал бота: такие не попадают в рассылки
+ALTER TABLE users ADD COLUMN IF NOT EXISTS bot_blocked_at TIMESTAMPTZ;
+
+-- Рассылки пользователям из админского чата и статистика доставки
+CREATE TABLE IF NOT EXISTS broadcasts (
+    id BIGSERIAL PRIMARY KEY,
+    audience VARCHAR(20) NOT NULL,
+    prize_id INT REFERENCES prizes(id),
+    text TEXT NOT NULL DEFAULT '',
+    photo_file_id VARCHAR(255),
+    button_text VARCHAR(64),
+    button_url VARCHAR(512),
+    status VARCHAR(20) NOT NULL DEFAULT 'draft',
+    created_by BIGINT NOT NULL,
+    total INT NOT NULL DEFAULT 0,
+    sent INT NOT NULL DEFAULT 0,
+    failed INT NOT NULL DEFAULT 0,
+    blocked INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    CONSTRAINT broadcasts_status_check CHECK (status IN ('draft', 'running', 'done', 'cancelled', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_broadcasts_created_at ON broadcasts(created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS broadcasts;
+ALTER TABLE users DROP COLUMN IF EXISTS bot_blocked_at;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Пользователь отказался от напоминаний о сгорающих ваучерах
+ALTER TABLE users ADD COLUMN IF NOT EXISTS reminders_opt_out_at TIMESTAMPTZ;
+
+-- Отправленные напоминания: по одному на ваучер и порог (за сколько дней до истечения)
+CREATE TABLE IF NOT EXISTS voucher_reminders (
+    id BIGSERIAL PRIMARY KEY,
+    spin_id BIGINT NOT NULL REFERENCES spins(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    days_before INT NOT NULL,
+    sent_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT voucher_reminders_spin_days_key UNIQUE (spin_id, days_before)
+);
+
+CREATE INDEX IF NOT EXISTS idx_spins_expires_at ON spins(expires_at) WHERE status IN ('issued', 'claimed');
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_spins_expires_at;
+DROP TABLE IF EXISTS voucher_reminders;
+ALTER TABLE users DROP COLUMN IF EXISTS reminders_opt_out_at;
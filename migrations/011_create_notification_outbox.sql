@@ -0,0 +1,22 @@
+-- +goose Up
+-- Очередь исходящих сообщений бота: API кладёт сообщение в той же БД, процесс бота
+-- доставляет его с повторами, поэтому уведомления не теряются при сбоях Telegram.
+CREATE TABLE IF NOT EXISTS notification_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(20) NOT NULL,
+    chat_id BIGINT NOT NULL DEFAULT 0,
+    text TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMPTZ,
+    CONSTRAINT notification_outbox_kind_check CHECK (kind IN ('admin', 'user')),
+    CONSTRAINT notification_outbox_status_check CHECK (status IN ('pending', 'sent', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_outbox_pending ON notification_outbox(next_attempt_at) WHERE status = 'pending';
+
+-- +goose Down
+DROP TABLE IF EXISTS notification_outbox;
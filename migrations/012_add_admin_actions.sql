@@ -0,0 +1,12 @@
+-- +goose Up
+-- Пользователь заблокирован менеджером из админского чата
+ALTER TABLE users ADD COLUMN IF NOT EXISTS blocked_at TIMESTAMPTZ;
+
+-- Сообщения очереди с разметкой и inline-кнопками (уведомления админу о спинах)
+ALTER TABLE notification_outbox ADD COLUMN IF NOT EXISTS parse_mode VARCHAR(16);
+ALTER TABLE notification_outbox ADD COLUMN IF NOT EXISTS reply_markup JSONB;
+
+-- +goose Down
+ALTER TABLE notification_outbox DROP COLUMN IF EXISTS reply_markup;
+ALTER TABLE notification_outbox DROP COLUMN IF EXISTS parse_mode;
+ALTER TABLE users DROP COLUMN IF EXISTS blocked_at;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- Шаблоны уведомлений (text/template), изменённые менеджерами. Для событий без строки
+-- используется шаблон по умолчанию из кода (internal/notifier/templates.go).
+CREATE TABLE IF NOT EXISTS notification_templates (
+    event VARCHAR(32) PRIMARY KEY,
+    body TEXT NOT NULL,
+    updated_by BIGINT,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS notification_templates;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Получатель из NOTIFY_DESTINATIONS и событие, по которому сообщение попало в очередь
+ALTER TABLE notification_outbox ADD COLUMN IF NOT EXISTS destination VARCHAR(64);
+ALTER TABLE notification_outbox ADD COLUMN IF NOT EXISTS event VARCHAR(32);
+
+-- +goose Down
+ALTER TABLE notification_outbox DROP COLUMN IF EXISTS event;
+ALTER TABLE notification_outbox DROP COLUMN IF EXISTS destination;
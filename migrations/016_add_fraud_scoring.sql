@@ -0,0 +1,13 @@
+-- +goose Up
+-- Оценка риска спина (internal/fraud) и сигнал Telegram Premium из initData
+ALTER TABLE spins ADD COLUMN IF NOT EXISTS fraud_score INT NOT NULL DEFAULT 0;
+ALTER TABLE spins ADD COLUMN IF NOT EXISTS fraud_reasons TEXT[];
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_premium BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_spins_ip_hash_created_at ON spins(ip_hash, created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_spins_ip_hash_created_at;
+ALTER TABLE users DROP COLUMN IF EXISTS is_premium;
+ALTER TABLE spins DROP COLUMN IF EXISTS fraud_reasons;
+ALTER TABLE spins DROP COLUMN IF EXISTS fraud_score;
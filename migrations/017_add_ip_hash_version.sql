@@ -0,0 +1,8 @@
+-- +goose Up
+-- Версия ключа HMAC, которым посчитан ip_hash (internal/iphash). Старые хэши — несолёный SHA-256,
+-- который обращается перебором адресов, поэтому они удаляются; версия 0 означает «до ключей».
+ALTER TABLE spins ADD COLUMN IF NOT EXISTS ip_hash_version INT NOT NULL DEFAULT 0;
+UPDATE spins SET ip_hash = NULL WHERE ip_hash IS NOT NULL AND ip_hash_version = 0;
+
+-- +goose Down
+ALTER TABLE spins DROP COLUMN IF EXISTS ip_hash_version;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Блокировка пользователей менеджерами: причина и кто заблокировал (время — users.blocked_at)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS block_reason TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS blocked_by VARCHAR(255);
+
+-- Баны по номеру телефона (только цифры): блокируют и аккаунты, зарегистрированные с номером позже
+CREATE TABLE IF NOT EXISTS phone_bans (
+    phone VARCHAR(32) PRIMARY KEY,
+    reason TEXT,
+    banned_by VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_users_phone_digits ON users ((regexp_replace(phone, '\D', '', 'g')));
+CREATE INDEX IF NOT EXISTS idx_users_blocked_at ON users(blocked_at) WHERE blocked_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_users_blocked_at;
+DROP INDEX IF EXISTS idx_users_phone_digits;
+DROP TABLE IF EXISTS phone_bans;
+ALTER TABLE users DROP COLUMN IF EXISTS blocked_by;
+ALTER TABLE users DROP COLUMN IF EXISTS block_reason;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Использованные refresh-токены (jti): каждый обменивается на новую пару только один раз.
+-- Строки нужны до истечения токена, потом удаляются при очередном обмене.
+CREATE TABLE IF NOT EXISTS refresh_token_uses (
+    jti VARCHAR(64) PRIMARY KEY,
+    telegram_user_id BIGINT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_token_uses_expires_at ON refresh_token_uses(expires_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS refresh_token_uses;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Номер пользователя в индексе и запросах банов приводится к ключу phone_bans так же, как
+-- domain.PhoneBanKey: только цифры, российский 8XXXXXXXXXX как 7XXXXXXXXXX
+DROP INDEX IF EXISTS idx_users_phone_digits;
+CREATE INDEX IF NOT EXISTS idx_users_phone_ban_key ON users ((regexp_replace(regexp_replace(phone, '\D', '', 'g'), '^8(\d{10})$', '7\1')));
+
+-- Аккаунты с номером через 8, которые бан по 7 раньше не находил
+UPDATE users u SET blocked_at = NOW(), block_reason = b.reason, blocked_by = b.banned_by, updated_at = NOW()
+FROM phone_bans b
+WHERE b.phone = regexp_replace(regexp_replace(u.phone, '\D', '', 'g'), '^8(\d{10})$', '7\1') AND u.blocked_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_users_phone_ban_key;
+CREATE INDEX IF NOT EXISTS idx_users_phone_digits ON users ((regexp_replace(phone, '\D', '', 'g')));
@@ -0,0 +1,9 @@
+// Package scripts содержит SQL-скрипты обслуживания базы; init_db_all.sql встроен в cmd/initdb.
+package scripts
+
+import _ "embed"
+
+// InitDBAll — scripts/init_db_all.sql: полная схема (все миграции) и призы по умолчанию.
+//
+//go:embed init_db_all.sql
+var InitDBAll string
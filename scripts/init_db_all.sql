@@ -56,6 +56,179 @@ CREATE INDEX IF NOT EXISTS idx_spins_user_id ON spins(user_id);
 CREATE INDEX IF NOT EXISTS idx_spins_created_at ON spins(created_at);
 CREATE INDEX IF NOT EXISTS idx_spins_user_created ON spins(user_id, created_at);
 
+-- Migration 006: Attribution by start_param
+ALTER TABLE users ADD COLUMN IF NOT EXISTS start_param VARCHAR(512);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS campaign VARCHAR(100);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS referrer_telegram_id BIGINT;
+
+ALTER TABLE spins ADD COLUMN IF NOT EXISTS start_param VARCHAR(512);
+ALTER TABLE spins ADD COLUMN IF NOT EXISTS campaign VARCHAR(100);
+
+CREATE INDEX IF NOT EXISTS idx_users_campaign ON users(campaign);
+CREATE INDEX IF NOT EXISTS idx_spins_campaign ON spins(campaign);
+
+-- Migration 007: Spin vouchers
+ALTER TABLE spins ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'issued';
+ALTER TABLE spins ADD COLUMN IF NOT EXISTS voucher_code VARCHAR(32);
+ALTER TABLE spins ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMPTZ;
+ALTER TABLE spins ADD COLUMN IF NOT EXISTS redeemed_at TIMESTAMPTZ;
+ALTER TABLE spins ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+ALTER TABLE spins DROP CONSTRAINT IF EXISTS spins_status_check;
+ALTER TABLE spins ADD CONSTRAINT spins_status_check CHECK (status IN ('issued', 'claimed', 'redeemed', 'expired'));
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_spins_voucher_code ON spins(voucher_code);
+CREATE INDEX IF NOT EXISTS idx_spins_user_created_id ON spins(user_id, created_at DESC, id DESC);
+
+-- Migration 008: Funnel events
+CREATE TABLE IF NOT EXISTS funnel_events (
+    id BIGSERIAL PRIMARY KEY,
+    telegram_user_id BIGINT NOT NULL,
+    event VARCHAR(32) NOT NULL,
+    start_param VARCHAR(512),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_funnel_events_event_created ON funnel_events(event, created_at);
+CREATE INDEX IF NOT EXISTS idx_funnel_events_telegram_user_id ON funnel_events(telegram_user_id);
+
+-- Migration 009: Broadcasts
+ALTER TABLE users ADD COLUMN IF NOT EXISTS bot_blocked_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS broadcasts (
+    id BIGSERIAL PRIMARY KEY,
+    audience VARCHAR(20) NOT NULL,
+    prize_id INT REFERENCES prizes(id),
+    text TEXT NOT NULL DEFAULT '',
+    photo_file_id VARCHAR(255),
+    button_text VARCHAR(64),
+    button_url VARCHAR(512),
+    status VARCHAR(20) NOT NULL DEFAULT 'draft',
+    created_by BIGINT NOT NULL,
+    total INT NOT NULL DEFAULT 0,
+    sent INT NOT NULL DEFAULT 0,
+    failed INT NOT NULL DEFAULT 0,
+    blocked INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    CONSTRAINT broadcasts_status_check CHECK (status IN ('draft', 'running', 'done', 'cancelled', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_broadcasts_created_at ON broadcasts(created_at);
+
+-- Migration 010: Voucher reminders
+ALTER TABLE users ADD COLUMN IF NOT EXISTS reminders_opt_out_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS voucher_reminders (
+    id BIGSERIAL PRIMARY KEY,
+    spin_id BIGINT NOT NULL REFERENCES spins(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    days_before INT NOT NULL,
+    sent_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT voucher_reminders_spin_days_key UNIQUE (spin_id, days_before)
+);
+
+CREATE INDEX IF NOT EXISTS idx_spins_expires_at ON spins(expires_at) WHERE status IN ('issued', 'claimed');
+
+-- Migration 011: Notification outbox
+CREATE TABLE IF NOT EXISTS notification_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(20) NOT NULL,
+    chat_id BIGINT NOT NULL DEFAULT 0,
+    text TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMPTZ,
+    CONSTRAINT notification_outbox_kind_check CHECK (kind IN ('admin', 'user')),
+    CONSTRAINT notification_outbox_status_check CHECK (status IN ('pending', 'sent', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_outbox_pending ON notification_outbox(next_attempt_at) WHERE status = 'pending';
+
+-- Migration 012: Admin actions
+ALTER TABLE users ADD COLUMN IF NOT EXISTS blocked_at TIMESTAMPTZ;
+ALTER TABLE notification_outbox ADD COLUMN IF NOT EXISTS parse_mode VARCHAR(16);
+ALTER TABLE notification_outbox ADD COLUMN IF NOT EXISTS reply_markup JSONB;
+
+-- Migration 013: Notification templates
+CREATE TABLE IF NOT EXISTS notification_templates (
+    event VARCHAR(32) PRIMARY KEY,
+    body TEXT NOT NULL,
+    updated_by BIGINT,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Migration 014: Outbox routing
+ALTER TABLE notification_outbox ADD COLUMN IF NOT EXISTS destination VARCHAR(64);
+ALTER TABLE notification_outbox ADD COLUMN IF NOT EXISTS event VARCHAR(32);
+
+-- Migration 015: Webhook deliveries
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    event_id VARCHAR(32) NOT NULL,
+    event VARCHAR(32) NOT NULL,
+    url TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    response_code INT,
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMPTZ,
+    CONSTRAINT webhook_deliveries_status_check CHECK (status IN ('pending', 'delivered', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_created_at ON webhook_deliveries(created_at DESC);
+
+-- Migration 016: Fraud scoring
+ALTER TABLE spins ADD COLUMN IF NOT EXISTS fraud_score INT NOT NULL DEFAULT 0;
+ALTER TABLE spins ADD COLUMN IF NOT EXISTS fraud_reasons TEXT[];
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_premium BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_spins_ip_hash_created_at ON spins(ip_hash, created_at);
+
+-- Migration 017: IP hash version
+ALTER TABLE spins ADD COLUMN IF NOT EXISTS ip_hash_version INT NOT NULL DEFAULT 0;
+UPDATE spins SET ip_hash = NULL WHERE ip_hash IS NOT NULL AND ip_hash_version = 0;
+
+-- Migration 018: User blocklist
+ALTER TABLE users ADD COLUMN IF NOT EXISTS block_reason TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS blocked_by VARCHAR(255);
+
+CREATE TABLE IF NOT EXISTS phone_bans (
+    phone VARCHAR(32) PRIMARY KEY,
+    reason TEXT,
+    banned_by VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_users_phone_digits ON users ((regexp_replace(phone, '\D', '', 'g')));
+CREATE INDEX IF NOT EXISTS idx_users_blocked_at ON users(blocked_at) WHERE blocked_at IS NOT NULL;
+
+-- Migration 019: Refresh token rotation
+CREATE TABLE IF NOT EXISTS refresh_token_uses (
+    jti VARCHAR(64) PRIMARY KEY,
+    telegram_user_id BIGINT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_token_uses_expires_at ON refresh_token_uses(expires_at);
+
+-- Migration 020: Phone ban key normalization
+DROP INDEX IF EXISTS idx_users_phone_digits;
+CREATE INDEX IF NOT EXISTS idx_users_phone_ban_key ON users ((regexp_replace(regexp_replace(phone, '\D', '', 'g'), '^8(\d{10})$', '7\1')));
+
+UPDATE users u SET blocked_at = NOW(), block_reason = b.reason, blocked_by = b.banned_by, updated_at = NOW()
+FROM phone_bans b
+WHERE b.phone = regexp_replace(regexp_replace(u.phone, '\D', '', 'g'), '^8(\d{10})$', '7\1') AND u.blocked_at IS NULL;
+
 -- Success message
 DO $$
 BEGIN
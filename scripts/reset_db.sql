@@ -1,5 +1,5 @@
 -- Очистка всех данных БД (таблицы остаются)
-TRUNCATE TABLE spins, users, prizes RESTART IDENTITY CASCADE;
+TRUNCATE TABLE refresh_token_uses, phone_bans, webhook_deliveries, notification_outbox, voucher_reminders, broadcasts, funnel_events, spins, users, prizes RESTART IDENTITY CASCADE;
 
 -- Восстановление призов по умолчанию
 INSERT INTO prizes (name, type, value, probability_weight, is_active) VALUES
@@ -25,8 +25,10 @@ check_service() {
         echo "  $name: ✓ работает (PID: $pid)"
         
         if [ ! -z "$port" ]; then
-            if nc -z localhost $port 2>/dev/null || curl -s http://localhost:$port >/dev/null 2>&1; then
-                echo "         Порт $port: ✓ доступен"
+            if curl -fs http://localhost:$port/readyz >/dev/null 2>&1; then
+                echo "         /readyz на порту $port: ✓ готов"
+            elif curl -fs http://localhost:$port/healthz >/dev/null 2>&1; then
+                echo "         /readyz на порту $port: ⚠️  зависимости недоступны (curl localhost:$port/readyz)"
             else
                 echo "         Порт $port: ⚠️  недоступен"
             fi
@@ -53,7 +55,7 @@ fi
 # Проверка сервисов
 echo "Сервисы:"
 check_service "api" ${API_PORT:-8080}
-check_service "bot"
+check_service "bot" ${BOT_HTTP_PORT:-8081}
 check_service "web"
 
 echo ""
@@ -0,0 +1,351 @@
+// Code generated by go run ./cmd/apiclientgen; DO NOT EDIT.
+// Типизированный клиент API Mini App по internal/api/openapi/openapi.json.
+// Для изменения поправьте спецификацию и выполните go generate ./web.
+
+/**
+ * Результат вызова: data — тело успешного ответа, error — тело ошибки API.
+ * status 0 означает, что запрос не дошёл до сервера.
+ * @template T
+ * @typedef {Object} ApiResult
+ * @property {boolean} ok
+ * @property {number} status
+ * @property {T} [data]
+ * @property {ErrorResponse} [error]
+ */
+
+/**
+ * @typedef {Object} AuthRequest
+ * @property {string} initData Telegram.WebApp.initData
+ */
+
+/**
+ * @typedef {Object} AuthResponse
+ * @property {string} expires_at
+ * @property {string} refresh_expires_at
+ * @property {string} refresh_token
+ * @property {string} [start_param]
+ * @property {UserState} state
+ * @property {string} token
+ * @property {User} user
+ */
+
+/**
+ * @typedef {Object} BlockRequest
+ * @property {string} [actor] Кто блокирует; по умолчанию admin_api
+ * @property {string} [reason] Причина, видна менеджерам в /blocked
+ */
+
+/**
+ * @typedef {Object} BlockedUser
+ * @property {string} [block_reason]
+ * @property {boolean} blocked
+ * @property {string} [blocked_at]
+ * @property {string} [blocked_by]
+ * @property {string} first_name
+ * @property {number} id
+ * @property {string} last_name
+ * @property {string} phone
+ * @property {number} telegram_user_id
+ * @property {string} username
+ */
+
+/**
+ * @typedef {Object} BlocklistResponse
+ * @property {Array<PhoneBan>} phones
+ * @property {Array<BlockedUser>} users
+ */
+
+/**
+ * @typedef {Object} ConfigResponse
+ * @property {Array<Prize>} prizes
+ */
+
+/**
+ * @typedef {Object} DailyStats
+ * @property {string} date
+ * @property {number} spins
+ * @property {Object<string, number>} steps Уникальные пользователи по шагам воронки
+ */
+
+/**
+ * @typedef {Object} ErrorResponse
+ * @property {ErrorCode} error
+ * @property {string} message Локализованное сообщение (Accept-Language или ?lang=, по умолчанию ru)
+ * @property {string} [request_id]
+ */
+
+/**
+ * @typedef {('bad_request'|'init_data_required'|'invalid_init_data'|'unauthorized'|'invalid_token'|'forbidden'|'not_found'|'user_not_found'|'phone_required'|'spin_limit_exceeded'|'campaign_closed'|'spin_rejected'|'user_blocked'|'rate_limited'|'nonce_required'|'nonce_reused'|'internal_error')} ErrorCode
+ */
+
+/**
+ * @typedef {Object} FunnelStep
+ * @property {number} conversion_prev Доля от предыдущего шага (0..1)
+ * @property {number} conversion_start Доля от шага start (0..1)
+ * @property {('start'|'subscribed'|'phone_shared'|'app_opened'|'spun')} step
+ * @property {number} users
+ */
+
+/**
+ * @typedef {Object} HealthReport
+ * @property {Object<string, {detail?: string, error?: string, optional?: boolean, status: string}>} [checks]
+ * @property {('ok'|'fail')} status
+ */
+
+/**
+ * @typedef {Object} HistoryResponse
+ * @property {Array<SpinHistoryItem>} history
+ * @property {string} [next_cursor] Отсутствует на последней странице
+ * @property {number} total Число спинов по фильтру без учёта пагинации
+ */
+
+/**
+ * @typedef {Object} PhoneBan
+ * @property {string} [banned_by]
+ * @property {string} created_at
+ * @property {string} phone Только цифры, с кодом страны
+ * @property {string} [reason]
+ */
+
+/**
+ * @typedef {Object} PhoneBanResponse
+ * @property {number} blocked_users Сколько аккаунтов с номером заблокировано сейчас
+ * @property {string} phone Номер, как он сохранён в бане (только цифры)
+ */
+
+/**
+ * @typedef {Object} Prize
+ * @property {number} id
+ * @property {string} name
+ * @property {string} type
+ * @property {number} value
+ * @property {number} weight
+ */
+
+/**
+ * @typedef {Object} PrizeStats
+ * @property {number} actual_rate Фактическая доля выигрышей за период
+ * @property {number} configured_rate Доля по весам среди разыгрываемых призов
+ * @property {boolean} is_active
+ * @property {string} name
+ * @property {number} prize_id
+ * @property {number} spins
+ * @property {number} weight
+ */
+
+/**
+ * @typedef {Object} RefreshRequest
+ * @property {string} refresh_token
+ */
+
+/**
+ * @typedef {Object} Spin
+ * @property {string} campaign
+ * @property {string} created_at
+ * @property {string} [expires_at]
+ * @property {number} id
+ * @property {number} prize_id
+ * @property {string} prize_name
+ * @property {string} prize_type
+ * @property {SpinStatus} status
+ * @property {number} stop_segment Сегмент колеса, на котором остановится анимация
+ * @property {number} value
+ * @property {string} [voucher_code]
+ */
+
+/**
+ * @typedef {Object} SpinHistoryItem
+ * @property {string} [claimed_at]
+ * @property {string} created_at
+ * @property {string} [expires_at]
+ * @property {number} id
+ * @property {string} prize_name
+ * @property {string} prize_type
+ * @property {string} [redeemed_at]
+ * @property {SpinStatus} status
+ * @property {number} value
+ * @property {string} [voucher_code]
+ */
+
+/**
+ * @typedef {Object} SpinResponse
+ * @property {Spin} spin
+ */
+
+/**
+ * Статус ваучера: выдан, получен (клиент связался), использован, просрочен
+ * @typedef {('issued'|'claimed'|'redeemed'|'expired')} SpinStatus
+ */
+
+/**
+ * @typedef {Object} StatsResponse
+ * @property {Array<DailyStats>} daily
+ * @property {string} from
+ * @property {Array<FunnelStep>} funnel
+ * @property {Array<PrizeStats>} prizes
+ * @property {string} timezone
+ * @property {string} to
+ * @property {number} unique_users Уникальные пользователи, дошедшие хотя бы до одного шага
+ */
+
+/**
+ * @typedef {Object} User
+ * @property {string} first_name
+ * @property {number} id
+ * @property {string} last_name
+ * @property {string} phone
+ * @property {number} telegram_user_id
+ * @property {string} username
+ */
+
+/**
+ * @typedef {Object} UserResponse
+ * @property {UserState} state
+ * @property {User} user
+ */
+
+/**
+ * @typedef {Object} UserState
+ * @property {boolean} spin_available
+ * @property {number} spin_limit
+ * @property {number} spins_used
+ */
+
+/**
+ * @typedef {Object} WebhookDelivery
+ * @property {number} attempts
+ * @property {string} created_at
+ * @property {string} [delivered_at]
+ * @property {('user.registered'|'spin.created'|'voucher.redeemed')} event
+ * @property {string} event_id Одинаков для всех URL и повторов события
+ * @property {number} id Совпадает с заголовком X-Webhook-Delivery
+ * @property {string} [last_error]
+ * @property {Object} payload Тело запроса: event_id, event, occurred_at, data
+ * @property {number} [response_code] HTTP-код последнего ответа получателя
+ * @property {('pending'|'delivered'|'failed')} status
+ * @property {string} url
+ */
+
+/**
+ * @typedef {Object} WebhookLogResponse
+ * @property {Array<WebhookDelivery>} deliveries
+ */
+
+(function (global) {
+  'use strict';
+
+  /**
+   * Создаёт клиент API.
+   * @param {{baseUrl?: string, getToken?: function(): string}} [options]
+   *   baseUrl — origin API (по умолчанию тот же), getToken — текущий access-токен сессии.
+   */
+  function createApiClient(options) {
+    const baseUrl = (options && options.baseUrl) || '';
+    const getToken = (options && options.getToken) || (() => '');
+
+    async function call(method, path, spec, params, body) {
+      params = params || {};
+      let url = baseUrl + path.replace(/\{(\w+)\}/g, (_, name) => encodeURIComponent(params[name]));
+      const query = new URLSearchParams();
+      spec.query.forEach(name => {
+        if (params[name] !== undefined && params[name] !== null && params[name] !== '') {
+          query.append(name, String(params[name]));
+        }
+      });
+      if (query.toString()) url += '?' + query.toString();
+
+      const headers = {};
+      spec.headers.forEach(name => {
+        if (params[name] !== undefined && params[name] !== null) headers[name] = String(params[name]);
+      });
+      if (spec.session) {
+        const token = getToken();
+        if (token) headers['Authorization'] = 'Bearer ' + token;
+      }
+      if (body !== undefined) headers['Content-Type'] = 'application/json';
+
+      let res;
+      try {
+        res = await fetch(url, { method, headers, body: body === undefined ? undefined : JSON.stringify(body) });
+      } catch (_) {
+        return { ok: false, status: 0 };
+      }
+      const payload = await res.json().catch(() => undefined);
+      return res.ok
+        ? { ok: true, status: res.status, data: payload }
+        : { ok: false, status: res.status, error: payload };
+    }
+
+    return {
+      /**
+       * Обмен initData на сессионный токен
+       * @param {AuthRequest} body
+       * @returns {Promise<ApiResult<AuthResponse>>}
+       */
+      auth(body) {
+        return call('POST', '/api/auth', { query: [], headers: [], session: false }, undefined, body);
+      },
+
+      /**
+       * Обновление сессии по refresh-токену
+       * @param {RefreshRequest} body
+       * @returns {Promise<ApiResult<AuthResponse>>}
+       */
+      refreshSession(body) {
+        return call('POST', '/api/auth/refresh', { query: [], headers: [], session: false }, undefined, body);
+      },
+
+      /**
+       * Эта спецификация
+       * @returns {Promise<ApiResult<Object>>}
+       */
+      getOpenAPI() {
+        return call('GET', '/api/openapi.json', { query: [], headers: [], session: false }, undefined, undefined);
+      },
+
+      /**
+       * Активные призы колеса
+       * @returns {Promise<ApiResult<ConfigResponse>>}
+       */
+      getRouletteConfig() {
+        return call('GET', '/api/roulette/config', { query: [], headers: [], session: false }, undefined, undefined);
+      },
+
+      /**
+       * История спинов пользователя (курсорная пагинация, от новых к старым)
+       * @param {{limit?: number, cursor?: string, status?: SpinStatus, from?: string, to?: string}} [params]
+       * @returns {Promise<ApiResult<HistoryResponse>>}
+       */
+      getSpinHistory(params) {
+        return call('GET', '/api/roulette/history', { query: ['limit', 'cursor', 'status', 'from', 'to'], headers: [], session: true }, params, undefined);
+      },
+
+      /**
+       * Вращение колеса
+       * @param {{'X-Request-Nonce'?: string}} [params]
+       * @returns {Promise<ApiResult<SpinResponse>>}
+       */
+      spin(params) {
+        return call('POST', '/api/roulette/spin', { query: [], headers: ['X-Request-Nonce'], session: true }, params, undefined);
+      },
+
+      /**
+       * Профиль и состояние пользователя
+       * @returns {Promise<ApiResult<UserResponse>>}
+       */
+      getMe() {
+        return call('GET', '/api/user/me', { query: [], headers: [], session: true }, undefined, undefined);
+      },
+
+      /**
+       * Состояние пользователя (то же, что /api/user/me)
+       * @returns {Promise<ApiResult<UserResponse>>}
+       */
+      getState() {
+        return call('GET', '/api/user/state', { query: [], headers: [], session: true }, undefined, undefined);
+      },
+    };
+  }
+
+  global.EraSportaApi = { createApiClient };
+})(window);
@@ -0,0 +1,25 @@
+package web
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"era_sporta_bot_ruletka/internal/api/openapi"
+	"era_sporta_bot_ruletka/internal/api/openapi/jsclient"
+)
+
+// TestAPIClientUpToDate проверяет, что api.js сгенерирован из текущего openapi.json.
+func TestAPIClientUpToDate(t *testing.T) {
+	want, err := jsclient.Generate(openapi.Spec)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	got, err := os.ReadFile("api.js")
+	if err != nil {
+		t.Fatalf("read api.js: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatal("web/api.js устарел: выполните go generate ./web")
+	}
+}
@@ -0,0 +1,10 @@
+// Package web содержит статические файлы Mini App, встроенные в бинарник cmd/app.
+package web
+
+import "embed"
+
+// FS — index.html и ассеты Mini App. api.js генерируется из спецификации API.
+//
+//go:generate go run ../cmd/apiclientgen -o api.js
+//go:embed index.html api.js logo.png
+var FS embed.FS